@@ -3,65 +3,749 @@ package main
 import (
 	"context"
 	"log/slog"
+	"qore-be/internal/attribute"
+	"qore-be/internal/breaker"
+	"qore-be/internal/cache"
+	"qore-be/internal/certs"
 	"qore-be/internal/config"
+	"qore-be/internal/database"
+	"qore-be/internal/debug"
+	"qore-be/internal/feature"
+	"qore-be/internal/health"
+	"qore-be/internal/logging"
+	"qore-be/internal/metrics"
+	"qore-be/internal/middleware"
+	"qore-be/internal/migrate"
 	"qore-be/internal/person"
+	"qore-be/internal/redact"
+	"qore-be/internal/relation"
+	"qore-be/internal/secrets"
 	"qore-be/internal/server"
+	"qore-be/internal/tracing"
 
+	"crypto/tls"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
+	"slices"
+	"strconv"
+	"sync"
 	"syscall"
+	"text/tabwriter"
+	"time"
 
-	"gorm.io/driver/mysql"
+	"github.com/redis/go-redis/v9"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"gorm.io/gorm"
 )
 
 func main() {
-	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-		AddSource: true,
-	})))
+	cfg, args := loadConfig(os.Args[1:])
+	level := setupLogger(cfg)
 
-	cfg := loadConfig()
-	start(cfg)
+	if len(args) == 0 {
+		provider := resolveSecrets(cfg)
+		start(cfg, level, config.NewReloader(cfg, os.Args[1:], config.WithResolver(func(ctx context.Context, cfg *config.Config) error {
+			return cfg.ResolveSecrets(ctx, provider)
+		})))
+		return
+	}
+
+	switch args[0] {
+	case "migrate":
+		resolveSecrets(cfg)
+		runMigrate(cfg, args[1:])
+	case "config":
+		runConfig(cfg, args[1:])
+	default:
+		log.Fatalf("unknown command: %q", args[0])
+	}
 }
 
-func start(cfg *config.Config) {
-	ctx, cancel := context.WithCancel(context.Background())
+// start serves the API until SIGINT or SIGTERM, then shuts down in order: the
+// server drains its requests, the background workers stop, the databases are
+// closed and the spans flushed.
+func start(cfg *config.Config, level *logging.LevelSwitch, reloader *config.Reloader) {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-	db := setupDB(cfg.DBUrl)
-	srv := server.New(
+	// the workers outlive ctx, serving the requests in flight.
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	run := func(worker func(context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			worker(workersCtx)
+		}()
+	}
+	// closed in reverse order, once the workers stopped.
+	var closers []func() error
+
+	m := metrics.New()
+	tp := setupTracing(ctx, cfg)
+	features := newFeatures(cfg, reloader)
+	opts := []server.Option{
 		server.WithConfig(cfg),
-		server.WithPersonController(newPersonCtrl(db)),
+		server.WithMetrics(m),
+		server.WithTracerProvider(tp),
+		server.WithDebugController(newDebugCtrl(level)),
+		server.WithOrigins(newOrigins(cfg, reloader)),
+		server.WithRateLimiter(newRateLimiter(cfg, reloader)),
+		server.WithFeatures(features),
+	}
+	subscribeLogLevel(reloader, level)
+	if cfg.TLSCertFile != "" {
+		loader := newCertLoader(cfg)
+		opts = append(opts, server.WithTLS(loader))
+		run(func(ctx context.Context) {
+			if err := loader.Watch(ctx); err != nil {
+				slog.Error("failed to watch the TLS certificates", "error", err.Error())
+			}
+		})
+	}
+
+	if cfg.DBDriver == "memory" {
+		// demo mode: the persons are lost on exit and the features needing a
+		// database are disabled.
+		slog.Warn("DB_DRIVER is memory: the persons are lost on exit, relationships and custom attributes are disabled")
+		opts = append(opts,
+			server.WithHealthController(newHealthCtrl(cfg)),
+			server.WithPersonController(newPersonCtrl(cfg, person.NewMemoryRepository(), nil, features, m, tp)),
+		)
+	} else {
+		db := setupDB(ctx, cfg, m, tp, reloader)
+		closers = append(closers, func() error { return database.Close(db) })
+		migrator := newMigrator(cfg, db)
+		if cfg.MigrateOnStart {
+			if err := migrator.Up(ctx); err != nil {
+				log.Fatalf("failed to migrate the database: %v", err)
+			}
+		}
+
+		repoOpts := []person.RepoOption{person.WithQueryTimeout(cfg.DBQueryTimeout)}
+		if replicas := setupReplicas(ctx, cfg, db, m, tp, reloader); replicas != nil {
+			closers = append(closers, replicas.Close)
+			run(func(ctx context.Context) { replicas.Watch(ctx, cfg.DBReplicaCheckInterval, cfg.HealthTimeout) })
+			repoOpts = append(repoOpts, person.WithReplicas(replicas))
+			opts = append(opts, server.WithReplicas(replicas))
+		}
+
+		personRepo := person.NewRepository(db, repoOpts...)
+		run(func(ctx context.Context) { indexBlockingKeys(ctx, personRepo) })
+
+		b := newBreaker(cfg, m)
+		persons := newPersonRepo(cfg, person.NewGuardedRepository(personRepo, b), m, reloader)
+		attrs := newAttributeSvc(cfg, db, b, persons)
+		opts = append(opts,
+			server.WithHealthController(newHealthCtrl(cfg,
+				health.WithCheck("database", health.DBCheck(db)),
+				health.WithMigrationStatus(migrationStatus(cfg, db)),
+				health.WithBreakerState(func() string { return b.State().String() }),
+			)),
+			server.WithPersonController(newPersonCtrl(cfg, persons, attrs, features, m, tp)),
+			server.WithRelationController(newRelationCtrl(cfg, db, b)),
+			server.WithAttributeController(newAttributeCtrl(attrs)),
+		)
+	}
+
+	srv := server.New(opts...)
+	if cfg.DebugHost != "" {
+		run(func(ctx context.Context) { startDebug(ctx, cfg) })
+	}
+	run(func(ctx context.Context) { watchConfig(ctx, cfg, reloader) })
+
+	err := srv.Start(ctx)
+	if err != nil {
+		slog.Error("server stopped", "error", err.Error())
+	}
+	// a second signal kills the process.
+	stop()
+
+	stopWorkers()
+	workers.Wait()
+
+	for i := len(closers) - 1; i >= 0; i-- {
+		if cerr := closers[i](); cerr != nil {
+			slog.Error("failed to close the database", "error", cerr.Error())
+		}
+	}
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+	if terr := tp.Shutdown(shutdownCtx); terr != nil {
+		slog.Error("failed to flush the spans", "error", terr.Error())
+	}
+
+	if err != nil {
+		shutdownCancel()
+		os.Exit(1)
+	}
+	slog.Info("shut down")
+}
+
+// loadConfig loads the configuration, returning the arguments left after
+// the flags.
+func loadConfig(args []string) (*config.Config, []string) {
+	cfg, args, err := config.Load(args)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		log.Fatalf("invalid configuration:\n%v", err)
+	}
+
+	return cfg, args
+}
+
+// resolveSecrets sets the settings held by the secrets provider, returning
+// the provider.
+func resolveSecrets(cfg *config.Config) secrets.Provider {
+	var provider secrets.Provider
+	switch cfg.SecretsProvider {
+	case "none":
+		return nil
+	case "file":
+		provider = secrets.NewDir(cfg.SecretsDir)
+	case "vault":
+		vault, err := secrets.NewVault(cfg.VaultAddr, cfg.VaultToken, cfg.VaultMount)
+		if err != nil {
+			log.Fatalf("failed to create the secrets provider: %v", err)
+		}
+		provider = vault
+	default:
+		log.Fatalf("unknown secrets provider: %q", cfg.SecretsProvider)
+	}
+
+	if err := cfg.ResolveSecrets(context.Background(), provider); err != nil {
+		log.Fatalf("invalid configuration:\n%v", err)
+	}
+	return provider
+}
+
+// runConfig runs the `config show` command, printing the effective
+// configuration.
+func runConfig(cfg *config.Config, args []string) {
+	if len(args) != 1 || args[0] != "show" {
+		log.Fatal("usage: config show")
+	}
+
+	if err := cfg.Show(os.Stdout); err != nil {
+		log.Fatalf("failed to show the configuration: %v", err)
+	}
+}
+
+func setupLogger(cfg *config.Config) *logging.LevelSwitch {
+	policy, err := redact.ParsePolicy(cfg.LogRedact, cfg.LogRedactKey)
+	if err != nil {
+		log.Fatalf("failed to setup log redaction: %v", err)
+	}
+	redact.SetPolicy(policy)
+
+	lvl, err := logging.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		log.Fatalf("failed to setup logger: %v", err)
+	}
+
+	level := logging.NewLevelSwitch(lvl)
+	logger, err := logging.New(os.Stdout, cfg.LogFormat, level)
+	if err != nil {
+		log.Fatalf("failed to setup logger: %v", err)
+	}
+
+	slog.SetDefault(logger)
+	return level
+}
+
+// watchConfig reloads the configuration on SIGHUP, whenever its file changes
+// and every SecretsRefreshInterval, picking up the rotated secrets, until ctx
+// is done.
+func watchConfig(ctx context.Context, cfg *config.Config, reloader *config.Reloader) {
+	var watcher sync.WaitGroup
+	defer watcher.Wait()
+	watcher.Add(1)
+	go func() {
+		defer watcher.Done()
+		if err := reloader.Watch(ctx); err != nil {
+			slog.Error("failed to watch the configuration", "error", err.Error())
+		}
+	}()
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var refresh <-chan time.Time
+	if cfg.SecretsRefreshInterval > 0 {
+		ticker := time.NewTicker(cfg.SecretsRefreshInterval)
+		defer ticker.Stop()
+		refresh = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-refresh:
+			if err := reloader.Reload(ctx); err != nil {
+				slog.Error("failed to refresh the secrets, keeping the current ones", "error", err.Error())
+			}
+		case <-hup:
+			if err := reloader.Reload(ctx); err != nil {
+				slog.Error("failed to reload the configuration, keeping the current one", "error", err.Error())
+			}
+		}
+	}
+}
+
+// onCommit returns the function finishing a reload which applies it, once
+// committed.
+func onCommit(apply func()) func(bool) {
+	return func(commit bool) {
+		if commit {
+			apply()
+		}
+	}
+}
+
+// subscribeLogLevel applies the reloaded log level, which replaces any
+// temporary one.
+func subscribeLogLevel(reloader *config.Reloader, level *logging.LevelSwitch) {
+	reloader.Subscribe(func(old *config.Config, cfg *config.Config) (func(bool), error) {
+		lvl, err := logging.ParseLevel(cfg.LogLevel)
+		if err != nil {
+			return nil, err
+		}
+		return onCommit(func() {
+			if cfg.LogLevel != old.LogLevel {
+				level.Set(lvl, 0)
+			}
+		}), nil
+	})
+}
+
+func newOrigins(cfg *config.Config, reloader *config.Reloader) *middleware.Origins {
+	origins := middleware.NewOrigins(cfg.CORSOrigins)
+	reloader.Subscribe(func(_ *config.Config, cfg *config.Config) (func(bool), error) {
+		return onCommit(func() { origins.Set(cfg.CORSOrigins) }), nil
+	})
+
+	return origins
+}
+
+func newRateLimiter(cfg *config.Config, reloader *config.Reloader) *middleware.RateLimiter {
+	limiter := middleware.NewRateLimiter(cfg.RateLimit, cfg.RateLimitBurst)
+	reloader.Subscribe(func(old *config.Config, cfg *config.Config) (func(bool), error) {
+		return onCommit(func() {
+			if cfg.RateLimit != old.RateLimit || cfg.RateLimitBurst != old.RateLimitBurst {
+				limiter.SetLimit(cfg.RateLimit, cfg.RateLimitBurst)
+			}
+		}), nil
+	})
+
+	return limiter
+}
+
+func newFeatures(cfg *config.Config, reloader *config.Reloader) *feature.Flags {
+	flags, err := feature.NewFlags(cfg.Features)
+	if err != nil {
+		log.Fatalf("invalid feature flags: %v", err)
+	}
+
+	reloader.Subscribe(func(_ *config.Config, cfg *config.Config) (func(bool), error) {
+		if err := feature.Check(cfg.Features); err != nil {
+			return nil, err
+		}
+		return onCommit(func() {
+			// the flags are checked above.
+			_ = flags.Set(cfg.Features)
+		}), nil
+	})
+
+	return flags
+}
+
+// startDebug serves the pprof and expvar routes on the admin listener, until
+// ctx is done.
+func startDebug(ctx context.Context, cfg *config.Config) {
+	srv := &http.Server{
+		Addr:    cfg.DebugHost,
+		Handler: debug.NewHandler(cfg.AdminToken, cfg.DebugPprof, cfg.DebugExpvar),
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.ListenAndServe()
+	}()
+
+	slog.Info("admin listener started", "addr", cfg.DebugHost)
+	select {
+	case err := <-errc:
+		slog.Error("failed to start the admin listener", "error", err.Error())
+		return
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = srv.Shutdown(shutdownCtx)
+}
+
+func newCertLoader(cfg *config.Config) *certs.Loader {
+	var opts []certs.LoaderOption
+	switch cfg.TLSClientAuth {
+	case "optional":
+		opts = append(opts, certs.WithClientCA(cfg.TLSClientCAFile, tls.VerifyClientCertIfGiven))
+	case "require":
+		opts = append(opts, certs.WithClientCA(cfg.TLSClientCAFile, tls.RequireAndVerifyClientCert))
+	}
+
+	loader, err := certs.NewLoader(cfg.TLSCertFile, cfg.TLSKeyFile, opts...)
+	if err != nil {
+		log.Fatalf("failed to load the TLS certificates: %v", err)
+	}
+
+	return loader
+}
+
+func newDebugCtrl(level *logging.LevelSwitch) *debug.Controller {
+	ctrl, err := debug.NewController(debug.WithLevelSwitch(level))
+	if err != nil {
+		log.Fatalf("failed to create debug controller: %v", err)
+	}
+
+	return ctrl
+}
+
+func setupTracing(ctx context.Context, cfg *config.Config) *sdktrace.TracerProvider {
+	tp, err := tracing.NewProvider(ctx, cfg.TracingExporter, cfg.TracingEndpoint)
+	if err != nil {
+		log.Fatalf("failed to setup tracing: %v", err)
+	}
+
+	return tp
+}
+
+func setupDB(ctx context.Context, cfg *config.Config, m *metrics.Metrics, tp *sdktrace.TracerProvider, reloader *config.Reloader) *gorm.DB {
+	db, err := openDB(ctx, cfg, cfg.DBUrl)
+	if err != nil {
+		log.Fatalf("failed to connect to the database: %v", err)
+	}
+	instrumentDB(cfg, db, "main", m, tp)
+
+	// the rotated credentials are checked before the switch: the reload is
+	// rejected, keeping the current connections, when they do not work.
+	reloader.Subscribe(func(old *config.Config, cfg *config.Config) (func(bool), error) {
+		if cfg.DBUrl == old.DBUrl {
+			return func(bool) {}, nil
+		}
+
+		finish, err := database.Rotate(ctx, db, cfg.DBDriver, cfg.DBUrl, poolOption(cfg))
+		if err != nil {
+			return nil, fmt.Errorf("failed to reconnect to the database: %w", err)
+		}
+		return func(commit bool) {
+			finish(commit)
+			if commit {
+				slog.Info("database connections rotated")
+			}
+		}, nil
+	})
+
+	return db
+}
+
+// setupReplicas connects to the read replicas, if any. The unreachable ones
+// are left out.
+func setupReplicas(ctx context.Context, cfg *config.Config, primary *gorm.DB, m *metrics.Metrics, tp *sdktrace.TracerProvider, reloader *config.Reloader) *database.Replicas {
+	if len(cfg.DBReplicaURLs) == 0 {
+		return nil
+	}
+
+	dbs := []*gorm.DB{}
+	// opened holds the replica of each url, nil when left out.
+	opened := make([]*gorm.DB, len(cfg.DBReplicaURLs))
+	for i, url := range cfg.DBReplicaURLs {
+		db, err := openDB(ctx, cfg, url)
+		if err != nil {
+			slog.Error("failed to connect to a read replica, leaving it out", "index", i, "error", err.Error())
+			continue
+		}
+		dbs = append(dbs, db)
+		opened[i] = db
+		instrumentDB(cfg, db, fmt.Sprintf("replica-%d", len(dbs)), m, tp)
+	}
+
+	// the replicas are rotated as the primary is, all or none of them.
+	reloader.Subscribe(func(old *config.Config, cfg *config.Config) (func(bool), error) {
+		if slices.Equal(cfg.DBReplicaURLs, old.DBReplicaURLs) {
+			return func(bool) {}, nil
+		}
+		if len(cfg.DBReplicaURLs) != len(old.DBReplicaURLs) {
+			return nil, errors.New("DB_REPLICA_URLS needs a restart to add or remove replicas")
+		}
+
+		finishes := []func(bool){}
+		for i, url := range cfg.DBReplicaURLs {
+			if opened[i] == nil || url == old.DBReplicaURLs[i] {
+				continue
+			}
+			finish, err := database.Rotate(ctx, opened[i], cfg.DBDriver, url, poolOption(cfg))
+			if err != nil {
+				for _, f := range finishes {
+					f(false)
+				}
+				return nil, fmt.Errorf("failed to reconnect to the read replica %d: %w", i+1, err)
+			}
+			finishes = append(finishes, finish)
+		}
+
+		return func(commit bool) {
+			for _, f := range finishes {
+				f(commit)
+			}
+			if commit && len(finishes) > 0 {
+				slog.Info("read replica connections rotated", "replicas", len(finishes))
+			}
+		}, nil
+	})
+
+	replicas, err := database.NewReplicas(primary, dbs, database.WithStickyWrites(cfg.DBReplicaSticky))
+	if err != nil {
+		log.Fatalf("failed to setup the read replicas: %v", err)
+	}
+
+	return replicas
+}
+
+// instrumentDB records the metrics and spans of the queries of the database.
+func instrumentDB(cfg *config.Config, db *gorm.DB, name string, m *metrics.Metrics, tp *sdktrace.TracerProvider) {
+	if err := db.Use(m.GormPlugin()); err != nil {
+		log.Fatalf("failed to register the metrics plugin: %v", err)
+	}
+
+	if err := db.Use(tracing.GormPlugin(tp, database.System(cfg.DBDriver))); err != nil {
+		log.Fatalf("failed to register the tracing plugin: %v", err)
+	}
+
+	m.RegisterDB(db, name)
+}
+
+// openDB connects to the database, waiting for it to be up.
+func openDB(ctx context.Context, cfg *config.Config, dsn string) (*gorm.DB, error) {
+	return database.Open(ctx, cfg.DBDriver, dsn,
+		&gorm.Config{Logger: logging.NewGormLogger(cfg.DBSlowQuery)},
+		poolOption(cfg),
+		database.WithConnectRetry(cfg.DBConnectTimeout, database.Backoff{Base: 500 * time.Millisecond, Max: 10 * time.Second}),
 	)
+}
 
-	go srv.Start(ctx)
+func poolOption(cfg *config.Config) database.Option {
+	return database.WithPool(cfg.DBMaxOpenConns, cfg.DBMaxIdleConns, cfg.DBConnMaxLifetime, cfg.DBConnMaxIdleTime)
+}
 
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-	cancel()
+// newPersonRepo puts the configured cache in front of the repository.
+func newPersonRepo(cfg *config.Config, repo person.Repository, m *metrics.Metrics, reloader *config.Reloader) person.Repository {
+	var c cache.Cache
+	switch cfg.CacheBackend {
+	case "none":
+		return repo
+	case "memory":
+		lru, err := cache.NewLRU(cfg.CacheSize)
+		if err != nil {
+			log.Fatalf("failed to create the cache: %v", err)
+		}
+		c = lru
+	case "redis":
+		opts, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			log.Fatalf("invalid redis url: %v", err)
+		}
+		c = cache.NewRedis(redis.NewClient(opts), "qore:")
+	default:
+		log.Fatalf("unknown cache backend: %q", cfg.CacheBackend)
+	}
+
+	cached, err := person.NewCachedRepository(repo, c,
+		person.WithCacheTTL(cfg.CacheTTL, cfg.CacheNegativeTTL),
+		person.WithCacheMetrics(m),
+	)
+	if err != nil {
+		log.Fatalf("failed to create the person cache: %v", err)
+	}
+
+	reloader.Subscribe(func(old *config.Config, cfg *config.Config) (func(bool), error) {
+		return onCommit(func() {
+			// the TTLs are validated by the configuration.
+			_ = cached.SetTTL(cfg.CacheTTL, cfg.CacheNegativeTTL)
+		}), nil
+	})
+
+	return cached
 }
 
-func loadConfig() *config.Config {
-	cfg, err := config.New()
+// indexBlockingKeys indexes the persons stored before their blocking keys,
+// which the duplicate detection looks the candidates up by.
+func indexBlockingKeys(ctx context.Context, repo *person.Repo) {
+	n, err := repo.IndexBlockingKeys(ctx, 500)
 	if err != nil {
-		log.Fatalf("failed to load config: %v", err)
+		slog.Error("failed to index the blocking keys", "error", err.Error())
+		return
 	}
+	if n > 0 {
+		slog.Info("blocking keys indexed", "persons", n)
+	}
+}
 
-	return cfg
+func newBreaker(cfg *config.Config, m *metrics.Metrics) *breaker.Breaker {
+	b, err := breaker.New(
+		breaker.WithFailureThreshold(cfg.DBBreakerFailures),
+		breaker.WithOpenTimeout(cfg.DBBreakerOpenTimeout),
+		breaker.WithHalfOpenProbes(cfg.DBBreakerProbes),
+		breaker.WithConcurrency(cfg.DBConcurrency),
+		breaker.WithOperationConcurrency(cfg.DBOperationConcurrency),
+		breaker.WithMetrics(m),
+	)
+	if err != nil {
+		log.Fatalf("failed to create the database circuit breaker: %v", err)
+	}
+
+	return b
 }
 
-func setupDB(url string) *gorm.DB {
-	db, err := gorm.Open(mysql.Open(url), &gorm.Config{})
+func newMigrator(cfg *config.Config, db *gorm.DB) *migrate.Migrator {
+	sqlDB, err := db.DB()
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	return db
+	m, err := migrate.New(sqlDB, migrate.WithDialect(cfg.DBDriver))
+	if err != nil {
+		log.Fatalf("failed to load the migrations: %v", err)
+	}
+
+	return m
 }
 
-func newPersonCtrl(db *gorm.DB) *person.Controller {
-	svc, err := person.NewService(person.WithRepository(person.NewRepository(db)))
+// migrationStatus counts the pending migrations through the current
+// connections of the database, which are replaced by the rotations.
+func migrationStatus(cfg *config.Config, db *gorm.DB) health.MigrationStatus {
+	var (
+		mu       sync.Mutex
+		conns    *sql.DB
+		migrator *migrate.Migrator
+	)
+
+	return func(ctx context.Context) (int, error) {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return 0, err
+		}
+
+		mu.Lock()
+		if sqlDB != conns {
+			conns, migrator = sqlDB, newMigrator(cfg, db)
+		}
+		m := migrator
+		mu.Unlock()
+
+		return m.Pending(ctx)
+	}
+}
+
+// runMigrate runs the `migrate up|down|status|to <version>` command.
+func runMigrate(cfg *config.Config, args []string) {
+	if len(args) == 0 {
+		log.Fatal("usage: migrate up|down|status|to <version>")
+	}
+	if cfg.DBDriver == "memory" {
+		log.Fatal("DB_DRIVER is memory: there is no database to migrate")
+	}
+
+	ctx := context.Background()
+	db, err := openDB(ctx, cfg, cfg.DBUrl)
+	if err != nil {
+		log.Fatalf("failed to connect to the database: %v", err)
+	}
+	m := newMigrator(cfg, db)
+
+	switch args[0] {
+	case "up":
+		err = m.Up(ctx)
+	case "down":
+		err = m.Down(ctx)
+	case "to":
+		if len(args) != 2 {
+			log.Fatal("usage: migrate to <version>")
+		}
+		version, perr := strconv.ParseInt(args[1], 10, 64)
+		if perr != nil {
+			log.Fatalf("invalid version: %q", args[1])
+		}
+		err = m.To(ctx, version)
+	case "status":
+		err = printMigrations(ctx, m)
+	default:
+		log.Fatalf("unknown migrate command: %q", args[0])
+	}
+
+	if err != nil {
+		log.Fatalf("migrate %s failed: %v", args[0], err)
+	}
+}
+
+func printMigrations(ctx context.Context, m *migrate.Migrator) error {
+	status, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, s := range status {
+		state, at := "pending", ""
+		switch {
+		case s.Dirty:
+			state = "dirty"
+		case s.Applied:
+			state = "applied"
+		}
+		if s.AppliedAt != nil {
+			at = s.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", s.Version, s.Name, state, at)
+	}
+	return w.Flush()
+}
+
+func newHealthCtrl(cfg *config.Config, opts ...health.ControllerOption) *health.Controller {
+	ctrl, err := health.NewController(append(opts, health.WithTimeout(cfg.HealthTimeout))...)
+	if err != nil {
+		log.Fatalf("failed to create health controller: %v", err)
+	}
+
+	return ctrl
+}
+
+func newPersonCtrl(cfg *config.Config, repo person.Repository, attrs person.AttributeSchema, features *feature.Flags, m person.Metrics, tp *sdktrace.TracerProvider) *person.Controller {
+	opts := []person.ServiceOption{
+		person.WithRepository(repo),
+		person.WithDuplicatePolicy(person.DuplicateMode(cfg.DuplicateMode), cfg.DuplicateThreshold),
+		person.WithFeatures(features),
+		person.WithMergeRetention(cfg.MergeRetention),
+		person.WithMetrics(m),
+		person.WithTracerProvider(tp),
+	}
+	if attrs != nil {
+		opts = append(opts, person.WithAttributeSchema(attrs))
+	}
+
+	svc, err := person.NewService(opts...)
 	if err != nil {
 		log.Fatalf("failed to create person service: %v", err)
 	}
@@ -73,3 +757,41 @@ func newPersonCtrl(db *gorm.DB) *person.Controller {
 
 	return ctrl
 }
+
+func newRelationCtrl(cfg *config.Config, db *gorm.DB, b *breaker.Breaker) *relation.Controller {
+	repo := relation.NewGuardedRepository(relation.NewRepository(db, relation.WithQueryTimeout(cfg.DBQueryTimeout)), b)
+	svc, err := relation.NewService(relation.WithRepository(repo))
+	if err != nil {
+		log.Fatalf("failed to create relationship service: %v", err)
+	}
+
+	ctrl, err := relation.NewController(relation.WithService(svc))
+	if err != nil {
+		log.Fatalf("failed to create relationship controller: %v", err)
+	}
+
+	return ctrl
+}
+
+func newAttributeSvc(cfg *config.Config, db *gorm.DB, b *breaker.Breaker, persons person.Repository) *attribute.ServiceImpl {
+	repo := attribute.NewGuardedRepository(attribute.NewRepository(db, attribute.WithQueryTimeout(cfg.DBQueryTimeout)), b)
+	opts := []attribute.ServiceOption{attribute.WithRepository(repo)}
+	if cached, ok := persons.(*person.CachedRepo); ok {
+		opts = append(opts, attribute.WithPersonCache(cached))
+	}
+	svc, err := attribute.NewService(opts...)
+	if err != nil {
+		log.Fatalf("failed to create attribute service: %v", err)
+	}
+
+	return svc
+}
+
+func newAttributeCtrl(svc *attribute.ServiceImpl) *attribute.Controller {
+	ctrl, err := attribute.NewController(attribute.WithService(svc))
+	if err != nil {
+		log.Fatalf("failed to create attribute controller: %v", err)
+	}
+
+	return ctrl
+}
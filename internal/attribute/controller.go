@@ -0,0 +1,148 @@
+package attribute
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"qore-be/internal/breaker"
+	"qore-be/internal/domain/dto"
+	"qore-be/internal/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Service the attribute definition services.
+//
+//go:generate mockery --name=Service --structname=AttributeService  --case underscore --output=../mocks/ --filename=attribute_service.go
+type Service interface {
+	Create(context.Context, dto.AttributeDefinitionDTO) (dto.AttributeDefinitionDTO, error)
+	Get(context.Context, string) (dto.AttributeDefinitionDTO, error)
+	GetAll(context.Context) ([]dto.AttributeDefinitionDTO, error)
+	Update(context.Context, string, dto.AttributeDefinitionDTO) (dto.AttributeDefinitionDTO, error)
+	Delete(context.Context, string) error
+}
+
+// Controller represents the attribute definition controller.
+type Controller struct {
+	svc Service
+}
+
+// ControllerOption ..
+type ControllerOption = func(*Controller) error
+
+var (
+	errMissingAttributeService = fmt.Errorf("nil attribute service")
+)
+
+// NewController creates a new attribute definition controller.
+func NewController(opts ...ControllerOption) (*Controller, error) {
+	ctrl := &Controller{}
+
+	for _, opt := range opts {
+		if err := opt(ctrl); err != nil {
+			return nil, err
+		}
+	}
+
+	if ctrl.svc == nil {
+		return nil, errMissingAttributeService
+	}
+
+	return ctrl, nil
+}
+
+// WithService initialize the attribute-controller with an attribute-service.
+func WithService(svc Service) ControllerOption {
+	return func(ctrl *Controller) error {
+		if svc == nil {
+			return errMissingAttributeService
+		}
+		ctrl.svc = svc
+		return nil
+	}
+}
+
+// Create defines a new custom attribute.
+func (c *Controller) Create(ctx *gin.Context) {
+	var req dto.AttributeDefinitionDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	def, err := c.svc.Create(ctx, req)
+	if err != nil {
+		c.handleError(ctx, "failed to create attribute definition", err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, def)
+}
+
+// GetAll lists the custom attribute definitions.
+func (c *Controller) GetAll(ctx *gin.Context) {
+	defs, err := c.svc.GetAll(ctx)
+	if err != nil {
+		c.handleError(ctx, "failed to get attribute definitions", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"content": defs,
+		"size":    len(defs),
+	})
+}
+
+// Get retrieves a custom attribute definition.
+func (c *Controller) Get(ctx *gin.Context) {
+	def, err := c.svc.Get(ctx, ctx.Param("name"))
+	if err != nil {
+		c.handleError(ctx, "failed to get attribute definition", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, def)
+}
+
+// Update replaces a custom attribute definition.
+func (c *Controller) Update(ctx *gin.Context) {
+	var req dto.AttributeDefinitionDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	def, err := c.svc.Update(ctx, ctx.Param("name"), req)
+	if err != nil {
+		c.handleError(ctx, "failed to update attribute definition", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, def)
+}
+
+// Delete removes a custom attribute definition.
+func (c *Controller) Delete(ctx *gin.Context) {
+	if err := c.svc.Delete(ctx, ctx.Param("name")); err != nil {
+		c.handleError(ctx, "failed to delete attribute definition", err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (c *Controller) handleError(ctx *gin.Context, msg string, err error) {
+	logging.FromContext(ctx).Error(msg, "error", err.Error())
+
+	switch {
+	case errors.Is(err, ErrInvalidDefinition):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrAlreadyExists):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		breaker.WriteError(ctx, err)
+	}
+}
@@ -0,0 +1,147 @@
+package attribute_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"qore-be/internal/attribute"
+	"qore-be/internal/domain/dto"
+	"qore-be/internal/mocks"
+
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAttributeController(t *testing.T) {
+	t.Run("successfully", func(t *testing.T) {
+		ctrl, err := attribute.NewController(attribute.WithService(mocks.NewAttributeService(t)))
+		assert.NoError(t, err)
+		assert.NotNil(t, ctrl)
+	})
+
+	t.Run("with nil attribute service", func(t *testing.T) {
+		ctrl, err := attribute.NewController(attribute.WithService(nil))
+		assert.Error(t, err)
+		assert.Nil(t, ctrl)
+	})
+}
+
+func TestAttributeController_Create(t *testing.T) {
+	validReq := dto.AttributeDefinitionDTO{Name: "employee_number", Type: attribute.TypeString}
+
+	cases := []struct {
+		name           string
+		svc            func(*testing.T) attribute.Service
+		in             interface{}
+		expectedStatus int
+	}{
+		{
+			name: "successfully",
+			svc: func(t *testing.T) attribute.Service {
+				s := mocks.NewAttributeService(t)
+				s.On("Create", mock.Anything, validReq).Return(validReq, nil)
+
+				return s
+			},
+			in:             validReq,
+			expectedStatus: 201,
+		},
+		{
+			name: "with missing type",
+			svc: func(t *testing.T) attribute.Service {
+				return mocks.NewAttributeService(t)
+			},
+			in:             map[string]string{"name": "employee_number"},
+			expectedStatus: 400,
+		},
+		{
+			name: "with invalid definition",
+			svc: func(t *testing.T) attribute.Service {
+				s := mocks.NewAttributeService(t)
+				s.On("Create", mock.Anything, validReq).
+					Return(dto.AttributeDefinitionDTO{}, fmt.Errorf("%w: error", attribute.ErrInvalidDefinition))
+
+				return s
+			},
+			in:             validReq,
+			expectedStatus: 400,
+		},
+		{
+			name: "with existing definition",
+			svc: func(t *testing.T) attribute.Service {
+				s := mocks.NewAttributeService(t)
+				s.On("Create", mock.Anything, validReq).
+					Return(dto.AttributeDefinitionDTO{}, attribute.ErrAlreadyExists)
+
+				return s
+			},
+			in:             validReq,
+			expectedStatus: 409,
+		},
+		{
+			name: "with internal error",
+			svc: func(t *testing.T) attribute.Service {
+				s := mocks.NewAttributeService(t)
+				s.On("Create", mock.Anything, validReq).
+					Return(dto.AttributeDefinitionDTO{}, fmt.Errorf("error"))
+
+				return s
+			},
+			in:             validReq,
+			expectedStatus: 500,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl, err := attribute.NewController(attribute.WithService(tc.svc(t)))
+			require.NoError(t, err)
+
+			srv := gin.Default()
+			gin.SetMode(gin.TestMode)
+
+			srv.POST("/admin/attributes", ctrl.Create)
+
+			rec := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.in)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, "/admin/attributes", bytes.NewBuffer(data))
+			require.NoError(t, err)
+
+			srv.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestAttributeController_Delete(t *testing.T) {
+	s := mocks.NewAttributeService(t)
+	s.On("Delete", mock.Anything, "employee_number").Return(nil)
+	s.On("Delete", mock.Anything, "unknown").Return(attribute.ErrRecordNotFound)
+
+	ctrl, err := attribute.NewController(attribute.WithService(s))
+	require.NoError(t, err)
+
+	srv := gin.Default()
+	gin.SetMode(gin.TestMode)
+
+	srv.DELETE("/admin/attributes/:name", ctrl.Delete)
+
+	for name, expectedStatus := range map[string]int{"employee_number": 204, "unknown": 404} {
+		rec := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodDelete, "/admin/attributes/"+name, nil)
+		require.NoError(t, err)
+
+		srv.ServeHTTP(rec, req)
+		assert.Equal(t, expectedStatus, rec.Code)
+	}
+}
@@ -0,0 +1,59 @@
+package attribute
+
+import (
+	"context"
+	"qore-be/internal/breaker"
+	"qore-be/internal/domain/dto"
+)
+
+// expected are the attribute errors the breaker does not count as failures.
+var expected = []error{ErrRecordNotFound, ErrAlreadyExists}
+
+// GuardedRepo guards a repository with a circuit breaker, which also bounds
+// the concurrent calls of each operation.
+type GuardedRepo struct {
+	repo    Repository
+	breaker *breaker.Breaker
+}
+
+// NewGuardedRepository wraps the repository with the breaker. The operations
+// are named "attribute.<method>", e.g. "attribute.GetAll".
+func NewGuardedRepository(repo Repository, b *breaker.Breaker) *GuardedRepo {
+	if repo == nil || b == nil {
+		panic("nil repository or breaker")
+	}
+	return &GuardedRepo{repo: repo, breaker: b}
+}
+
+// Add implements Repository.
+func (r *GuardedRepo) Add(ctx context.Context, d dto.AttributeDefinitionDTO) (dto.AttributeDefinitionDTO, error) {
+	return breaker.Call(ctx, r.breaker, "attribute.Add", func(ctx context.Context) (dto.AttributeDefinitionDTO, error) {
+		return r.repo.Add(ctx, d)
+	}, expected...)
+}
+
+// Get implements Repository.
+func (r *GuardedRepo) Get(ctx context.Context, name string) (dto.AttributeDefinitionDTO, error) {
+	return breaker.Call(ctx, r.breaker, "attribute.Get", func(ctx context.Context) (dto.AttributeDefinitionDTO, error) {
+		return r.repo.Get(ctx, name)
+	}, expected...)
+}
+
+// GetAll implements Repository.
+func (r *GuardedRepo) GetAll(ctx context.Context) ([]dto.AttributeDefinitionDTO, error) {
+	return breaker.Call(ctx, r.breaker, "attribute.GetAll", r.repo.GetAll, expected...)
+}
+
+// Update implements Repository.
+func (r *GuardedRepo) Update(ctx context.Context, d dto.AttributeDefinitionDTO) (dto.AttributeDefinitionDTO, error) {
+	return breaker.Call(ctx, r.breaker, "attribute.Update", func(ctx context.Context) (dto.AttributeDefinitionDTO, error) {
+		return r.repo.Update(ctx, d)
+	}, expected...)
+}
+
+// Delete implements Repository.
+func (r *GuardedRepo) Delete(ctx context.Context, name string) error {
+	return r.breaker.Do(ctx, "attribute.Delete", func(ctx context.Context) error {
+		return r.repo.Delete(ctx, name)
+	}, expected...)
+}
@@ -0,0 +1,196 @@
+package attribute
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"qore-be/internal/database"
+	"qore-be/internal/domain/dto"
+	"qore-be/internal/domain/entities"
+	"qore-be/internal/tenant"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRecordNotFound = fmt.Errorf("not found")
+	ErrAlreadyExists  = fmt.Errorf("already exists")
+)
+
+// Repo represents the attribute definition repository.
+//
+// Definitions are scoped to the tenant held by the context.
+type Repo struct {
+	db      *gorm.DB
+	timeout time.Duration
+}
+
+// NewRepository create a new instance of the attribute definition repository.
+func NewRepository(db *gorm.DB, opts ...RepoOption) *Repo {
+	if db == nil {
+		panic("nil db")
+	}
+
+	r := &Repo{
+		db: db,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// RepoOption ..
+type RepoOption func(*Repo)
+
+// WithQueryTimeout bounds the queries of every repository call.
+func WithQueryTimeout(d time.Duration) RepoOption {
+	return func(r *Repo) {
+		r.timeout = d
+	}
+}
+
+// Add saves a new attribute definition.
+func (r *Repo) Add(ctx context.Context, d dto.AttributeDefinitionDTO) (dto.AttributeDefinitionDTO, error) {
+	ctx, cancel := database.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	def := toEntity(tenant.FromContext(ctx), d)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&entities.AttributeDefinition{}).
+			Where("tenant = ? AND name = ?", def.Tenant, def.Name).
+			Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to get attribute definition: %v", err)
+		}
+
+		if count > 0 {
+			return ErrAlreadyExists
+		}
+
+		if err := tx.Create(&def).Error; err != nil {
+			return fmt.Errorf("failed to save attribute definition: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return dto.AttributeDefinitionDTO{}, err
+	}
+
+	return toDTO(def), nil
+}
+
+// Get retrieves an attribute definition by its name.
+func (r *Repo) Get(ctx context.Context, name string) (dto.AttributeDefinitionDTO, error) {
+	ctx, cancel := database.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	def := entities.AttributeDefinition{}
+	err := r.db.WithContext(ctx).
+		Where("tenant = ? AND name = ?", tenant.FromContext(ctx), name).
+		First(&def).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.AttributeDefinitionDTO{}, ErrRecordNotFound
+		}
+		return dto.AttributeDefinitionDTO{}, fmt.Errorf("failed to get attribute definition: %v", err)
+	}
+
+	return toDTO(def), nil
+}
+
+// GetAll retrieves all the attribute definitions.
+func (r *Repo) GetAll(ctx context.Context) ([]dto.AttributeDefinitionDTO, error) {
+	ctx, cancel := database.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	defs := []entities.AttributeDefinition{}
+	err := r.db.WithContext(ctx).
+		Where("tenant = ?", tenant.FromContext(ctx)).
+		Order("name").
+		Find(&defs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attribute definitions: %v", err)
+	}
+
+	dtos := make([]dto.AttributeDefinitionDTO, len(defs))
+	for i, def := range defs {
+		dtos[i] = toDTO(def)
+	}
+	return dtos, nil
+}
+
+// Update saves the changes of an existing attribute definition.
+func (r *Repo) Update(ctx context.Context, d dto.AttributeDefinitionDTO) (dto.AttributeDefinitionDTO, error) {
+	ctx, cancel := database.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	def := toEntity(tenant.FromContext(ctx), d)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		existing := entities.AttributeDefinition{}
+		if err := tx.Where("tenant = ? AND name = ?", def.Tenant, def.Name).First(&existing).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRecordNotFound
+			}
+			return fmt.Errorf("failed to get attribute definition: %v", err)
+		}
+
+		def.ID = existing.ID
+		if err := tx.Save(&def).Error; err != nil {
+			return fmt.Errorf("failed to update attribute definition: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return dto.AttributeDefinitionDTO{}, err
+	}
+
+	return toDTO(def), nil
+}
+
+// Delete removes an attribute definition and the persons values of that attribute.
+func (r *Repo) Delete(ctx context.Context, name string) error {
+	ctx, cancel := database.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	t := tenant.FromContext(ctx)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("tenant = ? AND name = ?", t, name).Delete(&entities.AttributeDefinition{})
+		if res.Error != nil {
+			return fmt.Errorf("failed to delete attribute definition: %v", res.Error)
+		}
+
+		if res.RowsAffected == 0 {
+			return ErrRecordNotFound
+		}
+
+		if err := tx.Where("tenant = ? AND name = ?", t, name).Delete(&entities.PersonAttribute{}).Error; err != nil {
+			return fmt.Errorf("failed to delete attribute values: %v", err)
+		}
+		return nil
+	})
+}
+
+func toEntity(tenant string, d dto.AttributeDefinitionDTO) entities.AttributeDefinition {
+	return entities.AttributeDefinition{
+		Tenant:     tenant,
+		Name:       d.Name,
+		Type:       d.Type,
+		Required:   d.Required,
+		EnumValues: d.EnumValues,
+		Regex:      d.Regex,
+	}
+}
+
+func toDTO(def entities.AttributeDefinition) dto.AttributeDefinitionDTO {
+	return dto.AttributeDefinitionDTO{
+		Name:       def.Name,
+		Type:       def.Type,
+		Required:   def.Required,
+		EnumValues: def.EnumValues,
+		Regex:      def.Regex,
+	}
+}
@@ -0,0 +1,359 @@
+package attribute
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"qore-be/internal/domain/dto"
+	"qore-be/internal/logging"
+	"regexp"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Attribute types.
+const (
+	TypeString  = "string"
+	TypeNumber  = "number"
+	TypeInteger = "integer"
+	TypeBoolean = "boolean"
+	TypeDate    = "date"
+	TypeEnum    = "enum"
+)
+
+var (
+	ErrInvalidDefinition = fmt.Errorf("invalid attribute definition")
+	ErrInvalidAttribute  = fmt.Errorf("invalid attribute")
+
+	validName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+)
+
+// Repository the attribute definition repository.
+//
+//go:generate mockery --name=Repository --structname=AttributeRepository  --case underscore --output=../mocks/ --filename=attribute_repository.go
+type Repository interface {
+	Add(context.Context, dto.AttributeDefinitionDTO) (dto.AttributeDefinitionDTO, error)
+	Get(context.Context, string) (dto.AttributeDefinitionDTO, error)
+	GetAll(context.Context) ([]dto.AttributeDefinitionDTO, error)
+	Update(context.Context, dto.AttributeDefinitionDTO) (dto.AttributeDefinitionDTO, error)
+	Delete(context.Context, string) error
+}
+
+// ServiceImpl implements the attribute service: it manages the attribute
+// definitions of the tenants and validates persons attributes against them.
+type ServiceImpl struct {
+	db      Repository
+	persons PersonCache
+
+	// patterns holds the compiled regex of the definitions, by expression.
+	patterns sync.Map
+}
+
+// ServiceOption ..
+type ServiceOption func(*ServiceImpl) error
+
+// NewService creates a new attribute service.
+func NewService(opts ...ServiceOption) (*ServiceImpl, error) {
+	svc := &ServiceImpl{}
+
+	for _, opt := range opts {
+		err := opt(svc)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if svc.db == nil {
+		return nil, fmt.Errorf("missing attribute DB")
+	}
+
+	return svc, nil
+}
+
+// WithRepository returns a closure that initialize the attribute-service with the attribute-repository.
+func WithRepository(db Repository) ServiceOption {
+	return func(svc *ServiceImpl) error {
+		svc.db = db
+		return nil
+	}
+}
+
+// PersonCache caches the persons along with their custom attributes.
+type PersonCache interface {
+	// EvictTenant evicts the persons of the tenant of the context.
+	EvictTenant(context.Context)
+}
+
+// WithPersonCache returns a closure that evicts the persons of the tenant
+// from the cache when one of its attribute definitions is deleted.
+func WithPersonCache(c PersonCache) ServiceOption {
+	return func(svc *ServiceImpl) error {
+		svc.persons = c
+		return nil
+	}
+}
+
+// Create saves a new attribute definition.
+func (s *ServiceImpl) Create(ctx context.Context, d dto.AttributeDefinitionDTO) (dto.AttributeDefinitionDTO, error) {
+	if err := s.validateDefinition(d); err != nil {
+		return dto.AttributeDefinitionDTO{}, err
+	}
+
+	def, err := s.db.Add(ctx, d)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to save the attribute definition", "name", d.Name, "error", err.Error())
+		return dto.AttributeDefinitionDTO{}, err
+	}
+
+	logging.FromContext(ctx).Info("attribute definition created with success", "name", def.Name)
+	return def, nil
+}
+
+// Get retrieves an attribute definition.
+func (s *ServiceImpl) Get(ctx context.Context, name string) (dto.AttributeDefinitionDTO, error) {
+	def, err := s.db.Get(ctx, name)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to get the attribute definition", "name", name, "error", err.Error())
+		return dto.AttributeDefinitionDTO{}, err
+	}
+
+	return def, nil
+}
+
+// GetAll retrieves all the attribute definitions.
+func (s *ServiceImpl) GetAll(ctx context.Context) ([]dto.AttributeDefinitionDTO, error) {
+	defs, err := s.db.GetAll(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to get the attribute definitions", "error", err.Error())
+		return nil, err
+	}
+
+	return defs, nil
+}
+
+// Update replaces an attribute definition. Values stored before the change
+// are not validated again.
+func (s *ServiceImpl) Update(ctx context.Context, name string, d dto.AttributeDefinitionDTO) (dto.AttributeDefinitionDTO, error) {
+	d.Name = name
+	if err := s.validateDefinition(d); err != nil {
+		return dto.AttributeDefinitionDTO{}, err
+	}
+
+	def, err := s.db.Update(ctx, d)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to update the attribute definition", "name", name, "error", err.Error())
+		return dto.AttributeDefinitionDTO{}, err
+	}
+
+	logging.FromContext(ctx).Info("attribute definition updated with success", "name", name)
+	return def, nil
+}
+
+// Delete removes an attribute definition along with the values of that attribute.
+func (s *ServiceImpl) Delete(ctx context.Context, name string) error {
+	if s.persons != nil {
+		// evicted whether the delete succeeded or not, as it may have.
+		defer s.persons.EvictTenant(ctx)
+	}
+
+	if err := s.db.Delete(ctx, name); err != nil {
+		logging.FromContext(ctx).Error("failed to delete the attribute definition", "name", name, "error", err.Error())
+		return err
+	}
+
+	logging.FromContext(ctx).Info("attribute definition deleted with success", "name", name)
+	return nil
+}
+
+// Validate validates the custom attributes of a person against the tenant
+// definitions and returns their normalized values. Null values are dropped.
+func (s *ServiceImpl) Validate(ctx context.Context, values map[string]any) (map[string]any, error) {
+	defs, err := s.db.GetAll(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to get the attribute definitions", "error", err.Error())
+		return nil, err
+	}
+
+	byName := map[string]dto.AttributeDefinitionDTO{}
+	for _, def := range defs {
+		byName[def.Name] = def
+	}
+
+	res := map[string]any{}
+	for name, v := range values {
+		def, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown attribute %q", ErrInvalidAttribute, name)
+		}
+
+		if v == nil {
+			continue
+		}
+
+		norm, err := s.normalize(def, v)
+		if err != nil {
+			return nil, err
+		}
+		res[name] = norm
+	}
+
+	for _, def := range defs {
+		if _, ok := res[def.Name]; def.Required && !ok {
+			return nil, fmt.Errorf("%w: missing required attribute %q", ErrInvalidAttribute, def.Name)
+		}
+	}
+
+	return res, nil
+}
+
+// ParseQuery parses the raw value of a custom attribute given in a query
+// string. It returns the normalized value and whether the attribute is
+// numeric.
+func (s *ServiceImpl) ParseQuery(ctx context.Context, name string, raw string) (any, bool, error) {
+	def, err := s.db.Get(ctx, name)
+	if err != nil {
+		if errors.Is(err, ErrRecordNotFound) {
+			return nil, false, fmt.Errorf("%w: unknown attribute %q", ErrInvalidAttribute, name)
+		}
+		return nil, false, err
+	}
+
+	numeric := def.Type == TypeNumber || def.Type == TypeInteger
+	if raw == "" {
+		return nil, numeric, nil
+	}
+
+	var v any = raw
+	switch def.Type {
+	case TypeNumber, TypeInteger:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, false, fmt.Errorf("%w: %q is not a number", ErrInvalidAttribute, raw)
+		}
+		v = f
+	case TypeBoolean:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, false, fmt.Errorf("%w: %q is not a boolean", ErrInvalidAttribute, raw)
+		}
+		v = b
+	}
+
+	// the regex only applies to stored values, not to searched ones.
+	def.Regex = ""
+	v, err = s.normalize(def, v)
+	return v, numeric, err
+}
+
+func (s *ServiceImpl) validateDefinition(d dto.AttributeDefinitionDTO) error {
+	if !validName.MatchString(d.Name) {
+		return fmt.Errorf("%w: name must match %s", ErrInvalidDefinition, validName)
+	}
+
+	switch d.Type {
+	case TypeString:
+	case TypeNumber, TypeInteger, TypeBoolean, TypeDate:
+		if d.Regex != "" {
+			return fmt.Errorf("%w: regex only applies to string attributes", ErrInvalidDefinition)
+		}
+	case TypeEnum:
+		if len(d.EnumValues) == 0 {
+			return fmt.Errorf("%w: enum attributes need enum values", ErrInvalidDefinition)
+		}
+		if d.Regex != "" {
+			return fmt.Errorf("%w: regex only applies to string attributes", ErrInvalidDefinition)
+		}
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidDefinition, d.Type)
+	}
+
+	if d.Type != TypeEnum && len(d.EnumValues) > 0 {
+		return fmt.Errorf("%w: enum values only apply to enum attributes", ErrInvalidDefinition)
+	}
+
+	if d.Regex != "" {
+		if _, err := s.pattern(d.Regex); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pattern returns the compiled regex, compiling it on its first use only. A
+// definition saved by another instance, or stored before the validation, may
+// hold an invalid regex: it is reported instead of panicking.
+func (s *ServiceImpl) pattern(expr string) (*regexp.Regexp, error) {
+	if re, ok := s.patterns.Load(expr); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDefinition, err)
+	}
+	s.patterns.Store(expr, re)
+	return re, nil
+}
+
+func (s *ServiceImpl) normalize(def dto.AttributeDefinitionDTO, v any) (any, error) {
+	invalid := func(expected string) error {
+		return fmt.Errorf("%w: %q must be %s", ErrInvalidAttribute, def.Name, expected)
+	}
+
+	switch def.Type {
+	case TypeString:
+		str, ok := v.(string)
+		if !ok {
+			return nil, invalid("a string")
+		}
+		if def.Regex != "" {
+			re, err := s.pattern(def.Regex)
+			if err != nil {
+				return nil, err
+			}
+			if !re.MatchString(str) {
+				return nil, invalid("matching " + def.Regex)
+			}
+		}
+		return str, nil
+	case TypeNumber:
+		f, ok := v.(float64)
+		if !ok {
+			return nil, invalid("a number")
+		}
+		return f, nil
+	case TypeInteger:
+		f, ok := v.(float64)
+		if !ok || f != math.Trunc(f) {
+			return nil, invalid("an integer")
+		}
+		return f, nil
+	case TypeBoolean:
+		b, ok := v.(bool)
+		if !ok {
+			return nil, invalid("a boolean")
+		}
+		return b, nil
+	case TypeDate:
+		str, ok := v.(string)
+		if !ok {
+			return nil, invalid("a YYYY-MM-DD date")
+		}
+		if _, err := time.Parse(time.DateOnly, str); err != nil {
+			return nil, invalid("a YYYY-MM-DD date")
+		}
+		return str, nil
+	case TypeEnum:
+		str, ok := v.(string)
+		if !ok || !slices.Contains(def.EnumValues, str) {
+			return nil, invalid(fmt.Sprintf("one of %v", def.EnumValues))
+		}
+		return str, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown type %q", ErrInvalidDefinition, def.Type)
+	}
+}
@@ -0,0 +1,260 @@
+package attribute_test
+
+import (
+	"context"
+	"qore-be/internal/attribute"
+	"qore-be/internal/domain/dto"
+	"qore-be/internal/mocks"
+
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var definitions = []dto.AttributeDefinitionDTO{
+	{Name: "employee_number", Type: attribute.TypeString, Required: true, Regex: `^E[0-9]{4}$`},
+	{Name: "preferred_language", Type: attribute.TypeEnum, EnumValues: []string{"en", "fr"}},
+	{Name: "seniority", Type: attribute.TypeInteger},
+	{Name: "score", Type: attribute.TypeNumber},
+	{Name: "remote", Type: attribute.TypeBoolean},
+	{Name: "hired_on", Type: attribute.TypeDate},
+}
+
+func TestNewAttributeService(t *testing.T) {
+	t.Run("successfully", func(t *testing.T) {
+		svc, err := attribute.NewService(attribute.WithRepository(&attribute.Repo{}))
+		assert.NoError(t, err)
+		assert.NotNil(t, svc)
+	})
+
+	t.Run("with missing attribute repo", func(t *testing.T) {
+		svc, err := attribute.NewService()
+		assert.Error(t, err)
+		assert.Nil(t, svc)
+	})
+}
+
+func TestAttributeService_Create(t *testing.T) {
+	cases := []struct {
+		name   string
+		in     dto.AttributeDefinitionDTO
+		hasErr bool
+	}{
+		{
+			name: "successfully (string with regex)",
+			in:   dto.AttributeDefinitionDTO{Name: "employee_number", Type: attribute.TypeString, Regex: `^E[0-9]+$`},
+		},
+		{
+			name: "successfully (enum)",
+			in:   dto.AttributeDefinitionDTO{Name: "lang", Type: attribute.TypeEnum, EnumValues: []string{"en"}},
+		},
+		{
+			name:   "with invalid name",
+			in:     dto.AttributeDefinitionDTO{Name: "Employee Number", Type: attribute.TypeString},
+			hasErr: true,
+		},
+		{
+			name:   "with unknown type",
+			in:     dto.AttributeDefinitionDTO{Name: "size", Type: "float"},
+			hasErr: true,
+		},
+		{
+			name:   "with enum without values",
+			in:     dto.AttributeDefinitionDTO{Name: "lang", Type: attribute.TypeEnum},
+			hasErr: true,
+		},
+		{
+			name:   "with enum values on a string",
+			in:     dto.AttributeDefinitionDTO{Name: "lang", Type: attribute.TypeString, EnumValues: []string{"en"}},
+			hasErr: true,
+		},
+		{
+			name:   "with regex on a number",
+			in:     dto.AttributeDefinitionDTO{Name: "size", Type: attribute.TypeNumber, Regex: "^1"},
+			hasErr: true,
+		},
+		{
+			name:   "with invalid regex",
+			in:     dto.AttributeDefinitionDTO{Name: "code", Type: attribute.TypeString, Regex: "(["},
+			hasErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := mocks.NewAttributeRepository(t)
+			if !tc.hasErr {
+				d.On("Add", mock.Anything, tc.in).Return(tc.in, nil)
+			}
+
+			svc, err := attribute.NewService(attribute.WithRepository(d))
+			require.NoError(t, err)
+
+			_, err = svc.Create(context.TODO(), tc.in)
+			if tc.hasErr {
+				assert.ErrorIs(t, err, attribute.ErrInvalidDefinition)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAttributeService_Validate(t *testing.T) {
+	cases := []struct {
+		name     string
+		in       map[string]any
+		expected map[string]any
+		hasErr   bool
+	}{
+		{
+			name: "successfully",
+			in: map[string]any{
+				"employee_number":    "E0042",
+				"preferred_language": "fr",
+				"seniority":          float64(3),
+				"score":              4.5,
+				"remote":             true,
+				"hired_on":           "2020-02-01",
+			},
+			expected: map[string]any{
+				"employee_number":    "E0042",
+				"preferred_language": "fr",
+				"seniority":          float64(3),
+				"score":              4.5,
+				"remote":             true,
+				"hired_on":           "2020-02-01",
+			},
+		},
+		{
+			name:     "successfully (null values are dropped)",
+			in:       map[string]any{"employee_number": "E0042", "score": nil},
+			expected: map[string]any{"employee_number": "E0042"},
+		},
+		{
+			name:   "with missing required attribute",
+			in:     map[string]any{"score": 1.0},
+			hasErr: true,
+		},
+		{
+			name:   "with unknown attribute",
+			in:     map[string]any{"employee_number": "E0042", "shoe_size": 42.0},
+			hasErr: true,
+		},
+		{
+			name:   "with value not matching the regex",
+			in:     map[string]any{"employee_number": "42"},
+			hasErr: true,
+		},
+		{
+			name:   "with value out of the enum",
+			in:     map[string]any{"employee_number": "E0042", "preferred_language": "de"},
+			hasErr: true,
+		},
+		{
+			name:   "with non integer value",
+			in:     map[string]any{"employee_number": "E0042", "seniority": 1.5},
+			hasErr: true,
+		},
+		{
+			name:   "with invalid date",
+			in:     map[string]any{"employee_number": "E0042", "hired_on": "01/02/2020"},
+			hasErr: true,
+		},
+		{
+			name:   "with wrong type",
+			in:     map[string]any{"employee_number": "E0042", "remote": "yes"},
+			hasErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := mocks.NewAttributeRepository(t)
+			d.On("GetAll", mock.Anything).Return(definitions, nil)
+
+			svc, err := attribute.NewService(attribute.WithRepository(d))
+			require.NoError(t, err)
+
+			res, err := svc.Validate(context.TODO(), tc.in)
+			if tc.hasErr {
+				assert.ErrorIs(t, err, attribute.ErrInvalidAttribute)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, res)
+		})
+	}
+}
+
+func TestAttributeService_Validate_InvalidStoredRegex(t *testing.T) {
+	d := mocks.NewAttributeRepository(t)
+	d.On("GetAll", mock.Anything).Return([]dto.AttributeDefinitionDTO{
+		{Name: "badge", Type: attribute.TypeString, Regex: "^E[0-9"},
+	}, nil)
+
+	svc, err := attribute.NewService(attribute.WithRepository(d))
+	require.NoError(t, err)
+
+	_, err = svc.Validate(context.TODO(), map[string]any{"badge": "E42"})
+	assert.ErrorIs(t, err, attribute.ErrInvalidDefinition)
+}
+
+func TestAttributeService_ParseQuery(t *testing.T) {
+	d := mocks.NewAttributeRepository(t)
+	for _, def := range definitions {
+		d.On("Get", mock.Anything, def.Name).Return(def, nil).Maybe()
+	}
+	d.On("Get", mock.Anything, "unknown").Return(dto.AttributeDefinitionDTO{}, attribute.ErrRecordNotFound)
+
+	svc, err := attribute.NewService(attribute.WithRepository(d))
+	require.NoError(t, err)
+
+	v, numeric, err := svc.ParseQuery(context.TODO(), "seniority", "3")
+	assert.NoError(t, err)
+	assert.True(t, numeric)
+	assert.Equal(t, float64(3), v)
+
+	v, numeric, err = svc.ParseQuery(context.TODO(), "remote", "true")
+	assert.NoError(t, err)
+	assert.False(t, numeric)
+	assert.Equal(t, true, v)
+
+	// the regex is not applied to searched values.
+	v, _, err = svc.ParseQuery(context.TODO(), "employee_number", "E1")
+	assert.NoError(t, err)
+	assert.Equal(t, "E1", v)
+
+	_, _, err = svc.ParseQuery(context.TODO(), "seniority", "three")
+	assert.ErrorIs(t, err, attribute.ErrInvalidAttribute)
+
+	_, _, err = svc.ParseQuery(context.TODO(), "preferred_language", "de")
+	assert.ErrorIs(t, err, attribute.ErrInvalidAttribute)
+
+	_, _, err = svc.ParseQuery(context.TODO(), "unknown", "1")
+	assert.ErrorIs(t, err, attribute.ErrInvalidAttribute)
+}
+
+type personCache struct {
+	evicted int
+}
+
+func (c *personCache) EvictTenant(context.Context) {
+	c.evicted++
+}
+
+func TestAttributeService_Delete(t *testing.T) {
+	d := mocks.NewAttributeRepository(t)
+	d.On("Delete", mock.Anything, "badge").Return(nil).Once()
+
+	persons := &personCache{}
+	svc, err := attribute.NewService(attribute.WithRepository(d), attribute.WithPersonCache(persons))
+	require.NoError(t, err)
+
+	// the persons cached with the values of the attribute are evicted.
+	require.NoError(t, svc.Delete(context.TODO(), "badge"))
+	assert.Equal(t, 1, persons.evicted)
+}
@@ -0,0 +1,345 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"qore-be/internal/logging"
+	"sync"
+	"time"
+)
+
+var (
+	ErrOpen = fmt.Errorf("circuit breaker open")
+	ErrBusy = fmt.Errorf("too many concurrent operations")
+)
+
+// State of a circuit breaker.
+type State int
+
+// The breaker lets the calls through while closed. It opens after too many
+// failures, rejecting the calls, and is half-open after a while: a few probe
+// calls are let through, which close it when they succeed.
+const (
+	StateClosed State = iota
+	StateHalfOpen
+	StateOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "open"
+	}
+}
+
+// Metrics records the state of the breaker and the rejected calls.
+type Metrics interface {
+	BreakerState(state string)
+	BreakerRejection(op string, reason string)
+}
+
+// RejectedError is returned for the calls the breaker did not let through.
+type RejectedError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RejectedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RejectedError) Unwrap() error {
+	return e.Err
+}
+
+// Breaker is a circuit breaker, which also bounds the number of concurrent
+// calls of each operation.
+type Breaker struct {
+	threshold   int
+	openTimeout time.Duration
+	probes      int
+	limit       int
+	limits      map[string]int
+	metrics     Metrics
+
+	mu         sync.Mutex
+	state      State
+	generation uint64
+	failures   int
+	successes  int
+	probing    int
+	openedAt   time.Time
+	inflight   map[string]int
+}
+
+// Option ..
+type Option func(*Breaker) error
+
+// New creates a closed circuit breaker. By default it opens after 5
+// consecutive failures, for 10 seconds, and probes with a single call.
+func New(opts ...Option) (*Breaker, error) {
+	b := &Breaker{
+		threshold:   5,
+		openTimeout: 10 * time.Second,
+		probes:      1,
+		limits:      map[string]int{},
+		inflight:    map[string]int{},
+	}
+
+	for _, opt := range opts {
+		if err := opt(b); err != nil {
+			return nil, err
+		}
+	}
+
+	if b.metrics != nil {
+		b.metrics.BreakerState(b.state.String())
+	}
+
+	return b, nil
+}
+
+// WithFailureThreshold opens the breaker after the given number of
+// consecutive failures.
+func WithFailureThreshold(n int) Option {
+	return func(b *Breaker) error {
+		if n <= 0 {
+			return fmt.Errorf("invalid failure threshold: %d", n)
+		}
+		b.threshold = n
+		return nil
+	}
+}
+
+// WithOpenTimeout sets how long the breaker stays open before probing.
+func WithOpenTimeout(d time.Duration) Option {
+	return func(b *Breaker) error {
+		if d <= 0 {
+			return fmt.Errorf("invalid open timeout: %v", d)
+		}
+		b.openTimeout = d
+		return nil
+	}
+}
+
+// WithHalfOpenProbes sets the number of probe calls let through while
+// half-open, all of which must succeed to close the breaker.
+func WithHalfOpenProbes(n int) Option {
+	return func(b *Breaker) error {
+		if n <= 0 {
+			return fmt.Errorf("invalid half-open probes: %d", n)
+		}
+		b.probes = n
+		return nil
+	}
+}
+
+// WithConcurrency bounds the concurrent calls of every operation, unless
+// overridden by WithOperationConcurrency. Zero means no limit.
+func WithConcurrency(limit int) Option {
+	return func(b *Breaker) error {
+		if limit < 0 {
+			return fmt.Errorf("invalid concurrency limit: %d", limit)
+		}
+		b.limit = limit
+		return nil
+	}
+}
+
+// WithOperationConcurrency bounds the concurrent calls of the given
+// operations, by name.
+func WithOperationConcurrency(limits map[string]int) Option {
+	return func(b *Breaker) error {
+		for op, limit := range limits {
+			if limit < 0 {
+				return fmt.Errorf("invalid %s concurrency limit: %d", op, limit)
+			}
+			b.limits[op] = limit
+		}
+		return nil
+	}
+}
+
+// WithMetrics records the state of the breaker and the rejected calls.
+func WithMetrics(m Metrics) Option {
+	return func(b *Breaker) error {
+		if m == nil {
+			return fmt.Errorf("nil metrics")
+		}
+		b.metrics = m
+		return nil
+	}
+}
+
+// State returns the state of the breaker.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.expire(context.Background())
+	return b.state
+}
+
+// Check fails while the breaker is open.
+func (b *Breaker) Check(context.Context) error {
+	if b.State() == StateOpen {
+		return ErrOpen
+	}
+	return nil
+}
+
+// Do runs fn as the given operation, if the breaker and the concurrency limit
+// of the operation let it through. The errors of fn count as failures,
+// except the expected ones: the outcomes the dependency answered, e.g. not
+// found errors.
+func (b *Breaker) Do(ctx context.Context, op string, fn func(context.Context) error, expected ...error) error {
+	generation, err := b.allow(ctx, op)
+	if err != nil {
+		return err
+	}
+
+	err = fn(ctx)
+	b.done(ctx, op, generation, classify(ctx, err, expected))
+	return err
+}
+
+// Call runs fn as Do does, returning its result.
+func Call[T any](ctx context.Context, b *Breaker, op string, fn func(context.Context) (T, error), expected ...error) (T, error) {
+	var res T
+	err := b.Do(ctx, op, func(ctx context.Context) error {
+		var err error
+		res, err = fn(ctx)
+		return err
+	}, expected...)
+	return res, err
+}
+
+type outcome int
+
+const (
+	succeeded outcome = iota
+	failed
+	ignored
+)
+
+func classify(ctx context.Context, err error, expected []error) outcome {
+	if err == nil {
+		return succeeded
+	}
+	for _, e := range expected {
+		if errors.Is(err, e) {
+			return succeeded
+		}
+	}
+	// the calls canceled by their caller tell nothing about the dependency.
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return ignored
+	}
+	return failed
+}
+
+// allow reserves a slot for a call of the operation.
+func (b *Breaker) allow(ctx context.Context, op string) (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.expire(ctx)
+
+	switch {
+	case b.state == StateOpen:
+		return 0, b.reject(op, "open", &RejectedError{Err: ErrOpen, RetryAfter: b.openTimeout - time.Since(b.openedAt)})
+	case b.state == StateHalfOpen && b.probing >= b.probes:
+		return 0, b.reject(op, "open", &RejectedError{Err: ErrOpen, RetryAfter: time.Second})
+	}
+
+	limit, ok := b.limits[op]
+	if !ok {
+		limit = b.limit
+	}
+	if limit > 0 && b.inflight[op] >= limit {
+		return 0, b.reject(op, "busy", &RejectedError{Err: ErrBusy, RetryAfter: time.Second})
+	}
+
+	b.inflight[op]++
+	if b.state == StateHalfOpen {
+		b.probing++
+	}
+	return b.generation, nil
+}
+
+func (b *Breaker) reject(op string, reason string, err *RejectedError) error {
+	if b.metrics != nil {
+		b.metrics.BreakerRejection(op, reason)
+	}
+	return err
+}
+
+// done releases the slot of a call, recording its outcome unless the call
+// started in a previous state.
+func (b *Breaker) done(ctx context.Context, op string, generation uint64, res outcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.inflight[op]--
+	if b.inflight[op] == 0 {
+		delete(b.inflight, op)
+	}
+
+	if generation != b.generation {
+		return
+	}
+	if b.state == StateHalfOpen {
+		b.probing--
+	}
+
+	switch {
+	case res == ignored:
+	case res == succeeded && b.state == StateHalfOpen:
+		b.successes++
+		if b.successes >= b.probes {
+			b.setState(ctx, StateClosed)
+		}
+	case res == succeeded:
+		b.failures = 0
+	case b.state == StateHalfOpen:
+		b.setState(ctx, StateOpen)
+	default:
+		b.failures++
+		if b.failures >= b.threshold {
+			b.setState(ctx, StateOpen)
+		}
+	}
+}
+
+// expire half-opens the breaker once open for long enough.
+func (b *Breaker) expire(ctx context.Context) {
+	if b.state == StateOpen && time.Since(b.openedAt) >= b.openTimeout {
+		b.setState(ctx, StateHalfOpen)
+	}
+}
+
+func (b *Breaker) setState(ctx context.Context, state State) {
+	prev := b.state
+	b.state = state
+	b.generation++
+	b.failures, b.successes, b.probing = 0, 0, 0
+	if state == StateOpen {
+		b.openedAt = time.Now()
+	}
+
+	log := logging.FromContext(ctx)
+	if state == StateOpen {
+		log.Warn("circuit breaker opened", "from", prev.String(), "open_timeout", b.openTimeout.String())
+	} else {
+		log.Info("circuit breaker state changed", "from", prev.String(), "to", state.String())
+	}
+
+	if b.metrics != nil {
+		b.metrics.BreakerState(state.String())
+	}
+}
@@ -0,0 +1,262 @@
+package breaker_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"qore-be/internal/breaker"
+	"sync"
+	"time"
+
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	errDB       = fmt.Errorf("connection refused")
+	errNotFound = fmt.Errorf("not found")
+)
+
+func succeed(context.Context) error { return nil }
+
+func fail(context.Context) error { return errDB }
+
+type fakeMetrics struct {
+	mu         sync.Mutex
+	states     []string
+	rejections map[string]int
+}
+
+func (m *fakeMetrics) BreakerState(state string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.states = append(m.states, state)
+}
+
+func (m *fakeMetrics) BreakerRejection(op string, reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.rejections == nil {
+		m.rejections = map[string]int{}
+	}
+	m.rejections[op+"/"+reason]++
+}
+
+func TestNew(t *testing.T) {
+	cases := []struct {
+		name string
+		opt  breaker.Option
+	}{
+		{name: "with invalid threshold", opt: breaker.WithFailureThreshold(0)},
+		{name: "with invalid open timeout", opt: breaker.WithOpenTimeout(0)},
+		{name: "with invalid probes", opt: breaker.WithHalfOpenProbes(0)},
+		{name: "with invalid concurrency", opt: breaker.WithConcurrency(-1)},
+		{name: "with invalid operation concurrency", opt: breaker.WithOperationConcurrency(map[string]int{"op": -1})},
+		{name: "with nil metrics", opt: breaker.WithMetrics(nil)},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := breaker.New(tc.opt)
+			assert.Error(t, err)
+			assert.Nil(t, b)
+		})
+	}
+}
+
+func TestBreaker_Do(t *testing.T) {
+	ctx := context.Background()
+	m := &fakeMetrics{}
+	b, err := breaker.New(
+		breaker.WithFailureThreshold(3),
+		breaker.WithOpenTimeout(50*time.Millisecond),
+		breaker.WithMetrics(m),
+	)
+	require.NoError(t, err)
+
+	// the expected errors and the successes reset the failures.
+	require.ErrorIs(t, b.Do(ctx, "op", fail), errDB)
+	require.ErrorIs(t, b.Do(ctx, "op", fail), errDB)
+	require.NoError(t, b.Do(ctx, "op", succeed))
+	require.ErrorIs(t, b.Do(ctx, "op", fail), errDB)
+	require.ErrorIs(t, b.Do(ctx, "op", fail), errDB)
+	notFound := func(context.Context) error { return errNotFound }
+	require.ErrorIs(t, b.Do(ctx, "op", notFound, errNotFound), errNotFound)
+	assert.Equal(t, breaker.StateClosed, b.State())
+
+	// as do the calls canceled by their caller.
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	for i := 0; i < 3; i++ {
+		require.Error(t, b.Do(canceled, "op", func(ctx context.Context) error { return ctx.Err() }))
+	}
+	assert.Equal(t, breaker.StateClosed, b.State())
+
+	for i := 0; i < 3; i++ {
+		require.ErrorIs(t, b.Do(ctx, "op", fail), errDB)
+	}
+	assert.Equal(t, breaker.StateOpen, b.State())
+	assert.ErrorIs(t, b.Check(ctx), breaker.ErrOpen)
+
+	// the calls are rejected without being run.
+	called := false
+	err = b.Do(ctx, "op", func(context.Context) error { called = true; return nil })
+	assert.False(t, called)
+	assert.ErrorIs(t, err, breaker.ErrOpen)
+	var rejected *breaker.RejectedError
+	require.ErrorAs(t, err, &rejected)
+	assert.Greater(t, rejected.RetryAfter, time.Duration(0))
+	assert.LessOrEqual(t, rejected.RetryAfter, 50*time.Millisecond)
+
+	// a failed probe opens it again.
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, breaker.StateHalfOpen, b.State())
+	assert.NoError(t, b.Check(ctx))
+	require.ErrorIs(t, b.Do(ctx, "op", fail), errDB)
+	assert.Equal(t, breaker.StateOpen, b.State())
+
+	// a successful one closes it.
+	time.Sleep(60 * time.Millisecond)
+	require.NoError(t, b.Do(ctx, "op", succeed))
+	assert.Equal(t, breaker.StateClosed, b.State())
+
+	assert.Equal(t, []string{"closed", "open", "half-open", "open", "half-open", "closed"}, m.states)
+	assert.Equal(t, map[string]int{"op/open": 1}, m.rejections)
+}
+
+func TestCall(t *testing.T) {
+	ctx := context.Background()
+	b, err := breaker.New(breaker.WithFailureThreshold(1))
+	require.NoError(t, err)
+
+	res, err := breaker.Call(ctx, b, "op", func(context.Context) (int, error) { return 42, nil })
+	require.NoError(t, err)
+	assert.Equal(t, 42, res)
+
+	// the expected errors are returned without opening the breaker.
+	_, err = breaker.Call(ctx, b, "op", func(context.Context) (int, error) { return 0, errNotFound }, errNotFound)
+	assert.ErrorIs(t, err, errNotFound)
+	assert.Equal(t, breaker.StateClosed, b.State())
+
+	_, err = breaker.Call(ctx, b, "op", func(context.Context) (int, error) { return 0, errDB })
+	assert.ErrorIs(t, err, errDB)
+	assert.Equal(t, breaker.StateOpen, b.State())
+}
+
+func TestBreaker_HalfOpenProbes(t *testing.T) {
+	ctx := context.Background()
+	b, err := breaker.New(
+		breaker.WithFailureThreshold(1),
+		breaker.WithOpenTimeout(10*time.Millisecond),
+		breaker.WithHalfOpenProbes(2),
+	)
+	require.NoError(t, err)
+
+	require.Error(t, b.Do(ctx, "op", fail))
+	time.Sleep(20 * time.Millisecond)
+
+	// the probes in flight are limited, the other calls rejected.
+	started, release := make(chan struct{}), make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, b.Do(ctx, "op", func(context.Context) error {
+				started <- struct{}{}
+				<-release
+				return nil
+			}))
+		}()
+		<-started
+	}
+
+	assert.ErrorIs(t, b.Do(ctx, "op", succeed), breaker.ErrOpen)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, breaker.StateClosed, b.State())
+}
+
+func TestBreaker_Concurrency(t *testing.T) {
+	ctx := context.Background()
+	m := &fakeMetrics{}
+	b, err := breaker.New(
+		breaker.WithConcurrency(2),
+		breaker.WithOperationConcurrency(map[string]int{"slow": 1}),
+		breaker.WithMetrics(m),
+	)
+	require.NoError(t, err)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	block := func(context.Context) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	for _, op := range []string{"fast", "fast", "slow"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, b.Do(ctx, op, block))
+		}()
+		<-started
+	}
+
+	assert.ErrorIs(t, b.Do(ctx, "fast", succeed), breaker.ErrBusy)
+	assert.ErrorIs(t, b.Do(ctx, "slow", succeed), breaker.ErrBusy)
+	assert.NoError(t, b.Do(ctx, "other", succeed))
+
+	close(release)
+	wg.Wait()
+
+	// the slots are released, and the rejections do not open the breaker.
+	assert.NoError(t, b.Do(ctx, "slow", succeed))
+	assert.Equal(t, breaker.StateClosed, b.State())
+	assert.Equal(t, map[string]int{"fast/busy": 1, "slow/busy": 1}, m.rejections)
+}
+
+func TestWriteError(t *testing.T) {
+	cases := []struct {
+		name               string
+		err                error
+		expectedStatus     int
+		expectedRetryAfter string
+	}{
+		{
+			name:               "open",
+			err:                &breaker.RejectedError{Err: breaker.ErrOpen, RetryAfter: 2500 * time.Millisecond},
+			expectedStatus:     http.StatusServiceUnavailable,
+			expectedRetryAfter: "3",
+		},
+		{
+			name:               "busy",
+			err:                fmt.Errorf("wrapped: %w", &breaker.RejectedError{Err: breaker.ErrBusy}),
+			expectedStatus:     http.StatusServiceUnavailable,
+			expectedRetryAfter: "1",
+		},
+		{
+			name:           "other error",
+			err:            fmt.Errorf("boom"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(rec)
+
+			breaker.WriteError(ctx, tc.err)
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.Equal(t, tc.expectedRetryAfter, rec.Header().Get("Retry-After"))
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package breaker
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WriteError responds to a request whose call failed with err: a 503 with a
+// Retry-After header when the breaker rejected the call, a 500 otherwise.
+func WriteError(ctx *gin.Context, err error) {
+	var rejected *RejectedError
+	if !errors.As(err, &rejected) {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	seconds := int((rejected.RetryAfter + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	ctx.Header("Retry-After", strconv.Itoa(seconds))
+	ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+}
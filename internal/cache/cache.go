@@ -0,0 +1,17 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Cache stores values by key and field, e.g. the persons by ID and tenant, so
+// that deleting a key deletes the values of all its fields.
+type Cache interface {
+	// Get returns the value of the field of the key, and whether it was found.
+	Get(ctx context.Context, key string, field string) ([]byte, bool, error)
+	// Set stores the value of the field of the key for the ttl.
+	Set(ctx context.Context, key string, field string, value []byte, ttl time.Duration) error
+	// Delete deletes the keys along with all their fields.
+	Delete(ctx context.Context, keys ...string) error
+}
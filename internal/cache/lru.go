@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// LRU is an in-process cache holding a bounded number of values, evicting the
+// least recently used ones first.
+type LRU struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List
+	items map[string]map[string]*list.Element
+}
+
+type entry struct {
+	key       string
+	field     string
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewLRU creates a cache holding at most size values.
+func NewLRU(size int) (*LRU, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("invalid cache size: %d", size)
+	}
+
+	return &LRU{
+		size:  size,
+		order: list.New(),
+		items: map[string]map[string]*list.Element{},
+	}, nil
+}
+
+// Get implements Cache.
+func (c *LRU) Get(_ context.Context, key string, field string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key][field]
+	if !ok {
+		return nil, false, nil
+	}
+
+	e := el.Value.(*entry)
+	if time.Now().After(e.expiresAt) {
+		c.remove(el)
+		return nil, false, nil
+	}
+
+	c.order.MoveToFront(el)
+	return e.value, true, nil
+}
+
+// Set implements Cache.
+func (c *LRU) Set(_ context.Context, key string, field string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := time.Now().Add(ttl)
+	if el, ok := c.items[key][field]; ok {
+		e := el.Value.(*entry)
+		e.value, e.expiresAt = value, expiresAt
+		c.order.MoveToFront(el)
+		return nil
+	}
+
+	fields, ok := c.items[key]
+	if !ok {
+		fields = map[string]*list.Element{}
+		c.items[key] = fields
+	}
+	fields[field] = c.order.PushFront(&entry{key: key, field: field, value: value, expiresAt: expiresAt})
+
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+	return nil
+}
+
+// Delete implements Cache.
+func (c *LRU) Delete(_ context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		for _, el := range c.items[key] {
+			c.order.Remove(el)
+		}
+		delete(c.items, key)
+	}
+	return nil
+}
+
+// Len returns the number of values held, including the expired ones not
+// evicted yet.
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+func (c *LRU) remove(el *list.Element) {
+	e := c.order.Remove(el).(*entry)
+
+	fields := c.items[e.key]
+	delete(fields, e.field)
+	if len(fields) == 0 {
+		delete(c.items, e.key)
+	}
+}
@@ -0,0 +1,89 @@
+package cache_test
+
+import (
+	"context"
+	"qore-be/internal/cache"
+	"time"
+
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLRU(t *testing.T) {
+	c, err := cache.NewLRU(0)
+	assert.Error(t, err)
+	assert.Nil(t, c)
+}
+
+func TestLRU(t *testing.T) {
+	ctx := context.Background()
+	c, err := cache.NewLRU(3)
+	require.NoError(t, err)
+
+	testCache(t, c)
+
+	// the least recently used values are evicted first.
+	require.NoError(t, c.Set(ctx, "a", "1", []byte("a1"), time.Minute))
+	require.NoError(t, c.Set(ctx, "a", "2", []byte("a2"), time.Minute))
+	require.NoError(t, c.Set(ctx, "b", "1", []byte("b1"), time.Minute))
+	_, ok, _ := c.Get(ctx, "a", "1")
+	require.True(t, ok)
+	require.NoError(t, c.Set(ctx, "c", "1", []byte("c1"), time.Minute))
+
+	assert.Equal(t, 3, c.Len())
+	for _, tc := range []struct {
+		key, field string
+		cached     bool
+	}{
+		{"a", "1", true},
+		{"a", "2", false},
+		{"b", "1", true},
+		{"c", "1", true},
+	} {
+		_, ok, err := c.Get(ctx, tc.key, tc.field)
+		require.NoError(t, err)
+		assert.Equal(t, tc.cached, ok, "%s/%s", tc.key, tc.field)
+	}
+}
+
+// testCache runs the tests every cache must pass.
+func testCache(t *testing.T, c cache.Cache) {
+	ctx := context.Background()
+
+	_, ok, err := c.Get(ctx, "person:1", "acme")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, c.Set(ctx, "person:1", "acme", []byte("homer"), time.Minute))
+	require.NoError(t, c.Set(ctx, "person:1", "globex", []byte("homer j."), time.Minute))
+	require.NoError(t, c.Set(ctx, "person:2", "acme", []byte("marge"), time.Minute))
+	require.NoError(t, c.Set(ctx, "person:3", "acme", []byte("bart"), 10*time.Millisecond))
+
+	v, ok, err := c.Get(ctx, "person:1", "globex")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("homer j."), v)
+
+	// the values expire.
+	time.Sleep(20 * time.Millisecond)
+	_, ok, err = c.Get(ctx, "person:3", "acme")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// deleting a key deletes all its fields.
+	require.NoError(t, c.Delete(ctx, "person:1", "person:4"))
+	for _, field := range []string{"acme", "globex"} {
+		_, ok, err = c.Get(ctx, "person:1", field)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	}
+
+	v, ok, err = c.Get(ctx, "person:2", "acme")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("marge"), v)
+
+	require.NoError(t, c.Delete(ctx, "person:2"))
+}
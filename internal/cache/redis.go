@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis is a cache shared by the instances of the service. The fields of a
+// key are stored in a Redis hash, living as long as its longest-lived field.
+// It needs Redis 7.0 or later.
+type Redis struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedis creates a cache storing its keys in Redis, prefixed by prefix.
+func NewRedis(client redis.UniversalClient, prefix string) *Redis {
+	if client == nil {
+		panic("nil redis client")
+	}
+	return &Redis{client: client, prefix: prefix}
+}
+
+// Get implements Cache.
+func (c *Redis) Get(ctx context.Context, key string, field string) ([]byte, bool, error) {
+	b, err := c.client.HGet(ctx, c.prefix+key, field).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	// a hash expires as a whole: each value holds its own expiry.
+	if len(b) < 8 || time.Now().UnixMilli() >= int64(binary.BigEndian.Uint64(b)) {
+		return nil, false, nil
+	}
+	return b[8:], true, nil
+}
+
+// Set implements Cache.
+func (c *Redis) Set(ctx context.Context, key string, field string, value []byte, ttl time.Duration) error {
+	b := binary.BigEndian.AppendUint64(make([]byte, 0, 8+len(value)), uint64(time.Now().Add(ttl).UnixMilli()))
+	b = append(b, value...)
+
+	// the hash expiry is only ever extended: a shorter ttl, e.g. of an ID not
+	// found, must not cut the other fields short.
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, c.prefix+key, field, b)
+		pipe.Do(ctx, "PEXPIRE", c.prefix+key, ttl.Milliseconds(), "NX")
+		pipe.Do(ctx, "PEXPIRE", c.prefix+key, ttl.Milliseconds(), "GT")
+		return nil
+	})
+	return err
+}
+
+// Delete implements Cache.
+func (c *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = c.prefix + key
+	}
+	return c.client.Del(ctx, prefixed...).Err()
+}
+
+// Check pings the Redis server.
+func (c *Redis) Check(ctx context.Context) error {
+	return c.client.Ping(ctx).Err()
+}
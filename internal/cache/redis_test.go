@@ -0,0 +1,47 @@
+package cache_test
+
+import (
+	"context"
+	"qore-be/internal/cache"
+	"time"
+
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedis(t *testing.T) {
+	ctx := context.Background()
+	srv := miniredis.RunT(t)
+	c := cache.NewRedis(redis.NewClient(&redis.Options{Addr: srv.Addr()}), "qore:")
+
+	testCache(t, c)
+
+	// the keys are prefixed and expire.
+	require.NoError(t, c.Set(ctx, "person:1", "acme", []byte("homer"), time.Minute))
+	assert.True(t, srv.Exists("qore:person:1"))
+	srv.FastForward(time.Minute)
+	assert.False(t, srv.Exists("qore:person:1"))
+
+	// a field with a shorter ttl does not expire the others.
+	require.NoError(t, c.Set(ctx, "person:1", "acme", []byte("homer"), time.Minute))
+	require.NoError(t, c.Set(ctx, "person:1", "globex", []byte("null"), 5*time.Second))
+	srv.FastForward(10 * time.Second)
+	v, ok, err := c.Get(ctx, "person:1", "acme")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("homer"), v)
+	srv.FastForward(time.Minute)
+	assert.False(t, srv.Exists("qore:person:1"))
+
+	assert.NoError(t, c.Check(ctx))
+
+	// the errors are reported once the server is gone.
+	srv.Close()
+	_, _, err = c.Get(ctx, "person:1", "acme")
+	assert.Error(t, err)
+	assert.Error(t, c.Check(ctx))
+}
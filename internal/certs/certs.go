@@ -0,0 +1,165 @@
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"qore-be/internal/logging"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Loader loads the certificate of the server, and the CA certificates
+// verifying the clients, from their PEM files. They are loaded again
+// whenever the files change, without a restart.
+type Loader struct {
+	certFile   string
+	keyFile    string
+	caFile     string
+	clientAuth tls.ClientAuthType
+
+	current atomic.Pointer[loaded]
+}
+
+type loaded struct {
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+}
+
+// LoaderOption ..
+type LoaderOption func(*Loader) error
+
+// NewLoader loads the certificate and key files.
+func NewLoader(certFile string, keyFile string, opts ...LoaderOption) (*Loader, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("missing certificate or key file")
+	}
+
+	l := &Loader{certFile: certFile, keyFile: keyFile}
+	for _, opt := range opts {
+		if err := opt(l); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := l.Load(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// WithClientCA verifies the client certificates with the CA certificates of
+// the file: auth is tls.VerifyClientCertIfGiven or
+// tls.RequireAndVerifyClientCert.
+func WithClientCA(caFile string, auth tls.ClientAuthType) LoaderOption {
+	return func(l *Loader) error {
+		if caFile == "" {
+			return fmt.Errorf("missing client CA file")
+		}
+		if auth != tls.VerifyClientCertIfGiven && auth != tls.RequireAndVerifyClientCert {
+			return fmt.Errorf("invalid client authentication: %v", auth)
+		}
+		l.caFile, l.clientAuth = caFile, auth
+		return nil
+	}
+}
+
+// Load loads the files again. The current certificates are kept when they
+// cannot be loaded.
+func (l *Loader) Load() error {
+	cert, err := tls.LoadX509KeyPair(l.certFile, l.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load the TLS certificate: %w", err)
+	}
+	next := &loaded{cert: &cert}
+
+	if l.caFile != "" {
+		pem, err := os.ReadFile(l.caFile)
+		if err != nil {
+			return fmt.Errorf("failed to load the client CA certificates: %w", err)
+		}
+		next.clientCAs = x509.NewCertPool()
+		if !next.clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no client CA certificate found in %s", l.caFile)
+		}
+	}
+
+	l.current.Store(next)
+	return nil
+}
+
+// Config returns the TLS configuration of the server, serving the current
+// certificates. It may be completed, e.g. with the NextProtos, before use.
+func (l *Loader) Config() *tls.Config {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	cfg.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		cur := l.current.Load()
+
+		c := cfg.Clone()
+		c.GetConfigForClient = nil
+		c.Certificates = []tls.Certificate{*cur.cert}
+		c.ClientCAs, c.ClientAuth = cur.clientCAs, l.clientAuth
+		return c, nil
+	}
+	return cfg
+}
+
+// Watch loads the files again whenever they change, until ctx is done. The
+// failed loads are logged.
+func (l *Loader) Watch(ctx context.Context) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch the TLS certificates: %v", err)
+	}
+	defer w.Close()
+
+	// watches the directories: the files may be replaced rather than
+	// written, e.g. by cert-manager or a Kubernetes Secret update.
+	names := map[string]bool{"..data": true}
+	for _, file := range []string{l.certFile, l.keyFile, l.caFile} {
+		if file == "" {
+			continue
+		}
+		dir, name := filepath.Split(filepath.Clean(file))
+		names[name] = true
+		if err := w.Add(filepath.Clean(dir)); err != nil {
+			return fmt.Errorf("failed to watch the TLS certificates: %v", err)
+		}
+	}
+
+	// a renewal takes a few events: the load waits for the last one.
+	debounce := time.NewTimer(time.Hour)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	log := logging.FromContext(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			if names[filepath.Base(ev.Name)] {
+				debounce.Reset(100 * time.Millisecond)
+			}
+		case err, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+			log.Error("failed to watch the TLS certificates", "error", err.Error())
+		case <-debounce.C:
+			if err := l.Load(); err != nil {
+				log.Error("failed to reload the TLS certificates, keeping the current ones", "error", err.Error())
+				continue
+			}
+			log.Info("TLS certificates reloaded")
+		}
+	}
+}
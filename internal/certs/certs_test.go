@@ -0,0 +1,129 @@
+package certs_test
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"qore-be/internal/certs"
+	"qore-be/internal/certs/certstest"
+	"time"
+
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// handshake returns the common name of the certificate served with cfg.
+func handshake(t *testing.T, cfg *tls.Config, client *tls.Config) (string, error) {
+	t.Helper()
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", cfg)
+	require.NoError(t, err)
+	defer ln.Close()
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_ = conn.(*tls.Conn).Handshake()
+	}()
+
+	conn, err := tls.Dial("tcp", ln.Addr().String(), client)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	// the server verifies the client certificate after the client handshake.
+	if _, err := conn.Read(make([]byte, 1)); err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return conn.ConnectionState().PeerCertificates[0].Subject.CommonName, nil
+}
+
+func TestNewLoader(t *testing.T) {
+	ca := certstest.NewCA(t, "ca")
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	ca.Issue(t, "server", certFile, keyFile)
+
+	_, err := certs.NewLoader(certFile, "")
+	assert.Error(t, err)
+
+	_, err = certs.NewLoader(certFile, filepath.Join(dir, "missing.key"))
+	assert.ErrorContains(t, err, "failed to load the TLS certificate")
+
+	_, err = certs.NewLoader(certFile, keyFile, certs.WithClientCA(ca.File, tls.RequestClientCert))
+	assert.ErrorContains(t, err, "invalid client authentication")
+
+	_, err = certs.NewLoader(certFile, keyFile, certs.WithClientCA(keyFile, tls.RequireAndVerifyClientCert))
+	assert.ErrorContains(t, err, "no client CA certificate found")
+
+	_, err = certs.NewLoader(certFile, keyFile, certs.WithClientCA(ca.File, tls.RequireAndVerifyClientCert))
+	assert.NoError(t, err)
+}
+
+func TestLoader_ClientCA(t *testing.T) {
+	ca := certstest.NewCA(t, "ca")
+	other := certstest.NewCA(t, "other")
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	ca.Issue(t, "server", certFile, keyFile)
+
+	l, err := certs.NewLoader(certFile, keyFile, certs.WithClientCA(ca.File, tls.RequireAndVerifyClientCert))
+	require.NoError(t, err)
+
+	client := &tls.Config{RootCAs: ca.Pool, ServerName: "localhost"}
+	_, err = handshake(t, l.Config(), client)
+	assert.Error(t, err, "a client certificate is required")
+
+	client.Certificates = []tls.Certificate{other.Issue(t, "intruder", filepath.Join(dir, "intruder.crt"), filepath.Join(dir, "intruder.key"))}
+	_, err = handshake(t, l.Config(), client)
+	assert.Error(t, err, "the client certificate is verified")
+
+	client.Certificates = []tls.Certificate{ca.Issue(t, "client", filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key"))}
+	name, err := handshake(t, l.Config(), client)
+	require.NoError(t, err)
+	assert.Equal(t, "server", name)
+}
+
+func TestLoader_Watch(t *testing.T) {
+	ca := certstest.NewCA(t, "ca")
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	ca.Issue(t, "first", certFile, keyFile)
+
+	l, err := certs.NewLoader(certFile, keyFile)
+	require.NoError(t, err)
+	cfg := l.Config()
+	client := &tls.Config{RootCAs: ca.Pool, ServerName: "localhost"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- l.Watch(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+	})
+
+	// an invalid certificate is ignored.
+	require.NoError(t, os.WriteFile(certFile, []byte("not a certificate"), 0o600))
+	time.Sleep(300 * time.Millisecond)
+	name, err := handshake(t, cfg, client)
+	require.NoError(t, err)
+	assert.Equal(t, "first", name)
+
+	// the renewed certificate is served to the new connections. The watcher
+	// may start after the write: the certificate is issued until seen.
+	assert.Eventually(t, func() bool {
+		ca.Issue(t, "second", certFile, keyFile)
+		name, err := handshake(t, cfg, client)
+		return err == nil && name == "second"
+	}, 5*time.Second, 200*time.Millisecond)
+}
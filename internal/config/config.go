@@ -1,15 +1,409 @@
 package config
 
-import "github.com/caarlos0/env/v11"
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"qore-be/internal/secrets"
+	"reflect"
+	"strings"
+	"time"
 
+	"github.com/caarlos0/env/v11"
+)
+
+// Config is the project configuration. The settings tagged reload are
+// applied without a restart when the configuration is reloaded.
 type Config struct {
-	DBUrl string `env:"DB_URL"`
-	Host  string `env:"SERVER_HOST" envDefault:":8080"`
+	// File is the configuration file the settings were read from, if any.
+	File string
+
+	// DBDriver is the database: "mysql", "postgres" or "sqlite", whose DBUrl
+	// is a file path, e.g. "qore.db". With "memory", for a demo, there is no
+	// database: the persons are lost on exit.
+	DBDriver string `env:"DB_DRIVER" envDefault:"mysql"`
+	DBUrl    string `env:"DB_URL" secret:"true" reload:"true"`
+	Host     string `env:"SERVER_HOST" envDefault:":8080"`
+
+	// ShutdownDelay is the time the readiness probe fails before the server
+	// stops accepting connections, letting the load balancers route the
+	// traffic elsewhere. The requests in flight are then given
+	// ShutdownTimeout to end.
+	ShutdownDelay   time.Duration `env:"SHUTDOWN_DELAY" envDefault:"0s"`
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT" envDefault:"15s"`
+
+	// The server reads the request headers within ServerReadHeaderTimeout,
+	// the whole request within ServerReadTimeout, and writes the response
+	// within ServerWriteTimeout. The idle keep-alive connections are closed
+	// after ServerIdleTimeout. Zero means no timeout. The headers are limited
+	// to ServerMaxHeaderBytes, the bodies to ServerMaxBodyBytes.
+	ServerReadHeaderTimeout time.Duration `env:"SERVER_READ_HEADER_TIMEOUT" envDefault:"5s"`
+	ServerReadTimeout       time.Duration `env:"SERVER_READ_TIMEOUT" envDefault:"30s"`
+	ServerWriteTimeout      time.Duration `env:"SERVER_WRITE_TIMEOUT" envDefault:"30s"`
+	ServerIdleTimeout       time.Duration `env:"SERVER_IDLE_TIMEOUT" envDefault:"2m"`
+	ServerMaxHeaderBytes    int           `env:"SERVER_MAX_HEADER_BYTES" envDefault:"65536"`
+	ServerMaxBodyBytes      int64         `env:"SERVER_MAX_BODY_BYTES" envDefault:"1048576"`
+
+	// TLSCertFile and TLSKeyFile serve the API over TLS, reloaded when they
+	// change. TLSClientAuth verifies the client certificates with the CA
+	// certificates of TLSClientCAFile: "none", "optional", verifying the
+	// certificates sent, or "require". ServerHTTP2 serves HTTP/2 over TLS,
+	// and ServerH2C over cleartext, e.g. behind a proxy terminating TLS.
+	TLSCertFile     string `env:"TLS_CERT_FILE"`
+	TLSKeyFile      string `env:"TLS_KEY_FILE"`
+	TLSClientCAFile string `env:"TLS_CLIENT_CA_FILE"`
+	TLSClientAuth   string `env:"TLS_CLIENT_AUTH" envDefault:"none"`
+	ServerHTTP2     bool   `env:"SERVER_HTTP2" envDefault:"true"`
+	ServerH2C       bool   `env:"SERVER_H2C" envDefault:"false"`
+
+	// DBMaxOpenConns and DBMaxIdleConns size the connection pool, whose
+	// connections are renewed after DBConnMaxLifetime or DBConnMaxIdleTime idle.
+	DBMaxOpenConns    int           `env:"DB_MAX_OPEN_CONNS" envDefault:"25"`
+	DBMaxIdleConns    int           `env:"DB_MAX_IDLE_CONNS" envDefault:"5"`
+	DBConnMaxLifetime time.Duration `env:"DB_CONN_MAX_LIFETIME" envDefault:"30m"`
+	DBConnMaxIdleTime time.Duration `env:"DB_CONN_MAX_IDLE_TIME" envDefault:"5m"`
+
+	// DBConnectTimeout is how long to retry connecting to the database at
+	// startup, e.g. while its container starts.
+	DBConnectTimeout time.Duration `env:"DB_CONNECT_TIMEOUT" envDefault:"1m"`
+
+	// DBQueryTimeout bounds every repository call. The statements lasting more
+	// than DBSlowQuery are logged as slow.
+	DBQueryTimeout time.Duration `env:"DB_QUERY_TIMEOUT" envDefault:"5s"`
+	DBSlowQuery    time.Duration `env:"DB_SLOW_QUERY" envDefault:"200ms"`
+
+	// DBReplicaURLs are the read replicas of the database the person lookups
+	// go to, checked every DBReplicaCheckInterval. A client reads from the
+	// primary for DBReplicaSticky after its writes. Their credentials can be
+	// rotated on a reload, but not their number.
+	DBReplicaURLs          []string      `env:"DB_REPLICA_URLS" secret:"true" reload:"true"`
+	DBReplicaSticky        time.Duration `env:"DB_REPLICA_STICKY" envDefault:"5s"`
+	DBReplicaCheckInterval time.Duration `env:"DB_REPLICA_CHECK_INTERVAL" envDefault:"5s"`
+
+	// DBBreakerFailures is the number of consecutive failed repository calls
+	// opening the database circuit breaker, which then rejects the calls for
+	// DBBreakerOpenTimeout before letting DBBreakerProbes calls probe the
+	// database.
+	DBBreakerFailures    int           `env:"DB_BREAKER_FAILURES" envDefault:"5"`
+	DBBreakerOpenTimeout time.Duration `env:"DB_BREAKER_OPEN_TIMEOUT" envDefault:"10s"`
+	DBBreakerProbes      int           `env:"DB_BREAKER_PROBES" envDefault:"1"`
+
+	// DBConcurrency bounds the concurrent calls of each repository operation
+	// unless overridden by DBOperationConcurrency, e.g. "person.GetAll:10".
+	// Zero means no limit.
+	DBConcurrency          int            `env:"DB_CONCURRENCY" envDefault:"20"`
+	DBOperationConcurrency map[string]int `env:"DB_OPERATION_CONCURRENCY"`
+
+	// CacheBackend caches the persons read by ID: "none", "memory", holding
+	// CacheSize persons, or "redis", at RedisURL. The persons are cached for
+	// CacheTTL and the IDs not found for CacheNegativeTTL.
+	CacheBackend     string        `env:"CACHE_BACKEND" envDefault:"none"`
+	CacheSize        int           `env:"CACHE_SIZE" envDefault:"10000"`
+	CacheTTL         time.Duration `env:"CACHE_TTL" envDefault:"1m" reload:"true"`
+	CacheNegativeTTL time.Duration `env:"CACHE_NEGATIVE_TTL" envDefault:"5s" reload:"true"`
+	RedisURL         string        `env:"REDIS_URL" envDefault:"redis://localhost:6379/0" secret:"true"`
+
+	// LogFormat is the log handler, "text" or "json", and LogLevel the
+	// minimum level logged: "debug", "info", "warn" or "error".
+	LogFormat string `env:"LOG_FORMAT" envDefault:"text"`
+	LogLevel  string `env:"LOG_LEVEL" envDefault:"info" reload:"true"`
+
+	// LogRedact tells how each PII field (name, phone, address, email) is
+	// written to the logs: "clear", "hash", "mask" or "drop", e.g.
+	// "name:hash,phone:drop". LogRedactKey is the key of the hashes, required
+	// by the "hash" mode.
+	LogRedact    map[string]string `env:"LOG_REDACT" envDefault:"name:mask,phone:mask,address:mask,email:mask"`
+	LogRedactKey string            `env:"LOG_REDACT_KEY" secret:"true"`
+
+	// TenantTrustedProxies are the networks, e.g. "10.0.0.0/8", of the
+	// proxies allowed to name the tenant with the X-Tenant-ID header: the
+	// other clients naming one are refused. With TenantFromCertificate, the
+	// tenant of a client verified by its TLS certificate is the common name
+	// of the certificate.
+	TenantTrustedProxies  []string `env:"TENANT_TRUSTED_PROXIES"`
+	TenantFromCertificate bool     `env:"TENANT_FROM_CERTIFICATE" envDefault:"false"`
+
+	// CORSOrigins are the origins allowed to call the API, "*" meaning any.
+	CORSOrigins []string `env:"CORS_ORIGINS" envDefault:"*" reload:"true"`
+
+	// RateLimit is the number of requests per second allowed to each client,
+	// told apart by tenant and IP address, in bursts of RateLimitBurst. Zero
+	// means no limit.
+	RateLimit      float64 `env:"RATE_LIMIT" envDefault:"0" reload:"true"`
+	RateLimitBurst int     `env:"RATE_LIMIT_BURST" envDefault:"20" reload:"true"`
+
+	// Features switches the optional features on or off, e.g. "merge:false".
+	// They are on unless switched off.
+	Features map[string]bool `env:"FEATURES" reload:"true"`
+
+	// SecretsProvider holds the secret settings named by Secrets: "none",
+	// "file", reading them from the files of SecretsDir, or "vault", reading
+	// them from the KV version 2 engine mounted at VaultMount of the Vault
+	// server at VaultAddr. Secrets maps the settings to their secret, e.g.
+	// "DB_URL:db_url" for a file, or "DB_URL:qore/db#url" for the url key of
+	// the qore/db Vault secret. The secrets, and the files of the *_FILE
+	// settings, are read again every SecretsRefreshInterval, unless zero, to
+	// rotate the database credentials.
+	SecretsProvider        string            `env:"SECRETS_PROVIDER" envDefault:"none"`
+	Secrets                map[string]string `env:"SECRETS" reload:"true"`
+	SecretsDir             string            `env:"SECRETS_DIR" envDefault:"/run/secrets"`
+	SecretsRefreshInterval time.Duration     `env:"SECRETS_REFRESH_INTERVAL" envDefault:"5m"`
+	VaultAddr              string            `env:"VAULT_ADDR" envDefault:"http://127.0.0.1:8200"`
+	VaultToken             string            `env:"VAULT_TOKEN" secret:"true"`
+	VaultMount             string            `env:"VAULT_MOUNT" envDefault:"secret"`
+
+	// AdminToken is the bearer token guarding the admin routes, which are
+	// disabled when it is empty.
+	AdminToken string `env:"ADMIN_TOKEN" secret:"true"`
+
+	// DuplicateMode tells what to do when a created person possibly duplicates
+	// a stored one: "off", "warn" or "block".
+	DuplicateMode      string  `env:"DUPLICATE_MODE" envDefault:"off"`
+	DuplicateThreshold float64 `env:"DUPLICATE_THRESHOLD" envDefault:"0.85"`
+
+	// MergeRetention is the window during which a merge can be reverted.
+	MergeRetention time.Duration `env:"MERGE_RETENTION" envDefault:"720h"`
+
+	// MigrateOnStart applies the pending migrations when the server starts.
+	// Otherwise they are applied with `migrate up` and the server is not
+	// ready until then.
+	MigrateOnStart bool `env:"MIGRATE_ON_START" envDefault:"true"`
+
+	// HealthTimeout bounds each dependency check of the health endpoints.
+	HealthTimeout time.Duration `env:"HEALTH_TIMEOUT" envDefault:"2s"`
+
+	// DebugHost is the address of the admin listener serving the pprof and
+	// expvar routes, which is disabled when it is empty.
+	DebugHost   string `env:"DEBUG_HOST"`
+	DebugPprof  bool   `env:"DEBUG_PPROF" envDefault:"true"`
+	DebugExpvar bool   `env:"DEBUG_EXPVAR" envDefault:"true"`
+
+	// TracingExporter is where the spans are sent: "none", "stdout" or
+	// "otlp". TracingEndpoint is the OTLP/HTTP collector URL.
+	TracingExporter string `env:"TRACING_EXPORTER" envDefault:"none"`
+	TracingEndpoint string `env:"TRACING_ENDPOINT"`
 }
 
-// New initialize the project configuration.
-func New() (*Config, error) {
+// Load reads the configuration in layers: the defaults, overridden by the
+// configuration file, by the environment and then by the flags of args. The
+// file is given by the -config flag or CONFIG_FILE. Every secret can also be
+// read from a file, whose path is set instead of the secret, with the _FILE
+// suffix, e.g. DB_URL_FILE. Load returns the arguments left after the flags,
+// e.g. a subcommand.
+func Load(args []string) (*Config, []string, error) {
+	fs := flag.NewFlagSet("qore", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: qore [flags] [migrate up|down|status|to <version> | config show]")
+		fs.PrintDefaults()
+	}
+
+	file := fs.String("config", os.Getenv("CONFIG_FILE"), "configuration file, YAML or TOML")
+	for _, f := range fields() {
+		fs.String(flagName(f.key), "", "overrides "+f.key)
+		if f.secret {
+			fs.String(flagName(f.key+fileSuffix), "", "reads "+f.key+" from the file")
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	values := settings{}
+	if *file != "" {
+		layer, err := readFile(*file)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := values.apply(layer); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	layer := map[string]string{}
+	for _, kv := range os.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		layer[k] = v
+	}
+	if err := values.apply(layer); err != nil {
+		return nil, nil, err
+	}
+
+	layer = map[string]string{}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name != "config" {
+			layer[envKey(f.Name)] = f.Value.String()
+		}
+	})
+	if err := values.apply(layer); err != nil {
+		return nil, nil, err
+	}
+
+	if err := values.readSecrets(); err != nil {
+		return nil, nil, err
+	}
+
 	cfg := &Config{}
-	err := env.Parse(cfg)
-	return cfg, err
+	if err := env.ParseWithOptions(cfg, env.Options{Environment: values}); err != nil {
+		return nil, nil, parseErrors(err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	cfg.File = *file
+
+	return cfg, fs.Args(), nil
+}
+
+// ResolveSecrets sets the settings held by the secrets provider.
+func (c *Config) ResolveSecrets(ctx context.Context, p secrets.Provider) error {
+	v := reflect.ValueOf(c).Elem()
+	for _, f := range fields() {
+		name, ok := c.Secrets[f.key]
+		if !ok {
+			continue
+		}
+		if p == nil {
+			return fmt.Errorf("failed to read %s: no secrets provider", f.key)
+		}
+
+		value, err := p.Get(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", f.key, err)
+		}
+
+		switch field := v.Field(f.index); field.Kind() {
+		case reflect.String:
+			field.SetString(value)
+		default:
+			field.Set(reflect.ValueOf(strings.Split(value, ",")))
+		}
+	}
+	return nil
+}
+
+// fileSuffix suffixes the settings holding the path of the file of a secret.
+const fileSuffix = "_FILE"
+
+// settings are the raw settings, by environment variable key.
+type settings map[string]string
+
+// apply overrides the settings with the ones of a layer. Setting a secret, or
+// its file, overrides both.
+func (s settings) apply(layer map[string]string) error {
+	secret := map[string]bool{}
+	for _, f := range fields() {
+		if f.secret {
+			secret[f.key] = true
+		}
+	}
+
+	for key, value := range layer {
+		base, ok := strings.CutSuffix(key, fileSuffix)
+		if !ok || !secret[base] || value == "" {
+			continue
+		}
+		if layer[base] != "" {
+			return fmt.Errorf("both %s and %s are set", base, key)
+		}
+	}
+
+	for key, value := range layer {
+		base, ok := strings.CutSuffix(key, fileSuffix)
+		switch {
+		case ok && secret[base]:
+			if value != "" {
+				s[key] = value
+				delete(s, base)
+			}
+		case secret[key]:
+			if value != "" {
+				s[key] = value
+				delete(s, key+fileSuffix)
+			}
+		default:
+			s[key] = value
+		}
+	}
+	return nil
+}
+
+// readSecrets reads the secrets set by file.
+func (s settings) readSecrets() error {
+	for _, f := range fields() {
+		path := s[f.key+fileSuffix]
+		if !f.secret || path == "" {
+			continue
+		}
+
+		value, err := secrets.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", f.key+fileSuffix, err)
+		}
+		s[f.key] = value
+	}
+	return nil
+}
+
+// field is a configuration field, set by its environment variable key.
+type field struct {
+	name   string
+	key    string
+	index  int
+	secret bool
+	reload bool
+}
+
+func fields() []field {
+	t := reflect.TypeOf(Config{})
+
+	var fs []field
+	for i := 0; i < t.NumField(); i++ {
+		key, _, _ := strings.Cut(t.Field(i).Tag.Get("env"), ",")
+		if key == "" {
+			continue
+		}
+		fs = append(fs, field{
+			name:   t.Field(i).Name,
+			key:    key,
+			index:  i,
+			secret: t.Field(i).Tag.Get("secret") == "true",
+			reload: t.Field(i).Tag.Get("reload") == "true",
+		})
+	}
+	return fs
+}
+
+// parseErrors names the settings which could not be parsed by their key.
+func parseErrors(err error) error {
+	var agg env.AggregateError
+	if !errors.As(err, &agg) {
+		return err
+	}
+
+	keys := map[string]string{}
+	for _, f := range fields() {
+		keys[f.name] = f.key
+	}
+
+	errs := make([]error, len(agg.Errors))
+	for i, e := range agg.Errors {
+		errs[i] = e
+		var perr env.ParseError
+		if errors.As(e, &perr) {
+			errs[i] = fmt.Errorf("%s is invalid: %v", keys[perr.Name], perr.Err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// flagName returns the flag of an environment variable, e.g. -db-url for DB_URL.
+func flagName(key string) string {
+	return strings.ReplaceAll(strings.ToLower(key), "_", "-")
+}
+
+func envKey(flagName string) string {
+	return strings.ReplaceAll(strings.ToUpper(flagName), "-", "_")
 }
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// readFile reads the settings of the YAML or TOML configuration file. The
+// file keys are the lower case environment variables, e.g. db_url, whose
+// values may also be lists and maps.
+func readFile(path string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the configuration file: %v", err)
+	}
+
+	parsed := map[string]any{}
+	switch ext := filepath.Ext(path); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &parsed)
+	case ".toml":
+		err = toml.Unmarshal(b, &parsed)
+	default:
+		return nil, fmt.Errorf("unsupported configuration file format: %q", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the configuration file %s: %v", path, err)
+	}
+
+	known := map[string]bool{}
+	for _, f := range fields() {
+		known[f.key] = true
+		if f.secret {
+			known[f.key+fileSuffix] = true
+		}
+	}
+
+	values := map[string]string{}
+	for name, v := range parsed {
+		key := strings.ToUpper(name)
+		if !known[key] {
+			return nil, fmt.Errorf("unknown setting %q in the configuration file %s", name, path)
+		}
+		values[key] = envValue(v)
+	}
+
+	return values, nil
+}
+
+// envValue formats a file value as an environment variable: the lists are
+// comma separated and the maps are written as "key:value,key:value".
+func envValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = envValue(item)
+		}
+		return strings.Join(items, ",")
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		items := make([]string, len(keys))
+		for i, k := range keys {
+			items[i] = k + ":" + envValue(v[k])
+		}
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
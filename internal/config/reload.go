@@ -0,0 +1,180 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"qore-be/internal/logging"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Subscriber prepares the switch from the old configuration to the reloaded
+// one, or rejects the reload with an error. It returns the function ending
+// the switch: applying it when commit is true, or discarding it when another
+// subscriber rejected the reload.
+type Subscriber func(old *Config, cfg *Config) (finish func(commit bool), err error)
+
+// Reloader reloads the configuration at runtime. Only the settings tagged
+// reload are changed; the others need a restart.
+type Reloader struct {
+	args    []string
+	resolve func(context.Context, *Config) error
+
+	mu          sync.Mutex
+	current     atomic.Pointer[Config]
+	subscribers []Subscriber
+}
+
+// ReloaderOption ..
+type ReloaderOption func(*Reloader)
+
+// NewReloader creates the reloader of the configuration loaded from args.
+func NewReloader(cfg *Config, args []string, opts ...ReloaderOption) *Reloader {
+	r := &Reloader{args: args}
+	r.current.Store(cfg)
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// WithResolver completes the reloaded configurations, e.g. with the settings
+// of the secrets provider.
+func WithResolver(resolve func(context.Context, *Config) error) ReloaderOption {
+	return func(r *Reloader) {
+		r.resolve = resolve
+	}
+}
+
+// Config returns the current configuration, which must not be modified.
+func (r *Reloader) Config() *Config {
+	return r.current.Load()
+}
+
+// Subscribe calls s on every reload changing a setting.
+func (r *Reloader) Subscribe(s Subscriber) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.subscribers = append(r.subscribers, s)
+}
+
+// Reload loads the configuration again. The reload is rejected, keeping the
+// current configuration, when it is invalid or when a subscriber rejects it.
+// Otherwise it is applied by all the subscribers at once.
+func (r *Reloader) Reload(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	loaded, _, err := Load(r.args)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	if r.resolve != nil {
+		if err := r.resolve(ctx, loaded); err != nil {
+			return fmt.Errorf("invalid configuration: %w", err)
+		}
+	}
+
+	old := r.current.Load()
+	cfg := *old
+
+	var changed, restart []string
+	src, dst := reflect.ValueOf(loaded).Elem(), reflect.ValueOf(&cfg).Elem()
+	for _, f := range fields() {
+		if reflect.DeepEqual(src.Field(f.index).Interface(), dst.Field(f.index).Interface()) {
+			continue
+		}
+		if !f.reload {
+			restart = append(restart, f.key)
+			continue
+		}
+		dst.Field(f.index).Set(src.Field(f.index))
+		changed = append(changed, f.key)
+	}
+
+	log := logging.FromContext(ctx)
+	if len(restart) > 0 {
+		log.Warn("configuration changes ignored until restart", "settings", restart)
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	finishes := make([]func(bool), 0, len(r.subscribers))
+	for _, s := range r.subscribers {
+		finish, err := s(old, &cfg)
+		if err != nil {
+			for _, finish := range finishes {
+				finish(false)
+			}
+			return fmt.Errorf("configuration reload rejected: %w", err)
+		}
+		finishes = append(finishes, finish)
+	}
+	for _, finish := range finishes {
+		finish(true)
+	}
+	r.current.Store(&cfg)
+
+	log.Info("configuration reloaded", "settings", changed)
+	return nil
+}
+
+// Watch reloads the configuration whenever its file changes, until ctx is
+// done. The failed reloads are logged.
+func (r *Reloader) Watch(ctx context.Context) error {
+	file := r.Config().File
+	if file == "" {
+		return nil
+	}
+
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch the configuration file: %v", err)
+	}
+	defer w.Close()
+
+	// watches the directory: the file may be replaced rather than written,
+	// e.g. by an editor or a Kubernetes ConfigMap update.
+	dir, name := filepath.Split(filepath.Clean(file))
+	if err := w.Add(filepath.Clean(dir)); err != nil {
+		return fmt.Errorf("failed to watch the configuration file: %v", err)
+	}
+
+	// a change takes a few events: the reload waits for the last one.
+	debounce := time.NewTimer(time.Hour)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	log := logging.FromContext(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			if base := filepath.Base(ev.Name); base == name || base == "..data" {
+				debounce.Reset(100 * time.Millisecond)
+			}
+		case err, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+			log.Error("failed to watch the configuration file", "error", err.Error())
+		case <-debounce.C:
+			if err := r.Reload(ctx); err != nil {
+				log.Error("failed to reload the configuration, keeping the current one", "error", err.Error())
+			}
+		}
+	}
+}
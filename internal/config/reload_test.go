@@ -0,0 +1,131 @@
+package config_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"qore-be/internal/config"
+	"time"
+
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloader_Reload(t *testing.T) {
+	t.Setenv("DB_URL", "qore:s3cr3t@tcp(mysql:3306)/qore")
+	ctx := context.Background()
+	path := writeFile(t, "qore.yaml", "log_level: info\ncache_ttl: 1m\n")
+	args := []string{"-config", path}
+
+	cfg, _, err := config.Load(args)
+	require.NoError(t, err)
+	r := config.NewReloader(cfg, args)
+
+	var applied, discarded []*config.Config
+	r.Subscribe(func(old *config.Config, cfg *config.Config) (func(bool), error) {
+		return func(commit bool) {
+			if commit {
+				applied = append(applied, cfg)
+			} else {
+				discarded = append(discarded, cfg)
+			}
+		}, nil
+	})
+	reject := false
+	r.Subscribe(func(old *config.Config, cfg *config.Config) (func(bool), error) {
+		if reject {
+			return nil, errors.New("rejected")
+		}
+		return func(bool) {}, nil
+	})
+
+	// nothing changed.
+	require.NoError(t, r.Reload(ctx))
+	assert.Empty(t, applied)
+
+	// the reloadable settings change, the others need a restart.
+	require.NoError(t, os.WriteFile(path, []byte("log_level: debug\ncache_ttl: 2m\ndb_driver: sqlite\ndb_replica_urls: [replica.db]\n"), 0o600))
+	require.NoError(t, r.Reload(ctx))
+	require.Len(t, applied, 1)
+	assert.Same(t, applied[0], r.Config())
+	assert.Equal(t, "debug", r.Config().LogLevel)
+	assert.Equal(t, 2*time.Minute, r.Config().CacheTTL)
+	assert.Equal(t, []string{"replica.db"}, r.Config().DBReplicaURLs)
+	assert.Equal(t, "mysql", r.Config().DBDriver)
+	assert.Equal(t, "info", cfg.LogLevel, "the previous configuration is left unchanged")
+
+	// an invalid configuration is rejected.
+	require.NoError(t, os.WriteFile(path, []byte("log_level: loud\n"), 0o600))
+	assert.ErrorContains(t, r.Reload(ctx), "LOG_LEVEL")
+	assert.Equal(t, "debug", r.Config().LogLevel)
+
+	// and so is a configuration rejected by a subscriber, which the others
+	// discard.
+	reject = true
+	require.NoError(t, os.WriteFile(path, []byte("log_level: warn\n"), 0o600))
+	assert.ErrorContains(t, r.Reload(ctx), "rejected")
+	assert.Len(t, applied, 1)
+	assert.Len(t, discarded, 1)
+	assert.Equal(t, "debug", r.Config().LogLevel)
+}
+
+func TestReloader_Resolver(t *testing.T) {
+	t.Setenv("DB_URL", "qore:s3cr3t@tcp(mysql:3306)/qore")
+	ctx := context.Background()
+	cfg, _, err := config.Load(nil)
+	require.NoError(t, err)
+
+	password := "first"
+	r := config.NewReloader(cfg, nil, config.WithResolver(func(ctx context.Context, cfg *config.Config) error {
+		if password == "" {
+			return errors.New("secret not found")
+		}
+		cfg.DBUrl = "qore:" + password + "@tcp(mysql:3306)/qore"
+		return nil
+	}))
+
+	// the rotated secrets are reloaded.
+	require.NoError(t, r.Reload(ctx))
+	assert.Equal(t, "qore:first@tcp(mysql:3306)/qore", r.Config().DBUrl)
+	password = "second"
+	require.NoError(t, r.Reload(ctx))
+	assert.Equal(t, "qore:second@tcp(mysql:3306)/qore", r.Config().DBUrl)
+
+	password = ""
+	assert.ErrorContains(t, r.Reload(ctx), "secret not found")
+	assert.Equal(t, "qore:second@tcp(mysql:3306)/qore", r.Config().DBUrl)
+}
+
+func TestReloader_Watch(t *testing.T) {
+	t.Setenv("DB_URL", "qore:s3cr3t@tcp(mysql:3306)/qore")
+	path := writeFile(t, "qore.yaml", "log_level: info\n")
+	args := []string{"-config", path}
+
+	cfg, _, err := config.Load(args)
+	require.NoError(t, err)
+	r := config.NewReloader(cfg, args)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- r.Watch(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+	})
+
+	// the watcher may start after the write: the file is written until seen.
+	assert.Eventually(t, func() bool {
+		require.NoError(t, os.WriteFile(path, []byte("log_level: warn\n"), 0o600))
+		return r.Config().LogLevel == "warn"
+	}, 5*time.Second, 200*time.Millisecond)
+}
+
+func TestReloader_WatchWithoutFile(t *testing.T) {
+	t.Setenv("DB_URL", "qore:s3cr3t@tcp(mysql:3306)/qore")
+	cfg, _, err := config.Load(nil)
+	require.NoError(t, err)
+
+	assert.NoError(t, config.NewReloader(cfg, nil).Watch(context.Background()))
+}
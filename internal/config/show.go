@@ -0,0 +1,43 @@
+package config
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redacted replaces the secrets shown.
+const redacted = "REDACTED"
+
+// Show writes the configuration as a YAML configuration file, with the
+// secrets redacted.
+func (c *Config) Show(w io.Writer) error {
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+
+	v := reflect.ValueOf(c).Elem()
+	for _, f := range fields() {
+		value := v.Field(f.index).Interface()
+		switch {
+		case f.secret && !v.Field(f.index).IsZero():
+			value = redacted
+		case v.Field(f.index).Type() == reflect.TypeOf(time.Duration(0)):
+			value = value.(time.Duration).String()
+		}
+
+		node := &yaml.Node{}
+		if err := node.Encode(value); err != nil {
+			return err
+		}
+		doc.Content = append(doc.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: strings.ToLower(f.key)}, node)
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Validate checks the settings, returning all the invalid ones at once.
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	v.oneOf("DB_DRIVER", c.DBDriver, "mysql", "postgres", "sqlite", "memory")
+	v.check(c.Host != "", "SERVER_HOST", "is required")
+	v.notNegative("SHUTDOWN_DELAY", c.ShutdownDelay)
+	v.positive("SHUTDOWN_TIMEOUT", c.ShutdownTimeout)
+	v.notNegative("SERVER_READ_HEADER_TIMEOUT", c.ServerReadHeaderTimeout)
+	v.notNegative("SERVER_READ_TIMEOUT", c.ServerReadTimeout)
+	v.notNegative("SERVER_WRITE_TIMEOUT", c.ServerWriteTimeout)
+	v.notNegative("SERVER_IDLE_TIMEOUT", c.ServerIdleTimeout)
+	v.check(c.ServerMaxHeaderBytes > 0, "SERVER_MAX_HEADER_BYTES", "must be positive")
+	v.check(c.ServerMaxBodyBytes > 0, "SERVER_MAX_BODY_BYTES", "must be positive")
+
+	v.check((c.TLSCertFile == "") == (c.TLSKeyFile == ""), "TLS_KEY_FILE", "must be set along with TLS_CERT_FILE")
+	v.oneOf("TLS_CLIENT_AUTH", c.TLSClientAuth, "none", "optional", "require")
+	v.check(c.TLSClientAuth == "none" || c.TLSClientCAFile != "", "TLS_CLIENT_AUTH", "requires TLS_CLIENT_CA_FILE")
+	v.check(c.TLSClientCAFile == "" || c.TLSClientAuth != "none", "TLS_CLIENT_CA_FILE", "requires TLS_CLIENT_AUTH")
+	v.check(c.TLSClientAuth == "none" || c.TLSCertFile != "", "TLS_CLIENT_AUTH", "requires TLS_CERT_FILE")
+	v.check(!c.ServerH2C || c.TLSCertFile == "", "SERVER_H2C", "cannot be used with TLS")
+
+	for _, network := range c.TenantTrustedProxies {
+		_, err := netip.ParsePrefix(network)
+		v.check(err == nil, "TENANT_TRUSTED_PROXIES", "%q is not a network, e.g. 10.0.0.0/8", network)
+	}
+	v.check(!c.TenantFromCertificate || c.TLSClientAuth != "none", "TENANT_FROM_CERTIFICATE", "requires TLS_CLIENT_AUTH")
+
+	v.check(c.DBMaxOpenConns >= 0, "DB_MAX_OPEN_CONNS", "must not be negative")
+	v.check(c.DBMaxIdleConns >= 0, "DB_MAX_IDLE_CONNS", "must not be negative")
+	v.notNegative("DB_CONN_MAX_LIFETIME", c.DBConnMaxLifetime)
+	v.notNegative("DB_CONN_MAX_IDLE_TIME", c.DBConnMaxIdleTime)
+	v.notNegative("DB_CONNECT_TIMEOUT", c.DBConnectTimeout)
+	v.notNegative("DB_QUERY_TIMEOUT", c.DBQueryTimeout)
+	v.notNegative("DB_SLOW_QUERY", c.DBSlowQuery)
+
+	_, secretURL := c.Secrets["DB_URL"]
+	memory := c.DBDriver == "memory"
+	v.check(memory || c.DBUrl != "" || secretURL, "DB_URL", "is required by the %s driver", c.DBDriver)
+	v.check(!memory || (c.DBUrl == "" && !secretURL), "DB_URL", "cannot be used with the memory driver")
+	v.check(!memory || len(c.DBReplicaURLs) == 0, "DB_REPLICA_URLS", "cannot be used with the memory driver")
+	v.notNegative("DB_REPLICA_STICKY", c.DBReplicaSticky)
+	v.positive("DB_REPLICA_CHECK_INTERVAL", c.DBReplicaCheckInterval)
+
+	v.check(c.DBBreakerFailures > 0, "DB_BREAKER_FAILURES", "must be positive")
+	v.positive("DB_BREAKER_OPEN_TIMEOUT", c.DBBreakerOpenTimeout)
+	v.check(c.DBBreakerProbes > 0, "DB_BREAKER_PROBES", "must be positive")
+	v.check(c.DBConcurrency >= 0, "DB_CONCURRENCY", "must not be negative")
+	for op, limit := range c.DBOperationConcurrency {
+		v.check(limit >= 0, "DB_OPERATION_CONCURRENCY", "%s must not be negative", op)
+	}
+
+	v.oneOf("CACHE_BACKEND", c.CacheBackend, "none", "memory", "redis")
+	v.check(c.CacheBackend != "memory" || c.CacheSize > 0, "CACHE_SIZE", "must be positive")
+	v.check(c.CacheBackend != "redis" || c.RedisURL != "", "REDIS_URL", "is required by the redis cache")
+	v.positive("CACHE_TTL", c.CacheTTL)
+	v.notNegative("CACHE_NEGATIVE_TTL", c.CacheNegativeTTL)
+
+	v.oneOf("LOG_FORMAT", c.LogFormat, "text", "json")
+	_, secretKey := c.Secrets["LOG_REDACT_KEY"]
+	for field, mode := range c.LogRedact {
+		hashed := strings.EqualFold(strings.TrimSpace(mode), "hash")
+		v.check(!hashed || c.LogRedactKey != "" || secretKey, "LOG_REDACT", "%s:hash requires LOG_REDACT_KEY", field)
+	}
+	var level slog.Level
+	v.check(level.UnmarshalText([]byte(strings.TrimSpace(c.LogLevel))) == nil, "LOG_LEVEL", "must be a log level, e.g. info, got %q", c.LogLevel)
+
+	v.check(c.RateLimit >= 0, "RATE_LIMIT", "must not be negative")
+	v.check(c.RateLimit == 0 || c.RateLimitBurst > 0, "RATE_LIMIT_BURST", "must be positive")
+
+	v.oneOf("SECRETS_PROVIDER", c.SecretsProvider, "none", "file", "vault")
+	v.check(c.SecretsProvider != "none" || len(c.Secrets) == 0, "SECRETS", "requires SECRETS_PROVIDER")
+	v.check(c.SecretsProvider != "vault" || c.VaultToken != "", "VAULT_TOKEN", "is required by the vault secrets provider")
+	secret := map[string]bool{}
+	for _, f := range fields() {
+		secret[f.key] = f.secret && f.key != "VAULT_TOKEN"
+	}
+	for key := range c.Secrets {
+		v.check(secret[key], "SECRETS", "%s is not a secret setting", key)
+	}
+	v.notNegative("SECRETS_REFRESH_INTERVAL", c.SecretsRefreshInterval)
+
+	v.oneOf("DUPLICATE_MODE", c.DuplicateMode, "off", "warn", "block")
+	v.check(c.DuplicateThreshold >= 0 && c.DuplicateThreshold <= 1, "DUPLICATE_THRESHOLD", "must be between 0 and 1")
+
+	v.positive("MERGE_RETENTION", c.MergeRetention)
+	v.positive("HEALTH_TIMEOUT", c.HealthTimeout)
+
+	v.oneOf("TRACING_EXPORTER", c.TracingExporter, "none", "stdout", "otlp")
+
+	return errors.Join(v.errs...)
+}
+
+type validator struct {
+	errs []error
+}
+
+func (v *validator) check(ok bool, key string, format string, args ...any) {
+	if !ok {
+		v.errs = append(v.errs, fmt.Errorf("%s %s", key, fmt.Sprintf(format, args...)))
+	}
+}
+
+func (v *validator) oneOf(key string, value string, allowed ...string) {
+	v.check(slices.Contains(allowed, value), key, "must be one of %s, got %q", strings.Join(allowed, ", "), value)
+}
+
+func (v *validator) positive(key string, d time.Duration) {
+	v.check(d > 0, key, "must be positive, got %v", d)
+}
+
+func (v *validator) notNegative(key string, d time.Duration) {
+	v.check(d >= 0, key, "must not be negative, got %v", d)
+}
@@ -0,0 +1,198 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"qore-be/internal/logging"
+	"strings"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Drivers supported by the service, also the gorm dialector names.
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// Dialector returns the gorm dialector of the driver.
+//
+// The dsn of the SQLite driver is a file path or URI (e.g. "file:qore.db" or
+// ":memory:"); the foreign keys, disabled by default, are enabled.
+func Dialector(driver string, dsn string) (gorm.Dialector, error) {
+	switch driver {
+	case DriverMySQL:
+		return mysql.Open(dsn), nil
+	case DriverPostgres:
+		return postgres.Open(dsn), nil
+	case DriverSQLite:
+		return sqlite.Open(sqliteDSN(dsn)), nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver: %q", driver)
+	}
+}
+
+// Option ..
+type Option func(*options) error
+
+type options struct {
+	maxOpen      int
+	maxIdle      int
+	maxLifetime  time.Duration
+	maxIdleTime  time.Duration
+	retryTimeout time.Duration
+	backoff      Backoff
+}
+
+// WithPool sizes the connection pool: at most maxOpen connections, maxIdle of
+// them kept idle, each one closed after maxLifetime or maxIdleTime idle. A
+// zero value keeps the database/sql default.
+func WithPool(maxOpen int, maxIdle int, maxLifetime time.Duration, maxIdleTime time.Duration) Option {
+	return func(o *options) error {
+		if maxOpen < 0 || maxIdle < 0 || maxLifetime < 0 || maxIdleTime < 0 {
+			return fmt.Errorf("invalid connection pool settings")
+		}
+		o.maxOpen, o.maxIdle, o.maxLifetime, o.maxIdleTime = maxOpen, maxIdle, maxLifetime, maxIdleTime
+		return nil
+	}
+}
+
+// WithConnectRetry keeps trying to connect for the given time, e.g. while the
+// database server starts.
+func WithConnectRetry(timeout time.Duration, backoff Backoff) Option {
+	return func(o *options) error {
+		if timeout < 0 || backoff.Base <= 0 || backoff.Max < backoff.Base {
+			return fmt.Errorf("invalid connect retry settings")
+		}
+		o.retryTimeout, o.backoff = timeout, backoff
+		return nil
+	}
+}
+
+// Backoff is an exponential backoff with jitter.
+type Backoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// Delay returns the time to wait before the given retry, starting at 0: a
+// random duration between the half and the whole of Base * 2^attempt, capped
+// by Max.
+func (b Backoff) Delay(attempt int) time.Duration {
+	d := b.Max
+	if attempt < 62 && b.Base<<attempt > 0 && b.Base<<attempt < b.Max {
+		d = b.Base << attempt
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// Open connects to the database, retrying as configured. The connections may
+// be replaced later by Rotate.
+func Open(ctx context.Context, driver string, dsn string, cfg *gorm.Config, opts ...Option) (*gorm.DB, error) {
+	o := &options{}
+	for _, opt := range opts {
+		if err := opt(o); err != nil {
+			return nil, err
+		}
+	}
+
+	dialector, err := Dialector(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(o.retryTimeout)
+	for attempt := 0; ; attempt++ {
+		db, err := gorm.Open(dialector, cfg)
+		if err == nil {
+			return db, configure(db, driver, o)
+		}
+		_ = Close(db)
+
+		delay := o.backoff.Delay(attempt)
+		if o.retryTimeout == 0 || time.Now().Add(delay).After(deadline) {
+			return nil, err
+		}
+
+		logging.FromContext(ctx).Warn("failed to connect to the database, retrying",
+			"driver", driver, "attempt", attempt+1, "retry_in", delay.String(), "error", err.Error())
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+func configure(db *gorm.DB, driver string, o *options) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	sqlDB.SetMaxOpenConns(o.maxOpen)
+	if o.maxIdle > 0 {
+		sqlDB.SetMaxIdleConns(o.maxIdle)
+	}
+	sqlDB.SetConnMaxLifetime(o.maxLifetime)
+	sqlDB.SetConnMaxIdleTime(o.maxIdleTime)
+
+	if driver == DriverSQLite {
+		// SQLite has a single writer: sharing one connection avoids the
+		// "database is locked" errors, and keeps an in-memory database alive.
+		sqlDB.SetMaxOpenConns(1)
+	}
+
+	// the queries go through the pool, which Rotate replaces.
+	p := newPool(sqlDB)
+	db.ConnPool, db.Statement.ConnPool = p, p
+	return nil
+}
+
+// Close closes the connections of the database.
+func Close(db *gorm.DB) error {
+	if db == nil {
+		return nil
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+func sqliteDSN(dsn string) string {
+	if strings.Contains(dsn, "foreign_keys") {
+		return dsn
+	}
+
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	return dsn + sep + "_pragma=foreign_keys(1)"
+}
+
+// System returns the OpenTelemetry db.system of the driver.
+func System(driver string) string {
+	if driver == DriverPostgres {
+		return "postgresql"
+	}
+	return driver
+}
+
+// WithTimeout bounds the queries run with the context, when the timeout is
+// positive.
+func WithTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
@@ -0,0 +1,103 @@
+package database_test
+
+import (
+	"context"
+	"path/filepath"
+	"qore-be/internal/database"
+	"time"
+
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestBackoff_Delay(t *testing.T) {
+	b := database.Backoff{Base: 100 * time.Millisecond, Max: time.Second}
+
+	cases := []struct {
+		attempt int
+		min     time.Duration
+		max     time.Duration
+	}{
+		{attempt: 0, min: 50 * time.Millisecond, max: 100 * time.Millisecond},
+		{attempt: 2, min: 200 * time.Millisecond, max: 400 * time.Millisecond},
+		{attempt: 4, min: 500 * time.Millisecond, max: time.Second},
+		{attempt: 100, min: 500 * time.Millisecond, max: time.Second},
+	}
+
+	for _, tc := range cases {
+		for i := 0; i < 20; i++ {
+			d := b.Delay(tc.attempt)
+			assert.GreaterOrEqual(t, d, tc.min, "attempt %d", tc.attempt)
+			assert.LessOrEqual(t, d, tc.max, "attempt %d", tc.attempt)
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	ctx := context.Background()
+	cfg := &gorm.Config{Logger: logger.Discard}
+	backoff := database.Backoff{Base: 10 * time.Millisecond, Max: 50 * time.Millisecond}
+
+	t.Run("pool", func(t *testing.T) {
+		db, err := database.Open(ctx, database.DriverSQLite, filepath.Join(t.TempDir(), "test.db"), cfg,
+			database.WithPool(10, 2, time.Minute, time.Second))
+		require.NoError(t, err)
+
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		defer sqlDB.Close()
+
+		// a SQLite database is used through one connection.
+		assert.Equal(t, 1, sqlDB.Stats().MaxOpenConnections)
+		assert.NoError(t, sqlDB.Ping())
+	})
+
+	t.Run("unsupported driver", func(t *testing.T) {
+		_, err := database.Open(ctx, "oracle", "dsn", cfg)
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		_, err := database.Open(ctx, database.DriverSQLite, ":memory:", cfg, database.WithPool(-1, 0, 0, 0))
+		assert.Error(t, err)
+
+		_, err = database.Open(ctx, database.DriverSQLite, ":memory:", cfg, database.WithConnectRetry(time.Second, database.Backoff{}))
+		assert.Error(t, err)
+	})
+
+	t.Run("retry until the timeout", func(t *testing.T) {
+		dsn := filepath.Join(t.TempDir(), "missing", "test.db")
+
+		start := time.Now()
+		_, err := database.Open(ctx, database.DriverSQLite, dsn, cfg, database.WithConnectRetry(200*time.Millisecond, backoff))
+		assert.Error(t, err)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("retry until the context is done", func(t *testing.T) {
+		dsn := filepath.Join(t.TempDir(), "missing", "test.db")
+
+		ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+
+		_, err := database.Open(ctx, database.DriverSQLite, dsn, cfg, database.WithConnectRetry(time.Minute, backoff))
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestWithTimeout(t *testing.T) {
+	ctx, cancel := database.WithTimeout(context.Background(), 0)
+	defer cancel()
+	_, ok := ctx.Deadline()
+	assert.False(t, ok)
+
+	ctx, cancel = database.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+}
@@ -0,0 +1,71 @@
+package databasetest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"qore-be/internal/database"
+	"qore-be/internal/migrate"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// URL environment variables of the servers the repository tests also run
+// against, e.g. in CI. Their databases are wiped by the tests.
+const (
+	MySQLEnv    = "TEST_MYSQL_URL"
+	PostgresEnv = "TEST_POSTGRES_URL"
+)
+
+// Drivers returns the database drivers the repository tests run against.
+func Drivers() []string {
+	return []string{database.DriverSQLite, database.DriverMySQL, database.DriverPostgres}
+}
+
+// Open returns an empty, migrated database of the driver. SQLite databases
+// are temporary files; the test is skipped when the server of another
+// driver is not configured.
+func Open(t testing.TB, driver string) *gorm.DB {
+	t.Helper()
+
+	var dsn string
+	switch driver {
+	case database.DriverSQLite:
+		dsn = filepath.Join(t.TempDir(), "test.db")
+	case database.DriverMySQL:
+		dsn = os.Getenv(MySQLEnv)
+	case database.DriverPostgres:
+		dsn = os.Getenv(PostgresEnv)
+	}
+	if dsn == "" {
+		t.Skipf("no %s database configured", driver)
+	}
+
+	db, err := database.Open(context.Background(), driver, dsn, &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open the %s database: %v", driver, err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	m, err := migrate.New(sqlDB, migrate.WithDialect(driver))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if err := m.To(ctx, 0); err != nil {
+		t.Fatalf("failed to reset the %s database: %v", driver, err)
+	}
+	if err := m.Up(ctx); err != nil {
+		t.Fatalf("failed to migrate the %s database: %v", driver, err)
+	}
+
+	return db
+}
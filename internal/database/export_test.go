@@ -0,0 +1,13 @@
+package database
+
+import "time"
+
+// SetDrainDelay shortens the drain of the replaced connection pools for the
+// tests. It returns a function restoring the delays.
+func SetDrainDelay(delay time.Duration, interval time.Duration) func() {
+	prevDelay, prevInterval := drainDelay, drainInterval
+	drainDelay, drainInterval = delay, interval
+	return func() {
+		drainDelay, drainInterval = prevDelay, prevInterval
+	}
+}
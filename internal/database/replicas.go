@@ -0,0 +1,198 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"qore-be/internal/logging"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Replicas routes the reads to the read replicas of a primary database, in
+// turn. The reads go to the primary while no replica is healthy.
+type Replicas struct {
+	primary  *gorm.DB
+	replicas []*replica
+	next     atomic.Uint64
+
+	sticky time.Duration
+
+	mu        sync.Mutex
+	writes    map[string]time.Time
+	nextPrune time.Time
+}
+
+type replica struct {
+	name    string
+	db      *gorm.DB
+	healthy atomic.Bool
+}
+
+// ReplicasOption ..
+type ReplicasOption func(*Replicas) error
+
+// NewReplicas creates the router of the reads between the primary database
+// and its replicas, which are deemed healthy until checked by Watch.
+func NewReplicas(primary *gorm.DB, replicas []*gorm.DB, opts ...ReplicasOption) (*Replicas, error) {
+	if primary == nil {
+		return nil, fmt.Errorf("nil primary database")
+	}
+
+	r := &Replicas{primary: primary, writes: map[string]time.Time{}}
+	for i, db := range replicas {
+		if db == nil {
+			return nil, fmt.Errorf("nil replica database")
+		}
+		rep := &replica{name: fmt.Sprintf("replica-%d", i+1), db: db}
+		rep.healthy.Store(true)
+		r.replicas = append(r.replicas, rep)
+	}
+
+	for _, opt := range opts {
+		if err := opt(r); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// WithStickyWrites sends the reads of a client to the primary for the given
+// time after its writes, so that it reads them back despite the replication
+// lag.
+func WithStickyWrites(d time.Duration) ReplicasOption {
+	return func(r *Replicas) error {
+		if d < 0 {
+			return fmt.Errorf("invalid sticky writes duration: %v", d)
+		}
+		r.sticky = d
+		return nil
+	}
+}
+
+type primaryKey struct{}
+
+// NewPrimaryContext returns a copy of ctx whose reads go to the primary.
+func NewPrimaryContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// Reader returns the database to read from: the next healthy replica, or the
+// primary when there is none or when ctx is a primary context.
+func (r *Replicas) Reader(ctx context.Context) *gorm.DB {
+	if primary, _ := ctx.Value(primaryKey{}).(bool); primary || len(r.replicas) == 0 {
+		return r.primary
+	}
+
+	n := uint64(len(r.replicas))
+	start := r.next.Add(1)
+	for i := uint64(0); i < n; i++ {
+		if rep := r.replicas[(start+i)%n]; rep.healthy.Load() {
+			return rep.db
+		}
+	}
+	return r.primary
+}
+
+// Wrote records a write by the client.
+func (r *Replicas) Wrote(client string) {
+	if r.sticky == 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.writes[client] = now
+
+	// forgets the clients whose writes are old enough to be on the replicas.
+	if now.After(r.nextPrune) {
+		for c, at := range r.writes {
+			if now.Sub(at) >= r.sticky {
+				delete(r.writes, c)
+			}
+		}
+		r.nextPrune = now.Add(r.sticky)
+	}
+}
+
+// Sticky reports whether the reads of the client go to the primary, i.e. the
+// client wrote recently.
+func (r *Replicas) Sticky(client string) bool {
+	if r.sticky == 0 {
+		return false
+	}
+
+	r.mu.Lock()
+	at, ok := r.writes[client]
+	r.mu.Unlock()
+
+	return ok && time.Since(at) < r.sticky
+}
+
+// Watch checks the health of the replicas every interval, each check bounded
+// by the timeout, until ctx is done.
+func (r *Replicas) Watch(ctx context.Context, interval time.Duration, timeout time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		r.check(ctx, timeout)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Close closes the connections of the replicas, the primary left open.
+func (r *Replicas) Close() error {
+	var errs []error
+	for _, rep := range r.replicas {
+		if err := Close(rep.db); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", rep.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Replicas) check(ctx context.Context, timeout time.Duration) {
+	for _, rep := range r.replicas {
+		err := rep.ping(ctx, timeout)
+		if ctx.Err() != nil {
+			return
+		}
+
+		healthy := err == nil
+		if rep.healthy.Swap(healthy) == healthy {
+			continue
+		}
+
+		log := logging.FromContext(ctx)
+		if healthy {
+			log.Info("read replica is healthy again", "replica", rep.name)
+		} else {
+			log.Warn("read replica is unhealthy, reading from the other databases",
+				"replica", rep.name, "error", err.Error())
+		}
+	}
+}
+
+func (rep *replica) ping(ctx context.Context, timeout time.Duration) error {
+	sqlDB, err := rep.db.DB()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return sqlDB.PingContext(ctx)
+}
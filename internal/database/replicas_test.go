@@ -0,0 +1,96 @@
+package database_test
+
+import (
+	"context"
+	"qore-be/internal/database"
+	"time"
+
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestNewReplicas(t *testing.T) {
+	_, err := database.NewReplicas(nil, nil)
+	assert.Error(t, err)
+
+	_, err = database.NewReplicas(&gorm.DB{}, []*gorm.DB{nil})
+	assert.Error(t, err)
+
+	_, err = database.NewReplicas(&gorm.DB{}, nil, database.WithStickyWrites(-time.Second))
+	assert.Error(t, err)
+}
+
+func TestReplicas_Reader(t *testing.T) {
+	ctx := context.Background()
+	primary, first, second := &gorm.DB{}, &gorm.DB{}, &gorm.DB{}
+
+	t.Run("without replicas", func(t *testing.T) {
+		r, err := database.NewReplicas(primary, nil)
+		require.NoError(t, err)
+		assert.Same(t, primary, r.Reader(ctx))
+	})
+
+	t.Run("replicas in turn", func(t *testing.T) {
+		r, err := database.NewReplicas(primary, []*gorm.DB{first, second})
+		require.NoError(t, err)
+
+		got := []*gorm.DB{r.Reader(ctx), r.Reader(ctx), r.Reader(ctx)}
+		assert.NotSame(t, got[0], got[1])
+		assert.Same(t, got[0], got[2])
+		assert.NotSame(t, primary, got[0])
+		assert.NotSame(t, primary, got[1])
+	})
+
+	t.Run("primary context", func(t *testing.T) {
+		r, err := database.NewReplicas(primary, []*gorm.DB{first})
+		require.NoError(t, err)
+		assert.Same(t, primary, r.Reader(database.NewPrimaryContext(ctx)))
+	})
+}
+
+func TestReplicas_Sticky(t *testing.T) {
+	r, err := database.NewReplicas(&gorm.DB{}, nil, database.WithStickyWrites(50*time.Millisecond))
+	require.NoError(t, err)
+
+	assert.False(t, r.Sticky("a"))
+	r.Wrote("a")
+	assert.True(t, r.Sticky("a"))
+	assert.False(t, r.Sticky("b"))
+
+	assert.Eventually(t, func() bool { return !r.Sticky("a") }, time.Second, 10*time.Millisecond)
+
+	// the reads are never sticky by default.
+	r, err = database.NewReplicas(&gorm.DB{}, nil)
+	require.NoError(t, err)
+	r.Wrote("a")
+	assert.False(t, r.Sticky("a"))
+}
+
+func TestReplicas_Watch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	open := func() *gorm.DB {
+		db, err := database.Open(ctx, database.DriverSQLite, ":memory:", &gorm.Config{Logger: logger.Discard})
+		require.NoError(t, err)
+		return db
+	}
+	primary, replica := open(), open()
+
+	r, err := database.NewReplicas(primary, []*gorm.DB{replica})
+	require.NoError(t, err)
+	go r.Watch(ctx, 10*time.Millisecond, time.Second)
+
+	assert.Same(t, replica, r.Reader(ctx))
+
+	// the reads fall back to the primary when the replica is down.
+	sqlDB, err := replica.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+
+	assert.Eventually(t, func() bool { return r.Reader(ctx) == primary }, time.Second, 10*time.Millisecond)
+}
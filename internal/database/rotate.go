@@ -0,0 +1,103 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// A replaced connection pool is closed once none of its connections is in
+// use, checked every drainInterval, but not before drainDelay: the queries
+// which just loaded it may not have taken a connection yet.
+var (
+	drainDelay    = time.Second
+	drainInterval = time.Second
+)
+
+// pool is the connection pool of the databases opened by Open, which Rotate
+// replaces while the database is in use.
+type pool struct {
+	current atomic.Pointer[sql.DB]
+}
+
+func newPool(db *sql.DB) *pool {
+	p := &pool{}
+	p.current.Store(db)
+	return p
+}
+
+// PrepareContext implements gorm.ConnPool.
+func (p *pool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return p.current.Load().PrepareContext(ctx, query)
+}
+
+// ExecContext implements gorm.ConnPool.
+func (p *pool) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return p.current.Load().ExecContext(ctx, query, args...)
+}
+
+// QueryContext implements gorm.ConnPool.
+func (p *pool) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return p.current.Load().QueryContext(ctx, query, args...)
+}
+
+// QueryRowContext implements gorm.ConnPool.
+func (p *pool) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return p.current.Load().QueryRowContext(ctx, query, args...)
+}
+
+// BeginTx implements gorm.TxBeginner: the transaction keeps its connection
+// until it ends, even when the pool is replaced.
+func (p *pool) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return p.current.Load().BeginTx(ctx, opts)
+}
+
+// GetDBConn implements gorm.GetDBConnector, returning the current pool.
+func (p *pool) GetDBConn() (*sql.DB, error) {
+	return p.current.Load(), nil
+}
+
+// Rotate reconnects the database opened by Open with the dsn, e.g. holding
+// new credentials, without interrupting the queries. The new connections are
+// checked first; the returned function switches to them when commit is true,
+// or closes them otherwise. The previous connections are closed once the
+// queries and transactions under way are done, however long they take.
+func Rotate(ctx context.Context, db *gorm.DB, driver string, dsn string, opts ...Option) (finish func(commit bool), err error) {
+	p, ok := db.ConnPool.(*pool)
+	if !ok {
+		return nil, fmt.Errorf("the database was not opened by database.Open")
+	}
+
+	next, err := Open(ctx, driver, dsn, &gorm.Config{Logger: logger.Discard}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := next.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(commit bool) {
+		if !commit {
+			sqlDB.Close()
+			return
+		}
+
+		go drain(p.current.Swap(sqlDB), drainDelay, drainInterval)
+	}, nil
+}
+
+// drain closes the replaced connection pool once its connections are no
+// longer in use.
+func drain(db *sql.DB, delay time.Duration, interval time.Duration) {
+	time.Sleep(delay)
+	for db.Stats().InUse > 0 {
+		time.Sleep(interval)
+	}
+	db.Close()
+}
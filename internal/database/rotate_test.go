@@ -0,0 +1,97 @@
+package database_test
+
+import (
+	"context"
+	"path/filepath"
+	"qore-be/internal/database"
+	"time"
+
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openNamed opens a SQLite database holding its name.
+func openNamed(t *testing.T, dsn string, name string) *gorm.DB {
+	t.Helper()
+
+	db, err := database.Open(context.Background(), database.DriverSQLite, dsn, &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, db.Exec("CREATE TABLE db (name TEXT)").Error)
+	require.NoError(t, db.Exec("INSERT INTO db (name) VALUES (?)", name).Error)
+	return db
+}
+
+func name(t *testing.T, db *gorm.DB) string {
+	t.Helper()
+
+	var n string
+	require.NoError(t, db.Raw("SELECT name FROM db").Scan(&n).Error)
+	return n
+}
+
+func TestRotate(t *testing.T) {
+	t.Cleanup(database.SetDrainDelay(10*time.Millisecond, 10*time.Millisecond))
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	first, second := filepath.Join(dir, "first.db"), filepath.Join(dir, "second.db")
+
+	db := openNamed(t, first, "first")
+	openNamed(t, second, "second")
+	session := db.WithContext(ctx)
+	prev, err := db.DB()
+	require.NoError(t, err)
+
+	// the new connections are checked before the switch.
+	_, err = database.Rotate(ctx, db, database.DriverSQLite, filepath.Join(dir, "missing", "test.db"))
+	assert.Error(t, err)
+	_, err = database.Rotate(ctx, db, database.DriverMySQL, "qore:qore@tcp(127.0.0.1:1)/qore")
+	assert.Error(t, err)
+
+	// a discarded rotation keeps the current connections.
+	finish, err := database.Rotate(ctx, db, database.DriverSQLite, second)
+	require.NoError(t, err)
+	finish(false)
+	assert.Equal(t, "first", name(t, db))
+
+	// a transaction begun before the rotation ends on its connection.
+	tx := db.Begin()
+	require.NoError(t, tx.Error)
+
+	finish, err = database.Rotate(ctx, db, database.DriverSQLite, second, database.WithPool(5, 1, 0, 0))
+	require.NoError(t, err)
+	finish(true)
+
+	// the database and its sessions use the new connections, while the
+	// previous ones are left open for the transactions under way, however
+	// long they take.
+	assert.Equal(t, "second", name(t, db))
+	assert.Equal(t, "second", name(t, session))
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	assert.NotSame(t, prev, sqlDB)
+	assert.Equal(t, 1, sqlDB.Stats().MaxOpenConnections)
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, "first", name(t, tx))
+	require.NoError(t, tx.Commit().Error)
+
+	// and closed once they are done.
+	assert.Eventually(t, func() bool { return prev.Ping() != nil }, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, sqlDB.Close())
+}
+
+func TestRotate_NotOpened(t *testing.T) {
+	dialector, err := database.Dialector(database.DriverSQLite, ":memory:")
+	require.NoError(t, err)
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+
+	_, err = database.Rotate(context.Background(), db, database.DriverSQLite, ":memory:")
+	assert.ErrorContains(t, err, "not opened by database.Open")
+}
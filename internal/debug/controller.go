@@ -0,0 +1,99 @@
+package debug
+
+import (
+	"fmt"
+	"net/http"
+	"qore-be/internal/logging"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LogLevelDTO represents the log level and its pending revert.
+type LogLevelDTO struct {
+	Level string `json:"level" binding:"required"`
+	// Duration, e.g. "15m", after which the level goes back to the previous
+	// one. The change is permanent when it is empty.
+	Duration string     `json:"duration,omitempty"`
+	RevertAt *time.Time `json:"revert_at,omitempty"`
+}
+
+// Controller represents the debug controller.
+type Controller struct {
+	level *logging.LevelSwitch
+}
+
+// ControllerOption ..
+type ControllerOption = func(*Controller) error
+
+var errMissingLevelSwitch = fmt.Errorf("nil log level switch")
+
+// NewController creates a new debug controller.
+func NewController(opts ...ControllerOption) (*Controller, error) {
+	ctrl := &Controller{}
+
+	for _, opt := range opts {
+		if err := opt(ctrl); err != nil {
+			return nil, err
+		}
+	}
+
+	if ctrl.level == nil {
+		return nil, errMissingLevelSwitch
+	}
+
+	return ctrl, nil
+}
+
+// WithLevelSwitch initialize the debug-controller with the log level switch.
+func WithLevelSwitch(level *logging.LevelSwitch) ControllerOption {
+	return func(ctrl *Controller) error {
+		if level == nil {
+			return errMissingLevelSwitch
+		}
+		ctrl.level = level
+		return nil
+	}
+}
+
+// GetLogLevel returns the current log level.
+func (c *Controller) GetLogLevel(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, c.current())
+}
+
+// SetLogLevel changes the log level, for good or for the requested duration.
+func (c *Controller) SetLogLevel(ctx *gin.Context) {
+	var req LogLevelDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logging.FromContext(ctx).Error("invalid request", "error", err.Error())
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	lvl, err := logging.ParseLevel(req.Level)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var d time.Duration
+	if req.Duration != "" {
+		d, err = time.ParseDuration(req.Duration)
+		if err != nil || d <= 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid duration: %q", req.Duration)})
+			return
+		}
+	}
+
+	c.level.Set(lvl, d)
+	logging.FromContext(ctx).Warn("log level changed", "level", lvl.String(), "duration", req.Duration)
+	ctx.JSON(http.StatusOK, c.current())
+}
+
+func (c *Controller) current() LogLevelDTO {
+	res := LogLevelDTO{Level: c.level.Level().String()}
+	if at := c.level.RevertAt(); !at.IsZero() {
+		res.RevertAt = &at
+	}
+	return res
+}
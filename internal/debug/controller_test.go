@@ -0,0 +1,128 @@
+package debug_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"qore-be/internal/debug"
+	"qore-be/internal/logging"
+
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDebugController(t *testing.T) {
+	t.Run("successfully", func(t *testing.T) {
+		ctrl, err := debug.NewController(debug.WithLevelSwitch(logging.NewLevelSwitch(slog.LevelInfo)))
+		assert.NoError(t, err)
+		assert.NotNil(t, ctrl)
+	})
+
+	t.Run("with missing level switch", func(t *testing.T) {
+		ctrl, err := debug.NewController()
+		assert.Error(t, err)
+		assert.Nil(t, ctrl)
+	})
+}
+
+func TestDebugController_SetLogLevel(t *testing.T) {
+	cases := []struct {
+		name           string
+		in             interface{}
+		expectedStatus int
+		expectedLevel  slog.Level
+		reverts        bool
+	}{
+		{
+			name:           "successfully",
+			in:             debug.LogLevelDTO{Level: "warn"},
+			expectedStatus: 200,
+			expectedLevel:  slog.LevelWarn,
+		},
+		{
+			name:           "successfully (for a while)",
+			in:             debug.LogLevelDTO{Level: "debug", Duration: "10m"},
+			expectedStatus: 200,
+			expectedLevel:  slog.LevelDebug,
+			reverts:        true,
+		},
+		{
+			name:           "with unknown level",
+			in:             debug.LogLevelDTO{Level: "verbose"},
+			expectedStatus: 400,
+			expectedLevel:  slog.LevelInfo,
+		},
+		{
+			name:           "with invalid duration",
+			in:             debug.LogLevelDTO{Level: "debug", Duration: "-1m"},
+			expectedStatus: 400,
+			expectedLevel:  slog.LevelInfo,
+		},
+		{
+			name:           "with missing level",
+			in:             map[string]string{},
+			expectedStatus: 400,
+			expectedLevel:  slog.LevelInfo,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			level := logging.NewLevelSwitch(slog.LevelInfo)
+			ctrl, err := debug.NewController(debug.WithLevelSwitch(level))
+			require.NoError(t, err)
+
+			srv := gin.Default()
+			gin.SetMode(gin.TestMode)
+
+			srv.PUT("/admin/log-level", ctrl.SetLogLevel)
+
+			data, err := json.Marshal(tc.in)
+			require.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodPut, "/admin/log-level", bytes.NewBuffer(data))
+			require.NoError(t, err)
+
+			srv.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.Equal(t, tc.expectedLevel, level.Level())
+			assert.Equal(t, tc.reverts, !level.RevertAt().IsZero())
+		})
+	}
+}
+
+func TestNewHandler(t *testing.T) {
+	cases := []struct {
+		name           string
+		token          string
+		pprof          bool
+		path           string
+		expectedStatus int
+	}{
+		{name: "pprof", token: "secret", pprof: true, path: "/debug/pprof/heap", expectedStatus: 200},
+		{name: "expvar", token: "secret", path: "/debug/vars", expectedStatus: 200},
+		{name: "with pprof disabled", token: "secret", path: "/debug/pprof/heap", expectedStatus: 404},
+		{name: "without admin token", pprof: true, path: "/debug/pprof/heap", expectedStatus: 403},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := debug.NewHandler(tc.token, tc.pprof, true)
+
+			rec := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodGet, tc.path, nil)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer secret")
+
+			h.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+		})
+	}
+}
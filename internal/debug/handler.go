@@ -0,0 +1,37 @@
+package debug
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"qore-be/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NewHandler creates the handler of the admin listener, serving the pprof
+// profiles under /debug/pprof and the expvar variables under /debug/vars.
+// The routes are guarded by the admin token.
+func NewHandler(token string, withPprof bool, withExpvar bool) http.Handler {
+	router := gin.New()
+	router.Use(gin.Recovery(), middleware.AdminGuard(token))
+
+	if withPprof {
+		g := router.Group("/debug/pprof")
+		g.GET("/", gin.WrapF(pprof.Index))
+		g.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+		g.GET("/profile", gin.WrapF(pprof.Profile))
+		g.GET("/symbol", gin.WrapF(pprof.Symbol))
+		g.POST("/symbol", gin.WrapF(pprof.Symbol))
+		g.GET("/trace", gin.WrapF(pprof.Trace))
+		g.GET("/:profile", func(ctx *gin.Context) {
+			pprof.Handler(ctx.Param("profile")).ServeHTTP(ctx.Writer, ctx.Request)
+		})
+	}
+
+	if withExpvar {
+		router.GET("/debug/vars", gin.WrapH(expvar.Handler()))
+	}
+
+	return router
+}
@@ -0,0 +1,276 @@
+package dedup
+
+import (
+	"math"
+	"qore-be/internal/domain/dto"
+	"sort"
+	"strings"
+)
+
+// Score field names.
+const (
+	FieldName    = "name"
+	FieldAge     = "age"
+	FieldPhone   = "phone"
+	FieldAddress = "address"
+)
+
+var weights = map[string]float64{
+	FieldName:    0.5,
+	FieldPhone:   0.25,
+	FieldAddress: 0.15,
+	FieldAge:     0.1,
+}
+
+// Matcher finds persons that possibly are the same human.
+type Matcher struct {
+	threshold float64
+}
+
+// NewMatcher creates a new matcher. Pairs scoring below the threshold are not
+// considered as duplicates.
+func NewMatcher(threshold float64) *Matcher {
+	return &Matcher{threshold: threshold}
+}
+
+// Score compares two persons. It returns the weighted score of the pair and
+// the score of every field both persons have a value for.
+//
+// Persons without a name are never considered as duplicates.
+func Score(a, b dto.PersonDTO) (float64, map[string]float64) {
+	na, nb := NormalizeName(a.Name), NormalizeName(b.Name)
+	if na == "" || nb == "" {
+		return 0, map[string]float64{}
+	}
+
+	fields := map[string]float64{
+		FieldName: jaroWinkler(na, nb),
+	}
+
+	if a.Age > 0 && b.Age > 0 {
+		fields[FieldAge] = ageScore(a.Age, b.Age)
+	}
+
+	if pa, pb := NormalizePhone(a.Number), NormalizePhone(b.Number); pa != "" && pb != "" {
+		fields[FieldPhone] = phoneScore(pa, pb)
+	}
+
+	if s, ok := addressScore(a, b); ok {
+		fields[FieldAddress] = s
+	}
+
+	var total, sum float64
+	for f, s := range fields {
+		total += weights[f]
+		sum += weights[f] * s
+	}
+
+	return round(sum / total), roundAll(fields)
+}
+
+// Match returns the pool entries that possibly duplicate the given person,
+// best match first.
+func (m *Matcher) Match(p dto.PersonDTO, pool []dto.PersonDTO) []dto.DuplicateDTO {
+	matches := []dto.DuplicateDTO{}
+	for _, c := range pool {
+		if p.ID != 0 && c.ID == p.ID {
+			continue
+		}
+
+		score, fields := Score(p, c)
+		if score < m.threshold {
+			continue
+		}
+
+		matches = append(matches, dto.DuplicateDTO{
+			Person: c,
+			Score:  score,
+			Fields: fields,
+		})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+
+	return matches
+}
+
+// Groups clusters the persons that possibly are the same human. Only the
+// persons of a block, e.g. sharing a blocking key (see BlockingKeys), are
+// compared, so the persons are never scored pairwise. A person may be in
+// several blocks.
+func (m *Matcher) Groups(blocks [][]dto.PersonDTO) []dto.DuplicateGroupDTO {
+	pool := []dto.PersonDTO{}
+	index := map[int]int{}
+	for _, block := range blocks {
+		for _, p := range block {
+			if _, ok := index[p.ID]; !ok {
+				index[p.ID] = len(pool)
+				pool = append(pool, p)
+			}
+		}
+	}
+
+	parent := make([]int, len(pool))
+	for i := range parent {
+		parent[i] = i
+	}
+	find := func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+
+	best := map[int]float64{}
+	compared := map[[2]int]bool{}
+	for _, block := range blocks {
+		for x := 0; x < len(block); x++ {
+			for y := x + 1; y < len(block); y++ {
+				i, j := index[block[x].ID], index[block[y].ID]
+				if i > j {
+					i, j = j, i
+				}
+				if i == j || compared[[2]int{i, j}] {
+					continue
+				}
+				compared[[2]int{i, j}] = true
+
+				score, _ := Score(pool[i], pool[j])
+				if score < m.threshold {
+					continue
+				}
+
+				ri, rj := find(i), find(j)
+				s := max(score, best[ri], best[rj])
+				if ri != rj {
+					parent[rj] = ri
+					delete(best, rj)
+				}
+				best[ri] = s
+			}
+		}
+	}
+
+	byRoot := map[int][]dto.PersonDTO{}
+	for i, p := range pool {
+		r := find(i)
+		if _, ok := best[r]; ok {
+			byRoot[r] = append(byRoot[r], p)
+		}
+	}
+
+	groups := make([]dto.DuplicateGroupDTO, 0, len(byRoot))
+	for r, members := range byRoot {
+		sort.Slice(members, func(i, j int) bool { return members[i].ID < members[j].ID })
+		groups = append(groups, dto.DuplicateGroupDTO{
+			Score:   best[r],
+			Members: members,
+		})
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Score != groups[j].Score {
+			return groups[i].Score > groups[j].Score
+		}
+		return groups[i].Members[0].ID < groups[j].Members[0].ID
+	})
+
+	return groups
+}
+
+// BlockingKeys returns the keys of a person: the phonetic code of each name
+// token and each normalized phone number. Only the persons sharing a key are
+// compared, so the keys can be stored to look the candidates up.
+func BlockingKeys(name string, numbers ...string) []string {
+	keys := []string{}
+	seen := map[string]bool{}
+	add := func(k string) {
+		if !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+
+	for _, t := range tokenize(name) {
+		if code := soundex(t); code != "" {
+			add("name:" + code)
+		}
+	}
+
+	for _, n := range numbers {
+		if ph := NormalizePhone(n); ph != "" {
+			add("phone:" + ph)
+		}
+	}
+
+	return keys
+}
+
+func ageScore(a, b int) float64 {
+	switch diff := a - b; {
+	case diff == 0:
+		return 1
+	case diff == 1 || diff == -1:
+		// the age may have been captured before and after a birthday.
+		return 0.8
+	default:
+		return 0
+	}
+}
+
+func phoneScore(a, b string) float64 {
+	switch levenshtein(a, b) {
+	case 0:
+		return 1
+	case 1:
+		return 0.7
+	default:
+		return 0
+	}
+}
+
+func addressScore(a, b dto.PersonDTO) (float64, bool) {
+	var parts []float64
+
+	sa := NormalizeAddress(a.Street1 + " " + a.Street2)
+	sb := NormalizeAddress(b.Street1 + " " + b.Street2)
+	if sa != "" && sb != "" {
+		parts = append(parts, jaroWinkler(sa, sb))
+	}
+
+	for _, pair := range [][2]string{{a.City, b.City}, {a.Zip, b.Zip}} {
+		x, y := NormalizeAddress(pair[0]), NormalizeAddress(pair[1])
+		if x == "" || y == "" {
+			continue
+		}
+		if strings.EqualFold(x, y) {
+			parts = append(parts, 1)
+		} else {
+			parts = append(parts, 0)
+		}
+	}
+
+	if len(parts) == 0 {
+		return 0, false
+	}
+
+	var sum float64
+	for _, s := range parts {
+		sum += s
+	}
+	return sum / float64(len(parts)), true
+}
+
+func round(f float64) float64 {
+	return math.Round(f*1000) / 1000
+}
+
+func roundAll(fields map[string]float64) map[string]float64 {
+	for f, s := range fields {
+		fields[f] = round(s)
+	}
+	return fields
+}
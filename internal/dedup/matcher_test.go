@@ -0,0 +1,153 @@
+package dedup_test
+
+import (
+	"qore-be/internal/dedup"
+	"qore-be/internal/domain/dto"
+
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "doe john", dedup.NormalizeName("  Doe, JOHN "))
+	assert.Equal(t, "doe jose", dedup.NormalizeName("José  Doe"))
+	assert.Equal(t, "555010011", dedup.NormalizePhone("+1 (555) 010-011"))
+	assert.Equal(t, "12 main st apt 3", dedup.NormalizeAddress("12, Main Street - Apartment 3"))
+}
+
+func TestScore(t *testing.T) {
+	john := dto.PersonDTO{
+		Name:    "John Doe",
+		Age:     30,
+		Number:  "555-010-0100",
+		City:    "Springfield",
+		Street1: "12 Main Street",
+		Zip:     "1234",
+	}
+
+	cases := []struct {
+		name   string
+		other  dto.PersonDTO
+		min    float64
+		max    float64
+		fields []string
+	}{
+		{
+			name:   "same person",
+			other:  john,
+			min:    1,
+			max:    1,
+			fields: []string{dedup.FieldName, dedup.FieldAge, dedup.FieldPhone, dedup.FieldAddress},
+		},
+		{
+			name: "slightly different spelling",
+			other: dto.PersonDTO{
+				Name:    "Doe, Jon",
+				Age:     31,
+				Number:  "+1 5550100100",
+				City:    "springfield",
+				Street1: "12 main st.",
+				Zip:     "1234",
+			},
+			min:    0.9,
+			max:    1,
+			fields: []string{dedup.FieldName, dedup.FieldAge, dedup.FieldPhone, dedup.FieldAddress},
+		},
+		{
+			name:   "only the name is known",
+			other:  dto.PersonDTO{Name: "john doe"},
+			min:    1,
+			max:    1,
+			fields: []string{dedup.FieldName},
+		},
+		{
+			name: "different person",
+			other: dto.PersonDTO{
+				Name:   "Alice Martin",
+				Age:    52,
+				Number: "555-999-1234",
+			},
+			min:    0,
+			max:    0.5,
+			fields: []string{dedup.FieldName, dedup.FieldAge, dedup.FieldPhone},
+		},
+		{
+			name:  "missing name",
+			other: dto.PersonDTO{Number: john.Number},
+			min:   0,
+			max:   0,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			score, fields := dedup.Score(john, tc.other)
+			assert.GreaterOrEqual(t, score, tc.min)
+			assert.LessOrEqual(t, score, tc.max)
+			assert.Len(t, fields, len(tc.fields))
+			for _, f := range tc.fields {
+				assert.Contains(t, fields, f)
+			}
+		})
+	}
+}
+
+func TestMatcher_Match(t *testing.T) {
+	m := dedup.NewMatcher(0.85)
+	pool := []dto.PersonDTO{
+		{ID: 1, Name: "John Doe", Age: 30, Number: "555-010-0100"},
+		{ID: 2, Name: "Alice Martin", Age: 52},
+		{ID: 3, Name: "Jon Doe", Age: 30, Number: "5550100100"},
+	}
+
+	matches := m.Match(dto.PersonDTO{Name: "john doe", Age: 30, Number: "555 010 0100"}, pool)
+	require.Len(t, matches, 2)
+	assert.Equal(t, 1, matches[0].Person.ID)
+	assert.Equal(t, 3, matches[1].Person.ID)
+	assert.GreaterOrEqual(t, matches[0].Score, matches[1].Score)
+
+	t.Run("ignores the person itself", func(t *testing.T) {
+		matches := m.Match(pool[0], pool)
+		require.Len(t, matches, 1)
+		assert.Equal(t, 3, matches[0].Person.ID)
+	})
+}
+
+func TestMatcher_Groups(t *testing.T) {
+	m := dedup.NewMatcher(0.85)
+	pool := []dto.PersonDTO{
+		{ID: 1, Name: "John Doe", Age: 30, Number: "555-010-0100"},
+		{ID: 2, Name: "Alice Martin", Age: 52},
+		{ID: 3, Name: "Jon Doe", Age: 30, Number: "5550100100"},
+		{ID: 4, Name: "Alicia Martin", Age: 52},
+		{ID: 5, Name: "Bob Stone", Age: 12},
+		{ID: 6, Name: "Doe John", Age: 31},
+	}
+
+	keys := map[string][]dto.PersonDTO{}
+	for _, p := range pool {
+		for _, k := range dedup.BlockingKeys(p.Name, p.Number) {
+			keys[k] = append(keys[k], p)
+		}
+	}
+	blocks := [][]dto.PersonDTO{}
+	for _, block := range keys {
+		blocks = append(blocks, block)
+	}
+
+	groups := m.Groups(blocks)
+	require.Len(t, groups, 2)
+
+	ids := func(g dto.DuplicateGroupDTO) []int {
+		res := []int{}
+		for _, p := range g.Members {
+			res = append(res, p.ID)
+		}
+		return res
+	}
+
+	assert.ElementsMatch(t, []int{1, 3, 6}, ids(groups[0]))
+	assert.ElementsMatch(t, []int{2, 4}, ids(groups[1]))
+}
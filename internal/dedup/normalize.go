@@ -0,0 +1,125 @@
+package dedup
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// phoneSignificantDigits is the number of trailing digits compared between
+// two phone numbers, so that "+1 555-0100" and "5550100" still match.
+const phoneSignificantDigits = 9
+
+var addressAbbreviations = map[string]string{
+	"street":    "st",
+	"avenue":    "ave",
+	"road":      "rd",
+	"boulevard": "blvd",
+	"drive":     "dr",
+	"lane":      "ln",
+	"court":     "ct",
+	"place":     "pl",
+	"square":    "sq",
+	"apartment": "apt",
+	"suite":     "ste",
+	"north":     "n",
+	"south":     "s",
+	"east":      "e",
+	"west":      "w",
+}
+
+// NormalizeName lower-cases the name, strips accents and punctuation and
+// sorts its tokens so that "Doe, John" and "john doe" are equal.
+func NormalizeName(name string) string {
+	tokens := tokenize(name)
+	sort.Strings(tokens)
+	return strings.Join(tokens, " ")
+}
+
+// NormalizePhone keeps the significant trailing digits of a phone number.
+func NormalizePhone(number string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, number)
+
+	if len(digits) > phoneSignificantDigits {
+		digits = digits[len(digits)-phoneSignificantDigits:]
+	}
+	return digits
+}
+
+// NormalizeAddress lower-cases an address line, strips accents and
+// punctuation and replaces the common street words by their abbreviation.
+func NormalizeAddress(line string) string {
+	tokens := tokenize(line)
+	for i, t := range tokens {
+		if abbr, ok := addressAbbreviations[t]; ok {
+			tokens[i] = abbr
+		}
+	}
+	return strings.Join(tokens, " ")
+}
+
+func tokenize(s string) []string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	s, _, err := transform.String(t, s)
+	if err != nil {
+		return nil
+	}
+
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// soundex returns the american soundex code of a single token.
+func soundex(token string) string {
+	codes := map[rune]byte{
+		'b': '1', 'f': '1', 'p': '1', 'v': '1',
+		'c': '2', 'g': '2', 'j': '2', 'k': '2', 'q': '2', 's': '2', 'x': '2', 'z': '2',
+		'd': '3', 't': '3',
+		'l': '4',
+		'm': '5', 'n': '5',
+		'r': '6',
+	}
+
+	var (
+		out  []byte
+		last byte
+	)
+	for i, r := range token {
+		if r > unicode.MaxASCII || !unicode.IsLetter(r) {
+			continue
+		}
+		code := codes[r]
+		if i == 0 || len(out) == 0 {
+			out = append(out, byte(unicode.ToUpper(r)))
+			last = code
+			continue
+		}
+		if code != 0 && code != last {
+			out = append(out, code)
+		}
+		if r != 'h' && r != 'w' {
+			last = code
+		}
+		if len(out) == 4 {
+			break
+		}
+	}
+
+	if len(out) == 0 {
+		return ""
+	}
+	for len(out) < 4 {
+		out = append(out, '0')
+	}
+	return string(out)
+}
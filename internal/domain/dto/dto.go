@@ -1,5 +1,12 @@
 package dto
 
+import (
+	"log/slog"
+	"qore-be/internal/redact"
+	"slices"
+	"time"
+)
+
 // PersonDTO represents the person DTO.
 type PersonDTO struct {
 	ID      int    `json:"id"`
@@ -11,4 +18,163 @@ type PersonDTO struct {
 	Street1 string `json:"street1"`
 	Street2 string `json:"street2"`
 	Zip     string `json:"zip_code"`
+
+	Tags       []string          `json:"tags,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	Attributes map[string]any    `json:"attributes,omitempty"`
+}
+
+// LogValue implements slog.LogValuer: the personal fields are redacted
+// according to the redaction policy, and only the keys of the labels and
+// custom attributes are logged.
+func (p PersonDTO) LogValue() slog.Value {
+	attrs := []slog.Attr{slog.Int("id", p.ID)}
+	attrs = appendRedacted(attrs, "name", redact.FieldName, p.Name)
+	attrs = append(attrs, slog.Int("age", p.Age))
+	attrs = appendRedacted(attrs, "phone_number", redact.FieldPhone, p.Number)
+	attrs = append(attrs, slog.String("city", p.City), slog.String("state", p.State))
+	attrs = appendRedacted(attrs, "street1", redact.FieldAddress, p.Street1)
+	attrs = appendRedacted(attrs, "street2", redact.FieldAddress, p.Street2)
+	attrs = append(attrs, slog.String("zip_code", p.Zip))
+
+	if len(p.Tags) > 0 {
+		attrs = append(attrs, slog.Any("tags", p.Tags))
+	}
+	if len(p.Labels) > 0 {
+		attrs = append(attrs, slog.Any("labels", sortedKeys(p.Labels)))
+	}
+	if len(p.Attributes) > 0 {
+		attrs = append(attrs, slog.Any("attributes", sortedKeys(p.Attributes)))
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+func appendRedacted(attrs []slog.Attr, key string, field string, v string) []slog.Attr {
+	if v, ok := redact.String(field, v); ok {
+		return append(attrs, slog.String(key, v))
+	}
+	return attrs
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// Tag filter modes.
+const (
+	TagModeAnd = "and"
+	TagModeOr  = "or"
+)
+
+// PersonFilter represents the filters of a person listing.
+type PersonFilter struct {
+	// Tags the listed persons are tagged with, all of them (TagModeAnd) or any
+	// of them (TagModeOr).
+	Tags    []string
+	TagMode string
+
+	// Attributes the listed persons custom attributes are equal to.
+	Attributes map[string]any
+
+	// Sort is the field ("id", "name", "age") or custom attribute the persons
+	// are sorted by; custom attributes with a numeric type are sorted as numbers.
+	Sort        string
+	SortDesc    bool
+	SortNumeric bool
+}
+
+// TagsDTO represents a request to tag a person.
+type TagsDTO struct {
+	Tags []string `json:"tags" binding:"required,min=1"`
+}
+
+// TagCountDTO represents a tag and the number of persons tagged with it.
+type TagCountDTO struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// LabelDTO represents a request to label a person.
+type LabelDTO struct {
+	Value string `json:"value"`
+}
+
+// CreatedPersonDTO represents the result of a person creation.
+type CreatedPersonDTO struct {
+	PersonDTO
+	Duplicates []DuplicateDTO `json:"possible_duplicates,omitempty"`
+}
+
+// DuplicateDTO represents a stored person that possibly duplicates another one.
+type DuplicateDTO struct {
+	Person PersonDTO          `json:"person"`
+	Score  float64            `json:"score"`
+	Fields map[string]float64 `json:"fields"`
+}
+
+// DuplicateGroupDTO represents a group of stored persons that possibly are the same human.
+type DuplicateGroupDTO struct {
+	Score   float64     `json:"score"`
+	Members []PersonDTO `json:"members"`
+}
+
+// MergeDTO represents a request to merge a person into another one.
+//
+// Rules maps a person field ("name", "age") to its survivorship rule:
+// "target" (default), "source" or "non_empty". The phones and addresses of
+// both persons are kept, those of the target first, so they take no rule.
+type MergeDTO struct {
+	SourceID int               `json:"source_id" binding:"required"`
+	Rules    map[string]string `json:"rules"`
+}
+
+// UnmergeDTO represents a request to revert a merge.
+type UnmergeDTO struct {
+	SourceID int `json:"source_id" binding:"required"`
+}
+
+// RelationDTO represents a directed relationship between two persons: the
+// related person is the <type> of the person.
+type RelationDTO struct {
+	ID        int        `json:"id"`
+	PersonID  int        `json:"person_id"`
+	RelatedID int        `json:"related_id" binding:"required"`
+	Type      string     `json:"type" binding:"required"`
+	ValidFrom *time.Time `json:"valid_from,omitempty"`
+	ValidTo   *time.Time `json:"valid_to,omitempty"`
+}
+
+// RelationNodeDTO represents a person of a relationship graph.
+type RelationNodeDTO struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Depth int    `json:"depth"`
+}
+
+// RelationGraphDTO represents the relationship graph around a person.
+type RelationGraphDTO struct {
+	Root      int               `json:"root"`
+	Depth     int               `json:"depth"`
+	Nodes     []RelationNodeDTO `json:"nodes"`
+	Relations []RelationDTO     `json:"relations"`
+}
+
+// AttributeDefinitionDTO represents a custom person attribute definition.
+type AttributeDefinitionDTO struct {
+	Name       string   `json:"name"`
+	Type       string   `json:"type" binding:"required"`
+	Required   bool     `json:"required"`
+	EnumValues []string `json:"enum_values,omitempty"`
+	Regex      string   `json:"regex,omitempty"`
+}
+
+// AttributesDTO represents a request to set the custom attributes of a person.
+type AttributesDTO struct {
+	Attributes map[string]any `json:"attributes"`
 }
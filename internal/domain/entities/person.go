@@ -1,5 +1,7 @@
 package entities
 
+import "time"
+
 // Person represents the person entity.
 type Person struct {
 	ID   int    `json:"id" gorm:"primaryKey;autoIncrement"`
@@ -12,7 +14,7 @@ func (Person) TableName() string {
 	return "person"
 }
 
-// Phone represents the phone entity.
+// Phone represents the phone entity, deleted with its person.
 type Phone struct {
 	ID       int    `json:"id" gorm:"primaryKey;autoIncrement"`
 	PersonID int    `json:"person_id"`
@@ -39,7 +41,7 @@ func (Address) TableName() string {
 	return "address"
 }
 
-// PersonAddress join table for person and address.
+// PersonAddress join table for person and address, deleted with either of them.
 type PersonAddress struct {
 	ID        int `json:"id" gorm:"primaryKey;autoIncrement"`
 	PersonID  int `json:"person_id"`
@@ -50,3 +52,123 @@ type PersonAddress struct {
 func (PersonAddress) TableName() string {
 	return "address_join"
 }
+
+// PersonBlockingKey is a blocking key of a person, deleted with it.
+type PersonBlockingKey struct {
+	PersonID int    `json:"person_id" gorm:"primaryKey"`
+	Key      string `json:"key" gorm:"column:blocking_key;primaryKey"`
+}
+
+// TableName ..
+func (PersonBlockingKey) TableName() string {
+	return "person_blocking_key"
+}
+
+// PersonMerge records a person (the source) merged into another one (the target).
+//
+// While it is not unmerged, the source ID is an alias of the target ID.
+type PersonMerge struct {
+	ID             int        `json:"id" gorm:"primaryKey;autoIncrement"`
+	SourceID       int        `json:"source_id"`
+	TargetID       int        `json:"target_id"`
+	Source         Person     `json:"source" gorm:"serializer:json"`
+	Target         Person     `json:"target" gorm:"serializer:json"`
+	PhoneIDs       []int      `json:"phone_ids" gorm:"serializer:json"`
+	AddressJoinIDs []int      `json:"address_join_ids" gorm:"serializer:json"`
+	TagIDs         []int      `json:"tag_ids" gorm:"serializer:json"`
+	LabelIDs       []int      `json:"label_ids" gorm:"serializer:json"`
+	AttributeIDs   []int      `json:"attribute_ids" gorm:"serializer:json"`
+	RelationIDs    []int      `json:"relation_ids" gorm:"serializer:json"`
+	RelatedIDs     []int      `json:"related_ids" gorm:"serializer:json"`
+	Dropped        MergedRows `json:"dropped" gorm:"serializer:json"`
+	MergedAt       time.Time  `json:"merged_at"`
+	UnmergedAt     *time.Time `json:"unmerged_at"`
+}
+
+// MergedRows are the rows of a merged person deleted by the merge: the tags,
+// labels and custom attributes the target already had, and the
+// relationships between both persons.
+type MergedRows struct {
+	Tags       []PersonTag       `json:"tags,omitempty"`
+	Labels     []PersonLabel     `json:"labels,omitempty"`
+	Attributes []PersonAttribute `json:"attributes,omitempty"`
+	Relations  []Relationship    `json:"relations,omitempty"`
+}
+
+// TableName ..
+func (PersonMerge) TableName() string {
+	return "person_merge"
+}
+
+// Relationship represents a directed relationship between two persons: the
+// related person is the <type> of the person (e.g. its parent). It is
+// deleted with either of them.
+type Relationship struct {
+	ID        int        `json:"id" gorm:"primaryKey;autoIncrement"`
+	PersonID  int        `json:"person_id"`
+	RelatedID int        `json:"related_id"`
+	Type      string     `json:"type"`
+	ValidFrom *time.Time `json:"valid_from"`
+	ValidTo   *time.Time `json:"valid_to"`
+}
+
+// TableName ..
+func (Relationship) TableName() string {
+	return "person_relation"
+}
+
+// PersonTag represents a free-form tag attached to a person, deleted with it.
+type PersonTag struct {
+	ID       int    `json:"id" gorm:"primaryKey;autoIncrement"`
+	PersonID int    `json:"person_id"`
+	Tag      string `json:"tag"`
+}
+
+// TableName ..
+func (PersonTag) TableName() string {
+	return "person_tag"
+}
+
+// PersonLabel represents a key/value label attached to a person, deleted with it.
+type PersonLabel struct {
+	ID       int    `json:"id" gorm:"primaryKey;autoIncrement"`
+	PersonID int    `json:"person_id"`
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+}
+
+// TableName ..
+func (PersonLabel) TableName() string {
+	return "person_label"
+}
+
+// AttributeDefinition represents a custom person attribute defined by a tenant.
+type AttributeDefinition struct {
+	ID         int      `json:"id" gorm:"primaryKey;autoIncrement"`
+	Tenant     string   `json:"tenant"`
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`
+	Required   bool     `json:"required"`
+	EnumValues []string `json:"enum_values" gorm:"serializer:json"`
+	Regex      string   `json:"regex"`
+}
+
+// TableName ..
+func (AttributeDefinition) TableName() string {
+	return "attribute_definition"
+}
+
+// PersonAttribute represents the JSON encoded value of a custom person
+// attribute, deleted with the person.
+type PersonAttribute struct {
+	ID       int    `json:"id" gorm:"primaryKey;autoIncrement"`
+	PersonID int    `json:"person_id"`
+	Tenant   string `json:"tenant"`
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+}
+
+// TableName ..
+func (PersonAttribute) TableName() string {
+	return "person_attribute"
+}
@@ -0,0 +1,72 @@
+package feature
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The optional features, on unless switched off.
+const (
+	Merge      = "merge"
+	Duplicates = "duplicates"
+	Relations  = "relations"
+)
+
+var known = map[string]bool{Merge: true, Duplicates: true, Relations: true}
+
+// Flags switch the optional features on or off at runtime.
+type Flags struct {
+	flags atomic.Pointer[map[string]bool]
+}
+
+// NewFlags creates the feature flags.
+func NewFlags(flags map[string]bool) (*Flags, error) {
+	f := &Flags{}
+	if err := f.Set(flags); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Check fails when the flags name unknown features.
+func Check(flags map[string]bool) error {
+	for name := range flags {
+		if !known[name] {
+			return fmt.Errorf("unknown feature: %q", name)
+		}
+	}
+	return nil
+}
+
+// Set replaces the flags.
+func (f *Flags) Set(flags map[string]bool) error {
+	if err := Check(flags); err != nil {
+		return err
+	}
+
+	flags = maps.Clone(flags)
+	f.flags.Store(&flags)
+	return nil
+}
+
+// Enabled reports whether the feature is on.
+func (f *Flags) Enabled(name string) bool {
+	on, ok := (*f.flags.Load())[name]
+	return on || !ok
+}
+
+// Require answers 404 while the feature is off.
+func Require(f *Flags, name string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !f.Enabled(name) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "feature disabled"})
+			return
+		}
+
+		ctx.Next()
+	}
+}
@@ -0,0 +1,51 @@
+package feature_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"qore-be/internal/feature"
+
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlags(t *testing.T) {
+	_, err := feature.NewFlags(map[string]bool{"teleport": true})
+	assert.ErrorContains(t, err, `unknown feature: "teleport"`)
+
+	flags, err := feature.NewFlags(nil)
+	require.NoError(t, err)
+	assert.True(t, flags.Enabled(feature.Merge))
+
+	require.NoError(t, flags.Set(map[string]bool{feature.Merge: false, feature.Relations: true}))
+	assert.False(t, flags.Enabled(feature.Merge))
+	assert.True(t, flags.Enabled(feature.Relations))
+	assert.True(t, flags.Enabled(feature.Duplicates))
+
+	// the flags are kept when the new ones are rejected.
+	assert.Error(t, flags.Set(map[string]bool{"teleport": false}))
+	assert.False(t, flags.Enabled(feature.Merge))
+}
+
+func TestRequire(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	flags, err := feature.NewFlags(nil)
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.POST("/merge", feature.Require(flags, feature.Merge), func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+	send := func() int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/merge", nil))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, send())
+	require.NoError(t, flags.Set(map[string]bool{feature.Merge: false}))
+	assert.Equal(t, http.StatusNotFound, send())
+}
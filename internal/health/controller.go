@@ -0,0 +1,259 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"qore-be/internal/logging"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Statuses reported by the health endpoints.
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+)
+
+const defaultTimeout = 2 * time.Second
+
+// Check probes a dependency. It returns nil when the dependency is healthy.
+type Check func(context.Context) error
+
+// MigrationStatus returns the number of database migrations not applied yet.
+type MigrationStatus func(context.Context) (int, error)
+
+// BreakerState returns the state of the database circuit breaker: "closed",
+// "half-open" or "open".
+type BreakerState func() string
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// DependencyStatus is the result of a dependency check.
+type DependencyStatus struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Controller serves the liveness, readiness and health endpoints.
+type Controller struct {
+	checks     []namedCheck
+	migrations MigrationStatus
+	breaker    BreakerState
+	timeout    time.Duration
+
+	draining atomic.Bool
+}
+
+// ControllerOption ..
+type ControllerOption = func(*Controller) error
+
+// NewController creates a new health controller.
+func NewController(opts ...ControllerOption) (*Controller, error) {
+	ctrl := &Controller{timeout: defaultTimeout}
+
+	for _, opt := range opts {
+		if err := opt(ctrl); err != nil {
+			return nil, err
+		}
+	}
+
+	return ctrl, nil
+}
+
+// WithCheck registers a dependency check under the given name.
+func WithCheck(name string, check Check) ControllerOption {
+	return func(ctrl *Controller) error {
+		if check == nil {
+			return fmt.Errorf("nil %s check", name)
+		}
+		ctrl.checks = append(ctrl.checks, namedCheck{name: name, check: check})
+		return nil
+	}
+}
+
+// WithMigrationStatus makes the readiness depend on the database migrations
+// being all applied.
+func WithMigrationStatus(status MigrationStatus) ControllerOption {
+	return func(ctrl *Controller) error {
+		if status == nil {
+			return fmt.Errorf("nil migration status")
+		}
+		ctrl.migrations = status
+		return nil
+	}
+}
+
+// WithBreakerState makes the readiness depend on the database circuit breaker
+// not being open.
+func WithBreakerState(state BreakerState) ControllerOption {
+	return func(ctrl *Controller) error {
+		if state == nil {
+			return fmt.Errorf("nil breaker state")
+		}
+		ctrl.breaker = state
+		return nil
+	}
+}
+
+// WithTimeout sets the time given to each dependency check.
+func WithTimeout(d time.Duration) ControllerOption {
+	return func(ctrl *Controller) error {
+		if d <= 0 {
+			return fmt.Errorf("invalid health check timeout %s", d)
+		}
+		ctrl.timeout = d
+		return nil
+	}
+}
+
+// DBCheck pings the database behind a gorm connection.
+func DBCheck(db *gorm.DB) Check {
+	return func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	}
+}
+
+// Drain makes the readiness probe fail so that no more traffic is routed to
+// the server while it shuts down.
+func (c *Controller) Drain() {
+	c.draining.Store(true)
+}
+
+// Liveness reports that the process is alive.
+func (c *Controller) Liveness(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": StatusOK})
+}
+
+// Readiness reports whether the server can handle traffic: the schema is
+// migrated, the database circuit breaker is not open, the server is not
+// draining and all the dependencies respond.
+func (c *Controller) Readiness(ctx *gin.Context) {
+	migrations := c.migrationStatus(ctx)
+	breaker := c.breakerState()
+
+	ready := migrations == "applied" && breaker != "open" && !c.draining.Load()
+	checks := map[string]string{}
+	for name, res := range c.run(ctx) {
+		checks[name] = res.Status
+		if res.Status != StatusOK {
+			ready = false
+		}
+	}
+
+	body := gin.H{
+		"status":     StatusOK,
+		"migrations": migrations,
+		"breaker":    breaker,
+		"draining":   c.draining.Load(),
+		"checks":     checks,
+	}
+
+	if !ready {
+		body["status"] = StatusUnavailable
+		ctx.JSON(http.StatusServiceUnavailable, body)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, body)
+}
+
+// Health reports the status and latency of every dependency.
+func (c *Controller) Health(ctx *gin.Context) {
+	deps := c.run(ctx)
+
+	status, code := StatusOK, http.StatusOK
+	for _, res := range deps {
+		if res.Status != StatusOK {
+			status, code = StatusUnavailable, http.StatusServiceUnavailable
+		}
+	}
+
+	ctx.JSON(code, gin.H{
+		"status":       status,
+		"migrations":   c.migrationStatus(ctx),
+		"breaker":      c.breakerState(),
+		"draining":     c.draining.Load(),
+		"dependencies": deps,
+	})
+}
+
+// migrationStatus returns "applied", "pending" or "unknown" when the status
+// cannot be read.
+func (c *Controller) migrationStatus(ctx context.Context) string {
+	if c.migrations == nil {
+		return "applied"
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	pending, err := c.migrations(ctx)
+	switch {
+	case err != nil:
+		logging.FromContext(ctx).Warn("failed to get the migration status", "error", err.Error())
+		return "unknown"
+	case pending > 0:
+		return "pending"
+	default:
+		return "applied"
+	}
+}
+
+// breakerState returns the state of the database circuit breaker, "closed"
+// when there is none.
+func (c *Controller) breakerState() string {
+	if c.breaker == nil {
+		return "closed"
+	}
+	return c.breaker()
+}
+
+// run executes the dependency checks concurrently, each within the timeout.
+func (c *Controller) run(ctx context.Context) map[string]DependencyStatus {
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		res = make(map[string]DependencyStatus, len(c.checks))
+	)
+
+	for _, nc := range c.checks {
+		wg.Add(1)
+		go func(nc namedCheck) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := nc.check(ctx)
+			st := DependencyStatus{
+				Status:    StatusOK,
+				LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				logging.FromContext(ctx).Warn("health check failed", "dependency", nc.name, "error", err.Error())
+				st.Status, st.Error = StatusUnavailable, err.Error()
+			}
+
+			mu.Lock()
+			res[nc.name] = st
+			mu.Unlock()
+		}(nc)
+	}
+
+	wg.Wait()
+	return res
+}
@@ -0,0 +1,170 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"qore-be/internal/health"
+
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHealthController(t *testing.T) {
+	t.Run("successfully", func(t *testing.T) {
+		ctrl, err := health.NewController(health.WithTimeout(time.Second))
+		assert.NoError(t, err)
+		assert.NotNil(t, ctrl)
+	})
+
+	t.Run("with nil check", func(t *testing.T) {
+		ctrl, err := health.NewController(health.WithCheck("database", nil))
+		assert.Error(t, err)
+		assert.Nil(t, ctrl)
+	})
+
+	t.Run("with invalid timeout", func(t *testing.T) {
+		ctrl, err := health.NewController(health.WithTimeout(0))
+		assert.Error(t, err)
+		assert.Nil(t, ctrl)
+	})
+}
+
+func okCheck(context.Context) error { return nil }
+
+func failingCheck(context.Context) error { return fmt.Errorf("connection refused") }
+
+func slowCheck(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestHealthController_Readiness(t *testing.T) {
+	cases := []struct {
+		name           string
+		check          health.Check
+		pending        int
+		breaker        string
+		draining       bool
+		expectedStatus int
+	}{
+		{
+			name:           "successfully",
+			check:          okCheck,
+			expectedStatus: 200,
+		},
+		{
+			name:           "with pending migrations",
+			check:          okCheck,
+			pending:        1,
+			expectedStatus: 503,
+		},
+		{
+			name:           "with half-open breaker",
+			check:          okCheck,
+			breaker:        "half-open",
+			expectedStatus: 200,
+		},
+		{
+			name:           "with open breaker",
+			check:          okCheck,
+			breaker:        "open",
+			expectedStatus: 503,
+		},
+		{
+			name:           "while draining",
+			check:          okCheck,
+			draining:       true,
+			expectedStatus: 503,
+		},
+		{
+			name:           "with failing dependency",
+			check:          failingCheck,
+			expectedStatus: 503,
+		},
+		{
+			name:           "with timed out dependency",
+			check:          slowCheck,
+			expectedStatus: 503,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl, err := health.NewController(
+				health.WithCheck("database", tc.check),
+				health.WithMigrationStatus(func(context.Context) (int, error) { return tc.pending, nil }),
+				health.WithBreakerState(func() string {
+					if tc.breaker == "" {
+						return "closed"
+					}
+					return tc.breaker
+				}),
+				health.WithTimeout(10*time.Millisecond),
+			)
+			require.NoError(t, err)
+
+			if tc.draining {
+				ctrl.Drain()
+			}
+
+			srv := gin.Default()
+			gin.SetMode(gin.TestMode)
+
+			srv.GET("/healthz", ctrl.Liveness)
+			srv.GET("/readyz", ctrl.Readiness)
+
+			rec := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodGet, "/readyz", nil)
+			require.NoError(t, err)
+
+			srv.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+
+			// liveness does not depend on anything.
+			rec = httptest.NewRecorder()
+			req, err = http.NewRequest(http.MethodGet, "/healthz", nil)
+			require.NoError(t, err)
+
+			srv.ServeHTTP(rec, req)
+			assert.Equal(t, 200, rec.Code)
+		})
+	}
+}
+
+func TestHealthController_Health(t *testing.T) {
+	ctrl, err := health.NewController(
+		health.WithCheck("database", okCheck),
+		health.WithCheck("cache", failingCheck),
+	)
+	require.NoError(t, err)
+
+	srv := gin.Default()
+	gin.SetMode(gin.TestMode)
+
+	srv.GET("/admin/health", ctrl.Health)
+
+	rec := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/admin/health", nil)
+	require.NoError(t, err)
+
+	srv.ServeHTTP(rec, req)
+	assert.Equal(t, 503, rec.Code)
+
+	var res struct {
+		Status       string                             `json:"status"`
+		Dependencies map[string]health.DependencyStatus `json:"dependencies"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+
+	assert.Equal(t, health.StatusUnavailable, res.Status)
+	assert.Equal(t, health.StatusOK, res.Dependencies["database"].Status)
+	assert.Equal(t, health.StatusUnavailable, res.Dependencies["cache"].Status)
+	assert.Equal(t, "connection refused", res.Dependencies["cache"].Error)
+}
@@ -0,0 +1,72 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// GormLogger logs the gorm messages and statements through the request-scoped
+// logger. Failed statements are logged as errors, the slow ones as warnings
+// and the others at debug level.
+type GormLogger struct {
+	level         logger.LogLevel
+	slowThreshold time.Duration
+}
+
+// NewGormLogger creates a gorm logger. The statements lasting more than
+// slowThreshold are logged as slow, unless it is 0.
+func NewGormLogger(slowThreshold time.Duration) *GormLogger {
+	return &GormLogger{level: logger.Info, slowThreshold: slowThreshold}
+}
+
+// LogMode implements logger.Interface.
+func (l *GormLogger) LogMode(level logger.LogLevel) logger.Interface {
+	return &GormLogger{level: level, slowThreshold: l.slowThreshold}
+}
+
+// Info implements logger.Interface.
+func (l *GormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Info {
+		FromContext(ctx).InfoContext(ctx, msg, "args", args)
+	}
+}
+
+// Warn implements logger.Interface.
+func (l *GormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Warn {
+		FromContext(ctx).WarnContext(ctx, msg, "args", args)
+	}
+}
+
+// Error implements logger.Interface.
+func (l *GormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Error {
+		FromContext(ctx).ErrorContext(ctx, msg, "args", args)
+	}
+}
+
+// Trace implements logger.Interface.
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= logger.Silent {
+		return
+	}
+
+	log := FromContext(ctx)
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && l.level >= logger.Error:
+		sql, rows := fc()
+		log.ErrorContext(ctx, "query failed", "sql", sql, "rows", rows, "elapsed", elapsed, "error", err.Error())
+	case l.slowThreshold > 0 && elapsed > l.slowThreshold && l.level >= logger.Warn:
+		sql, rows := fc()
+		log.WarnContext(ctx, "slow query", "sql", sql, "rows", rows, "elapsed", elapsed, "threshold", l.slowThreshold)
+	case l.level >= logger.Info && log.Enabled(ctx, slog.LevelDebug):
+		sql, rows := fc()
+		log.DebugContext(ctx, "query", "sql", sql, "rows", rows, "elapsed", elapsed)
+	}
+}
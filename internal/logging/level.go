@@ -0,0 +1,73 @@
+package logging
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// LevelSwitch is a log level that can be changed at runtime, for a while or
+// for good.
+type LevelSwitch struct {
+	mu       sync.Mutex
+	level    slog.LevelVar
+	base     slog.Level
+	timer    *time.Timer
+	revertAt time.Time
+}
+
+// NewLevelSwitch creates a level switch set to the given level.
+func NewLevelSwitch(level slog.Level) *LevelSwitch {
+	s := &LevelSwitch{base: level}
+	s.level.Set(level)
+	return s
+}
+
+// Level implements slog.Leveler.
+func (s *LevelSwitch) Level() slog.Level {
+	return s.level.Level()
+}
+
+// Set changes the level. When revertAfter is positive, the level goes back
+// to the previous permanent one after that duration; otherwise the change is
+// permanent. Any pending revert is cancelled.
+func (s *LevelSwitch) Set(level slog.Level, revertAfter time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+		s.revertAt = time.Time{}
+	}
+
+	s.level.Set(level)
+	if revertAfter <= 0 {
+		s.base = level
+		return
+	}
+
+	s.revertAt = time.Now().Add(revertAfter)
+	var timer *time.Timer
+	timer = time.AfterFunc(revertAfter, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		// a later change cancelled this revert.
+		if s.timer != timer {
+			return
+		}
+		s.level.Set(s.base)
+		s.timer = nil
+		s.revertAt = time.Time{}
+	})
+	s.timer = timer
+}
+
+// RevertAt returns when the level goes back to the permanent one, or the
+// zero time when no revert is pending.
+func (s *LevelSwitch) RevertAt() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.revertAt
+}
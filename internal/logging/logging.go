@@ -0,0 +1,74 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"qore-be/internal/redact"
+	"strings"
+)
+
+// Log formats.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+type loggerKey struct{}
+
+type requestIDKey struct{}
+
+// New creates a logger writing to w in the given format ("text" or "json")
+// from the given level on. The attributes known to hold PII are redacted.
+func New(w io.Writer, format string, level slog.Leveler) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{
+		AddSource: true,
+		Level:     level,
+	}
+
+	switch format {
+	case FormatText:
+		return slog.New(redact.NewHandler(slog.NewTextHandler(w, opts))), nil
+	case FormatJSON:
+		return slog.New(redact.NewHandler(slog.NewJSONHandler(w, opts))), nil
+	default:
+		return nil, fmt.Errorf("unknown log format: %q", format)
+	}
+}
+
+// ParseLevel parses a log level name: debug, info, warn or error.
+func ParseLevel(level string) (slog.Level, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(strings.TrimSpace(level))); err != nil {
+		return 0, fmt.Errorf("unknown log level: %q", level)
+	}
+	return lvl, nil
+}
+
+// NewContext returns a copy of ctx holding the logger.
+func NewContext(ctx context.Context, log *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, log)
+}
+
+// FromContext returns the request-scoped logger held by the context, or the
+// default logger when there is none.
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if log, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok && log != nil {
+			return log
+		}
+	}
+	return slog.Default()
+}
+
+// WithRequestID returns a copy of ctx holding the request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID held by the context, if any.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
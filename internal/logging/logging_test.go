@@ -0,0 +1,128 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"qore-be/internal/logging"
+
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestNew(t *testing.T) {
+	cases := []struct {
+		name   string
+		format string
+		hasErr bool
+	}{
+		{name: "text", format: logging.FormatText},
+		{name: "json", format: logging.FormatJSON},
+		{name: "with unknown format", format: "xml", hasErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			log, err := logging.New(&bytes.Buffer{}, tc.format, slog.LevelInfo)
+			if tc.hasErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, log)
+		})
+	}
+
+	t.Run("level filtering", func(t *testing.T) {
+		var buf bytes.Buffer
+		log, err := logging.New(&buf, logging.FormatJSON, slog.LevelWarn)
+		require.NoError(t, err)
+
+		log.Info("hidden")
+		log.Warn("shown")
+
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+		assert.Equal(t, "shown", entry["msg"])
+	})
+}
+
+func TestParseLevel(t *testing.T) {
+	lvl, err := logging.ParseLevel(" DEBUG ")
+	assert.NoError(t, err)
+	assert.Equal(t, slog.LevelDebug, lvl)
+
+	_, err = logging.ParseLevel("verbose")
+	assert.Error(t, err)
+}
+
+func TestLevelSwitch(t *testing.T) {
+	s := logging.NewLevelSwitch(slog.LevelInfo)
+
+	s.Set(slog.LevelWarn, 0)
+	assert.Equal(t, slog.LevelWarn, s.Level())
+	assert.True(t, s.RevertAt().IsZero())
+
+	s.Set(slog.LevelDebug, 20*time.Millisecond)
+	assert.Equal(t, slog.LevelDebug, s.Level())
+	assert.False(t, s.RevertAt().IsZero())
+
+	assert.Eventually(t, func() bool {
+		return s.Level() == slog.LevelWarn
+	}, time.Second, 5*time.Millisecond)
+	assert.True(t, s.RevertAt().IsZero())
+
+	// a later change cancels the pending revert.
+	s.Set(slog.LevelDebug, 20*time.Millisecond)
+	s.Set(slog.LevelError, 0)
+	time.Sleep(40 * time.Millisecond)
+	assert.Equal(t, slog.LevelError, s.Level())
+}
+
+func TestFromContext(t *testing.T) {
+	assert.Equal(t, slog.Default(), logging.FromContext(context.TODO()))
+
+	log := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	ctx := logging.NewContext(context.TODO(), log)
+	assert.Equal(t, log, logging.FromContext(ctx))
+
+	ctx = logging.WithRequestID(ctx, "abc")
+	assert.Equal(t, "abc", logging.RequestID(ctx))
+	assert.Equal(t, "", logging.RequestID(context.TODO()))
+}
+
+func TestGormLogger_Trace(t *testing.T) {
+	cases := []struct {
+		name     string
+		elapsed  time.Duration
+		err      error
+		expected string
+	}{
+		{name: "fast query", elapsed: time.Millisecond, expected: ""},
+		{name: "slow query", elapsed: time.Second, expected: "level=WARN msg=\"slow query\""},
+		{name: "failed query", elapsed: time.Millisecond, err: fmt.Errorf("boom"), expected: "level=ERROR msg=\"query failed\""},
+		{name: "not found", elapsed: time.Second, err: gorm.ErrRecordNotFound, expected: "level=WARN msg=\"slow query\""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			ctx := logging.NewContext(context.TODO(), slog.New(slog.NewTextHandler(buf, nil)))
+
+			l := logging.NewGormLogger(100 * time.Millisecond)
+			l.Trace(ctx, time.Now().Add(-tc.elapsed), func() (string, int64) { return "SELECT 1", 1 }, tc.err)
+
+			if tc.expected == "" {
+				assert.Empty(t, buf.String())
+				return
+			}
+			assert.Contains(t, buf.String(), tc.expected)
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"gorm.io/gorm"
+)
+
+const startKey = "metrics:start"
+
+// gormPlugin times the gorm queries.
+type gormPlugin struct {
+	m *Metrics
+}
+
+// GormPlugin returns a gorm plugin recording the duration of the queries by
+// operation and table.
+func (m *Metrics) GormPlugin() gorm.Plugin {
+	return &gormPlugin{m: m}
+}
+
+// Name implements gorm.Plugin.
+func (p *gormPlugin) Name() string {
+	return "metrics"
+}
+
+// Initialize implements gorm.Plugin.
+func (p *gormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	for _, err := range []error{
+		cb.Create().Before("gorm:create").Register("metrics:before_create", p.before),
+		cb.Create().After("gorm:create").Register("metrics:after_create", p.after("create")),
+		cb.Query().Before("gorm:query").Register("metrics:before_query", p.before),
+		cb.Query().After("gorm:query").Register("metrics:after_query", p.after("query")),
+		cb.Update().Before("gorm:update").Register("metrics:before_update", p.before),
+		cb.Update().After("gorm:update").Register("metrics:after_update", p.after("update")),
+		cb.Delete().Before("gorm:delete").Register("metrics:before_delete", p.before),
+		cb.Delete().After("gorm:delete").Register("metrics:after_delete", p.after("delete")),
+		cb.Row().Before("gorm:row").Register("metrics:before_row", p.before),
+		cb.Row().After("gorm:row").Register("metrics:after_row", p.after("row")),
+		cb.Raw().Before("gorm:raw").Register("metrics:before_raw", p.before),
+		cb.Raw().After("gorm:raw").Register("metrics:after_raw", p.after("raw")),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *gormPlugin) before(db *gorm.DB) {
+	db.InstanceSet(startKey, time.Now())
+}
+
+func (p *gormPlugin) after(op string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(startKey)
+		if !ok {
+			return
+		}
+
+		start, ok := v.(time.Time)
+		if !ok {
+			return
+		}
+
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+
+		p.m.dbDuration.WithLabelValues(op, table).Observe(time.Since(start).Seconds())
+	}
+}
+
+// dbStatsCollector collects the stats of the current connection pool of the
+// database.
+type dbStatsCollector struct {
+	db   *gorm.DB
+	name string
+}
+
+// Describe implements prometheus.Collector.
+func (c *dbStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	// the descriptions do not depend on the pool.
+	collectors.NewDBStatsCollector(nil, c.name).Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *dbStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	sqlDB, err := c.db.DB()
+	if err != nil {
+		return
+	}
+	collectors.NewDBStatsCollector(sqlDB, c.name).Collect(ch)
+}
@@ -0,0 +1,149 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"gorm.io/gorm"
+)
+
+const namespace = "qore"
+
+// Metrics holds the prometheus collectors of the application.
+type Metrics struct {
+	reg *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+	personOps    *prometheus.CounterVec
+	dbDuration   *prometheus.HistogramVec
+
+	breakerState      *prometheus.GaugeVec
+	breakerRejections *prometheus.CounterVec
+	cacheRequests     *prometheus.CounterVec
+}
+
+// New creates the application metrics along with the Go runtime and process ones.
+func New() *Metrics {
+	m := &Metrics{
+		reg: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests by route, method and status.",
+		}, []string{"route", "method", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Duration of the HTTP requests by route, method and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		personOps: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "person_operations_total",
+			Help:      "Number of person operations by operation and result.",
+		}, []string{"operation", "result"}),
+		dbDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Duration of the database queries by operation and table.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"operation", "table"}),
+		breakerState: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "db_breaker_state",
+			Help:      "State of the database circuit breaker: 1 for the current state, 0 for the others.",
+		}, []string{"state"}),
+		breakerRejections: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "db_breaker_rejections_total",
+			Help:      "Number of repository calls rejected by the database circuit breaker by operation and reason.",
+		}, []string{"operation", "reason"}),
+		cacheRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_requests_total",
+			Help:      "Number of cache lookups by cache and result: hit, miss or error.",
+		}, []string{"cache", "result"}),
+	}
+
+	m.reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.personOps,
+		m.dbDuration,
+		m.breakerState,
+		m.breakerRejections,
+		m.cacheRequests,
+	)
+
+	return m
+}
+
+// Registry returns the registry holding the collectors.
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.reg
+}
+
+// RegisterDB exposes the connection pool stats of the database, following
+// the replacements of its pool.
+func (m *Metrics) RegisterDB(db *gorm.DB, name string) {
+	m.reg.MustRegister(&dbStatsCollector{db: db, name: name})
+}
+
+// Handler serves the metrics in the prometheus exposition format.
+func (m *Metrics) Handler() gin.HandlerFunc {
+	h := promhttp.HandlerFor(m.reg, promhttp.HandlerOpts{})
+	return gin.WrapH(h)
+}
+
+// Middleware records the count and duration of the HTTP requests. Requests
+// are labelled by route template so that the path parameters do not blow up
+// the labels cardinality.
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		status := strconv.Itoa(ctx.Writer.Status())
+		m.httpRequests.WithLabelValues(route, ctx.Request.Method, status).Inc()
+		m.httpDuration.WithLabelValues(route, ctx.Request.Method, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// BreakerState records the state of the database circuit breaker.
+func (m *Metrics) BreakerState(state string) {
+	for _, s := range []string{"closed", "half-open", "open"} {
+		v := 0.0
+		if s == state {
+			v = 1
+		}
+		m.breakerState.WithLabelValues(s).Set(v)
+	}
+}
+
+// BreakerRejection counts a repository call rejected by the database circuit
+// breaker, "open" or "busy".
+func (m *Metrics) BreakerRejection(op string, reason string) {
+	m.breakerRejections.WithLabelValues(op, reason).Inc()
+}
+
+// CacheRequest counts a cache lookup with its result.
+func (m *Metrics) CacheRequest(cache string, result string) {
+	m.cacheRequests.WithLabelValues(cache, result).Inc()
+}
+
+// PersonOperation counts a person operation with its result.
+func (m *Metrics) PersonOperation(op string, result string) {
+	m.personOps.WithLabelValues(op, result).Inc()
+}
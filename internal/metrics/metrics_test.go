@@ -0,0 +1,99 @@
+package metrics_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"qore-be/internal/database"
+	"qore-be/internal/metrics"
+	"strings"
+
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestMetrics_Middleware(t *testing.T) {
+	m := metrics.New()
+
+	srv := gin.Default()
+	gin.SetMode(gin.TestMode)
+
+	srv.Use(m.Middleware())
+	srv.GET("/metrics", m.Handler())
+	srv.GET("/person/:id/info", func(ctx *gin.Context) {
+		ctx.Status(http.StatusNotFound)
+	})
+
+	for _, path := range []string{"/person/1/info", "/person/2/info", "/unknown"} {
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		require.NoError(t, err)
+		srv.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	m.PersonOperation("read", "not_found")
+	m.BreakerState("open")
+	m.BreakerRejection("person.GetAll", "open")
+	m.CacheRequest("person", "hit")
+
+	rec := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/metrics", nil)
+	require.NoError(t, err)
+	srv.ServeHTTP(rec, req)
+
+	assert.Equal(t, 200, rec.Code)
+
+	body := rec.Body.String()
+	// requests are labelled by route template, not by path.
+	assert.Contains(t, body, `qore_http_requests_total{method="GET",route="/person/:id/info",status="404"} 2`)
+	assert.Contains(t, body, `qore_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, body, `qore_person_operations_total{operation="read",result="not_found"} 1`)
+	assert.Contains(t, body, `qore_db_breaker_state{state="open"} 1`)
+	assert.Contains(t, body, `qore_db_breaker_state{state="closed"} 0`)
+	assert.Contains(t, body, `qore_db_breaker_rejections_total{operation="person.GetAll",reason="open"} 1`)
+	assert.Contains(t, body, `qore_cache_requests_total{cache="person",result="hit"} 1`)
+	assert.Contains(t, body, "go_goroutines")
+
+	problems, err := testutil.GatherAndLint(m.Registry())
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+	assert.False(t, strings.Contains(body, "/person/1/info"))
+}
+
+func TestMetrics_RegisterDB(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	db, err := database.Open(ctx, database.DriverSQLite, filepath.Join(dir, "first.db"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+
+	m := metrics.New()
+	m.RegisterDB(db, "main")
+
+	// the stats follow the rotations of the connection pool.
+	prev, err := db.DB()
+	require.NoError(t, err)
+	finish, err := database.Rotate(ctx, db, database.DriverSQLite, filepath.Join(dir, "second.db"))
+	require.NoError(t, err)
+	finish(true)
+	require.NoError(t, prev.Close())
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+	require.NoError(t, sqlDB.Ping())
+
+	assert.NoError(t, testutil.GatherAndCompare(m.Registry(), strings.NewReader(`
+# HELP go_sql_open_connections The number of established connections both in use and idle.
+# TYPE go_sql_open_connections gauge
+go_sql_open_connections{db_name="main"} 1
+`), "go_sql_open_connections"))
+
+	problems, err := testutil.GatherAndLint(m.Registry())
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BodyLimit answers 413 to the requests whose body is larger than limit
+// bytes, reading no more than that. The bodies of unknown length are read
+// before the handlers, so that they get the whole body or none.
+func BodyLimit(limit int64) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := ctx.Request
+		switch {
+		case req.Body == nil || req.Body == http.NoBody:
+		case req.ContentLength > limit:
+			tooLarge(ctx)
+			return
+		case req.ContentLength >= 0:
+			// the body is no longer than its length.
+		default:
+			b, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, req.Body, limit))
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				tooLarge(ctx)
+				return
+			}
+			if err != nil {
+				ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "failed to read the request body"})
+				return
+			}
+			req.Body = io.NopCloser(bytes.NewReader(b))
+		}
+
+		ctx.Next()
+	}
+}
+
+func tooLarge(ctx *gin.Context) {
+	// the rest of the body is not read: the connection is closed.
+	ctx.Header("Connection", "close")
+	ctx.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "request body too large"})
+}
@@ -0,0 +1,61 @@
+package middleware_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"qore-be/internal/middleware"
+	"strings"
+
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// chunked hides the length of the body.
+type chunked struct {
+	io.Reader
+}
+
+func TestBodyLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	srv := gin.New()
+	srv.Use(middleware.BodyLimit(10))
+	srv.POST("/echo", func(ctx *gin.Context) {
+		b, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		ctx.String(http.StatusOK, string(b))
+	})
+
+	cases := []struct {
+		name   string
+		body   io.Reader
+		status int
+	}{
+		{name: "no body", status: http.StatusOK},
+		{name: "small body", body: strings.NewReader("0123456789"), status: http.StatusOK},
+		{name: "large body", body: strings.NewReader("0123456789A"), status: http.StatusRequestEntityTooLarge},
+		{name: "small body of unknown length", body: chunked{strings.NewReader("0123456789")}, status: http.StatusOK},
+		{name: "large body of unknown length", body: chunked{strings.NewReader("0123456789A")}, status: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/echo", tc.body)
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.status, rec.Code)
+			if tc.status == http.StatusOK && tc.body != nil {
+				assert.Equal(t, "0123456789", rec.Body.String())
+			} else if tc.status != http.StatusOK {
+				assert.JSONEq(t, `{"error":"request body too large"}`, rec.Body.String())
+			}
+		})
+	}
+}
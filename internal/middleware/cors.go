@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"slices"
+	"sync/atomic"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// Origins are the origins allowed to call the API, which can be changed at
+// runtime. "*" allows any origin.
+type Origins struct {
+	origins atomic.Pointer[[]string]
+}
+
+// NewOrigins creates the allowed origins.
+func NewOrigins(origins []string) *Origins {
+	o := &Origins{}
+	o.Set(origins)
+	return o
+}
+
+// Set replaces the allowed origins.
+func (o *Origins) Set(origins []string) {
+	origins = slices.Clone(origins)
+	o.origins.Store(&origins)
+}
+
+// Allowed reports whether the origin is allowed.
+func (o *Origins) Allowed(origin string) bool {
+	origins := *o.origins.Load()
+	return slices.Contains(origins, "*") || slices.Contains(origins, origin)
+}
+
+// CORS answers the cross-origin requests of the allowed origins.
+func CORS(origins *Origins) gin.HandlerFunc {
+	cfg := cors.DefaultConfig()
+	cfg.AllowOriginFunc = origins.Allowed
+	return cors.New(cfg)
+}
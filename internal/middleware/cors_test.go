@@ -0,0 +1,38 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"qore-be/internal/middleware"
+
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	origins := middleware.NewOrigins([]string{"https://app.example.com"})
+	router := gin.New()
+	router.Use(middleware.CORS(origins))
+	router.GET("/", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+	send := func(origin string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Origin", origin)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, send("https://app.example.com"))
+	assert.Equal(t, http.StatusForbidden, send("https://evil.example.com"))
+
+	origins.Set([]string{"*"})
+	assert.Equal(t, http.StatusOK, send("https://evil.example.com"))
+
+	origins.Set(nil)
+	assert.Equal(t, http.StatusForbidden, send("https://app.example.com"))
+}
@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"qore-be/internal/tenant"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+// RateLimiter limits the requests of each client with a token bucket. The
+// limit can be changed at runtime.
+type RateLimiter struct {
+	mu        sync.Mutex
+	limit     rate.Limit
+	burst     int
+	clients   map[string]*rate.Limiter
+	nextPrune time.Time
+}
+
+// NewRateLimiter allows each client rps requests per second, in bursts of
+// burst requests. Zero rps means no limit.
+func NewRateLimiter(rps float64, burst int) *RateLimiter {
+	l := &RateLimiter{clients: map[string]*rate.Limiter{}}
+	l.SetLimit(rps, burst)
+	return l
+}
+
+// SetLimit changes the limit of every client.
+func (l *RateLimiter) SetLimit(rps float64, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.limit, l.burst = rate.Limit(rps), burst
+	if rps == 0 {
+		l.clients = map[string]*rate.Limiter{}
+		return
+	}
+
+	now := time.Now()
+	for _, lim := range l.clients {
+		lim.SetLimitAt(now, l.limit)
+		lim.SetBurstAt(now, burst)
+	}
+}
+
+// Reserve takes a token of the client, returning how long to wait for one
+// when none is left.
+func (l *RateLimiter) Reserve(client string) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.limit == 0 {
+		return 0, true
+	}
+
+	now := time.Now()
+	l.prune(now)
+
+	lim, ok := l.clients[client]
+	if !ok {
+		lim = rate.NewLimiter(l.limit, l.burst)
+		l.clients[client] = lim
+	}
+
+	r := lim.ReserveN(now, 1)
+	if delay := r.DelayFrom(now); delay > 0 {
+		r.CancelAt(now)
+		return delay, false
+	}
+	return 0, true
+}
+
+// prune forgets the clients whose bucket is full again, every minute.
+func (l *RateLimiter) prune(now time.Time) {
+	if now.Before(l.nextPrune) {
+		return
+	}
+	for client, lim := range l.clients {
+		if lim.TokensAt(now) >= float64(l.burst) {
+			delete(l.clients, client)
+		}
+	}
+	l.nextPrune = now.Add(time.Minute)
+}
+
+// RateLimit rejects the requests of the clients over the limit with a 429.
+// The clients are told apart by tenant and IP address, hence it runs after
+// the tenant middleware.
+func RateLimit(l *RateLimiter) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		client := tenant.FromContext(ctx.Request.Context()) + "/" + ctx.ClientIP()
+		if delay, ok := l.Reserve(client); !ok {
+			ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+			ctx.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "too many requests"})
+			return
+		}
+
+		ctx.Next()
+	}
+}
@@ -0,0 +1,53 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"qore-be/internal/middleware"
+	"qore-be/internal/tenant"
+	"time"
+
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	limiter := middleware.NewRateLimiter(0.1, 2)
+	router := gin.New()
+	router.Use(tenant.Middleware(tenant.WithTrustedProxies(netip.MustParsePrefix("10.0.0.0/8"))), middleware.RateLimit(limiter))
+	router.GET("/", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+	send := func(tenantID string, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(tenant.Header, tenantID)
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// a client gets its burst, then waits for the next token.
+	assert.Equal(t, http.StatusOK, send("acme", "10.0.0.1").Code)
+	assert.Equal(t, http.StatusOK, send("acme", "10.0.0.1").Code)
+	w := send("acme", "10.0.0.1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "10", w.Header().Get("Retry-After"))
+
+	// the other clients are limited apart.
+	assert.Equal(t, http.StatusOK, send("acme", "10.0.0.2").Code)
+	assert.Equal(t, http.StatusOK, send("globex", "10.0.0.1").Code)
+
+	// the limit changes for every client.
+	limiter.SetLimit(1e6, 2)
+	assert.Eventually(t, func() bool { return send("acme", "10.0.0.1").Code == http.StatusOK }, time.Second, time.Millisecond)
+
+	limiter.SetLimit(0, 0)
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, http.StatusOK, send("acme", "10.0.0.1").Code)
+	}
+}
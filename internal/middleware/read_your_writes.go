@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+	"qore-be/internal/database"
+	"qore-be/internal/tenant"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReadYourWrites sends the reads of a client to the primary database for a
+// while after its writes, i.e. its requests other than GET, HEAD and OPTIONS,
+// so that it reads them back. The clients are told apart by tenant and IP
+// address, hence it runs after the tenant middleware.
+func ReadYourWrites(replicas *database.Replicas) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		client := tenant.FromContext(ctx.Request.Context()) + "/" + ctx.ClientIP()
+		if replicas.Sticky(client) {
+			ctx.Request = ctx.Request.WithContext(database.NewPrimaryContext(ctx.Request.Context()))
+		}
+
+		ctx.Next()
+
+		switch ctx.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			replicas.Wrote(client)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"qore-be/internal/database"
+	"qore-be/internal/middleware"
+	"qore-be/internal/tenant"
+	"time"
+
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestReadYourWrites(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	primary := &gorm.DB{}
+	replicas, err := database.NewReplicas(primary, []*gorm.DB{{}}, database.WithStickyWrites(time.Minute))
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.Use(tenant.Middleware(tenant.WithTrustedProxies(netip.MustParsePrefix("10.0.0.0/8"))), middleware.ReadYourWrites(replicas))
+	handler := func(ctx *gin.Context) {
+		if replicas.Reader(ctx.Request.Context()) == primary {
+			ctx.String(http.StatusOK, "primary")
+			return
+		}
+		ctx.String(http.StatusOK, "replica")
+	}
+	router.GET("/", handler)
+	router.POST("/", handler)
+
+	send := func(method string, tenantID string, ip string) string {
+		req := httptest.NewRequest(method, "/", nil)
+		req.Header.Set(tenant.Header, tenantID)
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+
+	cases := []struct {
+		name     string
+		method   string
+		tenant   string
+		ip       string
+		expected string
+	}{
+		{name: "read before any write", method: http.MethodGet, tenant: "acme", ip: "10.0.0.1", expected: "replica"},
+		{name: "write", method: http.MethodPost, tenant: "acme", ip: "10.0.0.1", expected: "replica"},
+		{name: "read after the write", method: http.MethodGet, tenant: "acme", ip: "10.0.0.1", expected: "primary"},
+		{name: "read by another address", method: http.MethodGet, tenant: "acme", ip: "10.0.0.2", expected: "replica"},
+		{name: "read by another tenant", method: http.MethodGet, tenant: "globex", ip: "10.0.0.1", expected: "replica"},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.expected, send(tc.method, tc.tenant, tc.ip), tc.name)
+	}
+}
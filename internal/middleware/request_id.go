@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"qore-be/internal/logging"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader is the header carrying the request ID.
+const RequestIDHeader = "X-Request-ID"
+
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID accepts the request ID sent by the caller, or generates one, and
+// stores a logger tagged with it in the request context. The ID is echoed in
+// the response header and in the JSON error bodies.
+func RequestID() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+
+		log := logging.FromContext(ctx.Request.Context()).With("request_id", id)
+		if sc := trace.SpanContextFromContext(ctx.Request.Context()); sc.IsValid() {
+			log = log.With("trace_id", sc.TraceID().String())
+		}
+
+		reqCtx := logging.WithRequestID(ctx.Request.Context(), id)
+		ctx.Request = ctx.Request.WithContext(logging.NewContext(reqCtx, log))
+		ctx.Header(RequestIDHeader, id)
+
+		w := &errorBodyWriter{ResponseWriter: ctx.Writer}
+		ctx.Writer = w
+		ctx.Next()
+		w.flush(id)
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// errorBodyWriter holds back the JSON error bodies so that the request ID can
+// be added to them.
+type errorBodyWriter struct {
+	gin.ResponseWriter
+	buf bytes.Buffer
+}
+
+func (w *errorBodyWriter) holds() bool {
+	return w.Status() >= http.StatusBadRequest &&
+		strings.HasPrefix(w.Header().Get("Content-Type"), "application/json")
+}
+
+func (w *errorBodyWriter) Write(b []byte) (int, error) {
+	if w.holds() {
+		return w.buf.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *errorBodyWriter) WriteString(s string) (int, error) {
+	if w.holds() {
+		return w.buf.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *errorBodyWriter) flush(id string) {
+	if w.buf.Len() == 0 {
+		return
+	}
+
+	body := w.buf.Bytes()
+	var obj map[string]any
+	if err := json.Unmarshal(body, &obj); err == nil {
+		obj["request_id"] = id
+		if b, err := json.Marshal(obj); err == nil {
+			body = b
+		}
+	}
+
+	_, _ = w.ResponseWriter.Write(body)
+}
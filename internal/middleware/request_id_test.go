@@ -0,0 +1,102 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"qore-be/internal/logging"
+	"qore-be/internal/middleware"
+
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID(t *testing.T) {
+	cases := []struct {
+		name       string
+		requestID  string
+		status     int
+		generated  bool
+		bodyHasID  bool
+		expectedID string
+	}{
+		{
+			name:       "with caller request id",
+			requestID:  "abc-123",
+			status:     http.StatusOK,
+			expectedID: "abc-123",
+		},
+		{
+			name:      "without request id",
+			status:    http.StatusOK,
+			generated: true,
+		},
+		{
+			name:      "with invalid request id",
+			requestID: "not valid\n",
+			status:    http.StatusOK,
+			generated: true,
+		},
+		{
+			name:       "with error response",
+			requestID:  "abc-123",
+			status:     http.StatusNotFound,
+			bodyHasID:  true,
+			expectedID: "abc-123",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			prev := slog.Default()
+			slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+			defer slog.SetDefault(prev)
+
+			srv := gin.Default()
+			gin.SetMode(gin.TestMode)
+			srv.ContextWithFallback = true
+
+			srv.Use(middleware.RequestID())
+			srv.GET("/person/:id/info", func(ctx *gin.Context) {
+				logging.FromContext(ctx).Info("handled")
+				ctx.JSON(tc.status, gin.H{"error": "not found"})
+			})
+
+			rec := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodGet, "/person/1/info", nil)
+			require.NoError(t, err)
+			if tc.requestID != "" {
+				req.Header.Set(middleware.RequestIDHeader, tc.requestID)
+			}
+
+			srv.ServeHTTP(rec, req)
+
+			id := rec.Header().Get(middleware.RequestIDHeader)
+			if tc.generated {
+				assert.Len(t, id, 32)
+			} else {
+				assert.Equal(t, tc.expectedID, id)
+			}
+
+			var body map[string]any
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Equal(t, tc.status, rec.Code)
+			assert.Equal(t, "not found", body["error"])
+			if tc.bodyHasID {
+				assert.Equal(t, id, body["request_id"])
+			} else {
+				assert.NotContains(t, body, "request_id")
+			}
+
+			var entry map[string]any
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+			assert.Equal(t, id, entry["request_id"])
+		})
+	}
+}
@@ -0,0 +1,573 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"qore-be/internal/logging"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The migrations of each dialect are in their own directory.
+//
+//go:embed migrations
+var embedded embed.FS
+
+// Dialects supported by the migrator, the same as the database drivers.
+const (
+	DialectMySQL    = "mysql"
+	DialectPostgres = "postgres"
+	DialectSQLite   = "sqlite"
+)
+
+const (
+	lockName           = "qore_schema_migrations"
+	lockKey            = 7318424150
+	lockPollInterval   = 500 * time.Millisecond
+	defaultLockTimeout = time.Minute
+)
+
+var (
+	ErrDirty          = fmt.Errorf("database schema is dirty")
+	ErrUnknownVersion = fmt.Errorf("unknown migration version")
+	ErrLocked         = fmt.Errorf("migrations are locked by another instance")
+	ErrNoMigration    = fmt.Errorf("no migration to revert")
+
+	filename = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+)
+
+// Migration is a versioned schema change with the scripts applying and
+// reverting it.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status is the state of a migration in the database.
+type Status struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+	Dirty     bool       `json:"dirty,omitempty"`
+}
+
+type record struct {
+	appliedAt timestamp
+	dirty     bool
+}
+
+// timestamp scans a TIMESTAMP column whether the driver parses it or not
+// (e.g. MySQL without parseTime=true).
+type timestamp time.Time
+
+func (t *timestamp) Scan(src any) error {
+	switch v := src.(type) {
+	case time.Time:
+		*t = timestamp(v)
+		return nil
+	case []byte:
+		return t.parse(string(v))
+	case string:
+		return t.parse(v)
+	default:
+		return fmt.Errorf("unsupported timestamp: %T", src)
+	}
+}
+
+func (t *timestamp) parse(s string) error {
+	for _, layout := range []string{time.DateTime, time.RFC3339Nano, "2006-01-02 15:04:05.999999999-07:00"} {
+		if v, err := time.Parse(layout, s); err == nil {
+			*t = timestamp(v)
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid timestamp: %q", s)
+}
+
+// Migrator applies and reverts the migrations, recording the applied ones in
+// the schema_migrations table. Only one migrator at a time changes a
+// database: the others wait for the lock.
+type Migrator struct {
+	db          *sql.DB
+	dialect     string
+	source      fs.FS
+	migrations  []Migration
+	lockTimeout time.Duration
+}
+
+// Option ..
+type Option func(*Migrator) error
+
+// New creates a new migrator of the embedded migrations of the dialect.
+func New(db *sql.DB, opts ...Option) (*Migrator, error) {
+	if db == nil {
+		return nil, fmt.Errorf("nil db")
+	}
+
+	m := &Migrator{
+		db:          db,
+		dialect:     DialectMySQL,
+		lockTimeout: defaultLockTimeout,
+	}
+
+	for _, opt := range opts {
+		if err := opt(m); err != nil {
+			return nil, err
+		}
+	}
+
+	if m.source == nil {
+		src, err := fs.Sub(embedded, path.Join("migrations", m.dialect))
+		if err != nil {
+			return nil, err
+		}
+		m.source = src
+	}
+
+	migrations, err := load(m.source)
+	if err != nil {
+		return nil, err
+	}
+	m.migrations = migrations
+
+	return m, nil
+}
+
+// WithDialect sets the SQL dialect of the database.
+func WithDialect(dialect string) Option {
+	return func(m *Migrator) error {
+		switch dialect {
+		case DialectMySQL, DialectPostgres, DialectSQLite:
+		default:
+			return fmt.Errorf("unsupported dialect: %q", dialect)
+		}
+		m.dialect = dialect
+		return nil
+	}
+}
+
+// WithSource replaces the embedded migrations.
+func WithSource(source fs.FS) Option {
+	return func(m *Migrator) error {
+		if source == nil {
+			return fmt.Errorf("nil migrations source")
+		}
+		m.source = source
+		return nil
+	}
+}
+
+// WithLockTimeout sets how long to wait for another instance to release the lock.
+func WithLockTimeout(d time.Duration) Option {
+	return func(m *Migrator) error {
+		if d <= 0 {
+			return fmt.Errorf("invalid lock timeout: %v", d)
+		}
+		m.lockTimeout = d
+		return nil
+	}
+}
+
+// Latest returns the version of the last migration, or 0 when there is none.
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Status returns the state of every migration.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var res []Status
+	err := m.withConn(ctx, false, func(conn *sql.Conn, applied map[int64]record) error {
+		res = make([]Status, len(m.migrations))
+		for i, mig := range m.migrations {
+			res[i] = Status{Version: mig.Version, Name: mig.Name}
+			if rec, ok := applied[mig.Version]; ok {
+				at := time.Time(rec.appliedAt)
+				res[i].Applied, res[i].AppliedAt, res[i].Dirty = true, &at, rec.dirty
+			}
+		}
+		return nil
+	})
+	return res, err
+}
+
+// Pending returns the number of migrations not applied yet.
+func (m *Migrator) Pending(ctx context.Context) (int, error) {
+	status, err := m.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	pending := 0
+	for _, s := range status {
+		if !s.Applied || s.Dirty {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+// Up applies all the pending migrations.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.To(ctx, m.Latest())
+}
+
+// Down reverts the last applied migration.
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.withConn(ctx, true, func(conn *sql.Conn, applied map[int64]record) error {
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			if _, ok := applied[m.migrations[i].Version]; ok {
+				return m.revert(ctx, conn, m.migrations[i])
+			}
+		}
+		return ErrNoMigration
+	})
+}
+
+// To applies or reverts the migrations so that the given version is the last
+// applied one. Version 0 reverts all the migrations.
+func (m *Migrator) To(ctx context.Context, version int64) error {
+	if version != 0 && m.find(version) < 0 {
+		return fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+
+	return m.withConn(ctx, true, func(conn *sql.Conn, applied map[int64]record) error {
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; ok && mig.Version > version {
+				if err := m.revert(ctx, conn, mig); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; !ok && mig.Version <= version {
+				if err := m.apply(ctx, conn, mig); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (m *Migrator) find(version int64) int {
+	for i, mig := range m.migrations {
+		if mig.Version == version {
+			return i
+		}
+	}
+	return -1
+}
+
+// withConn runs fn on a dedicated connection with the applied migrations.
+// When fn changes the schema, the connection holds the migration lock and
+// changes are refused on a dirty schema.
+func (m *Migrator) withConn(ctx context.Context, write bool, fn func(*sql.Conn, map[int64]record) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	if write {
+		if err := m.lock(ctx, conn); err != nil {
+			return err
+		}
+		defer m.unlock(ctx, conn)
+	}
+
+	// the reads, e.g. the readiness probes, leave the schema untouched: with
+	// no table yet, all the migrations are pending.
+	if write {
+		if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT NOT NULL PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			applied_at TIMESTAMP NOT NULL,
+			dirty BOOLEAN NOT NULL DEFAULT FALSE
+		)`); err != nil {
+			return fmt.Errorf("failed to create schema_migrations: %v", err)
+		}
+	} else {
+		exists, err := m.tableExists(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fn(conn, map[int64]record{})
+		}
+	}
+
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	if write {
+		for version, rec := range applied {
+			if rec.dirty {
+				return fmt.Errorf("%w: migration %d failed halfway and must be fixed by hand", ErrDirty, version)
+			}
+		}
+	}
+
+	return fn(conn, applied)
+}
+
+// tableExists tells whether the schema_migrations table was created.
+func (m *Migrator) tableExists(ctx context.Context, conn *sql.Conn) (bool, error) {
+	query := "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = 'schema_migrations'"
+	switch m.dialect {
+	case DialectPostgres:
+		query = "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = 'schema_migrations'"
+	case DialectSQLite:
+		query = "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'"
+	}
+
+	var n int
+	if err := conn.QueryRowContext(ctx, query).Scan(&n); err != nil {
+		return false, fmt.Errorf("failed to get applied migrations: %v", err)
+	}
+	return n > 0, nil
+}
+
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]record, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at, dirty FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get applied migrations: %v", err)
+	}
+	defer rows.Close()
+
+	res := map[int64]record{}
+	for rows.Next() {
+		var (
+			version int64
+			rec     record
+		)
+		if err := rows.Scan(&version, &rec.appliedAt, &rec.dirty); err != nil {
+			return nil, fmt.Errorf("failed to get applied migrations: %v", err)
+		}
+		res[version] = rec
+	}
+	return res, rows.Err()
+}
+
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, mig Migration) error {
+	logging.FromContext(ctx).Info("applying migration", "version", mig.Version, "name", mig.Name)
+	return m.exec(ctx, conn, mig, mig.Up,
+		"INSERT INTO schema_migrations (version, name, applied_at, dirty) VALUES (?, ?, ?, TRUE)",
+		[]any{mig.Version, mig.Name, time.Now().UTC()},
+		"UPDATE schema_migrations SET dirty = FALSE WHERE version = ?")
+}
+
+func (m *Migrator) revert(ctx context.Context, conn *sql.Conn, mig Migration) error {
+	logging.FromContext(ctx).Info("reverting migration", "version", mig.Version, "name", mig.Name)
+	return m.exec(ctx, conn, mig, mig.Down,
+		"UPDATE schema_migrations SET dirty = TRUE WHERE version = ?",
+		[]any{mig.Version},
+		"DELETE FROM schema_migrations WHERE version = ?")
+}
+
+// exec runs the script between the before and after bookkeeping statements.
+// The schema is flagged dirty first, so that a script failing halfway on a
+// database without transactional DDL (e.g. MySQL) is not silently retried.
+func (m *Migrator) exec(ctx context.Context, conn *sql.Conn, mig Migration, script string, before string, args []any, after string) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if _, err := tx.ExecContext(ctx, m.rebind(before), args...); err != nil {
+		return fmt.Errorf("failed to record migration %d: %v", mig.Version, err)
+	}
+
+	for _, stmt := range split(script) {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("migration %d_%s failed: %v", mig.Version, mig.Name, err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, m.rebind(after), mig.Version); err != nil {
+		return fmt.Errorf("failed to record migration %d: %v", mig.Version, err)
+	}
+
+	return tx.Commit()
+}
+
+// rebind replaces the ? placeholders with the $n ones of PostgreSQL.
+func (m *Migrator) rebind(query string) string {
+	if m.dialect != DialectPostgres {
+		return query
+	}
+
+	var (
+		b strings.Builder
+		n int
+	)
+	for _, c := range query {
+		if c == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+func (m *Migrator) lock(ctx context.Context, conn *sql.Conn) error {
+	switch m.dialect {
+	case DialectPostgres:
+		return m.lockPostgres(ctx, conn)
+	case DialectSQLite:
+		// SQLite serializes the writers itself.
+		return nil
+	}
+
+	var got sql.NullInt64
+	err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lockName, int(m.lockTimeout.Seconds())).Scan(&got)
+	if err != nil {
+		return fmt.Errorf("failed to lock migrations: %v", err)
+	}
+	if got.Int64 != 1 {
+		return ErrLocked
+	}
+	return nil
+}
+
+// lockPostgres polls the session advisory lock, which cannot wait for a
+// bounded time by itself.
+func (m *Migrator) lockPostgres(ctx context.Context, conn *sql.Conn) error {
+	ctx, cancel := context.WithTimeout(ctx, m.lockTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(lockPollInterval)
+	defer ticker.Stop()
+
+	for {
+		var got bool
+		if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", lockKey).Scan(&got); err != nil {
+			if ctx.Err() != nil {
+				return ErrLocked
+			}
+			return fmt.Errorf("failed to lock migrations: %v", err)
+		}
+		if got {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ErrLocked
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *Migrator) unlock(ctx context.Context, conn *sql.Conn) {
+	var err error
+	switch m.dialect {
+	case DialectMySQL:
+		_, err = conn.ExecContext(context.WithoutCancel(ctx), "SELECT RELEASE_LOCK(?)", lockName)
+	case DialectPostgres:
+		_, err = conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", lockKey)
+	}
+
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to unlock migrations", "error", err.Error())
+	}
+}
+
+// load reads the migrations of the source, sorted by version.
+func load(source fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(source, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %v", err)
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, e := range entries {
+		match := filename.FindStringSubmatch(e.Name())
+		if e.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version: %s", e.Name())
+		}
+
+		script, err := fs.ReadFile(source, e.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %v", e.Name(), err)
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = mig
+		}
+		if mig.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, mig.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			mig.Up = string(script)
+		} else {
+			mig.Down = string(script)
+		}
+	}
+
+	res := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" || mig.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down script", mig.Version, mig.Name)
+		}
+		res = append(res, *mig)
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Version < res[j].Version })
+	return res, nil
+}
+
+// split splits a script into statements, which end with a semicolon at the
+// end of a line. Comment lines are dropped.
+func split(script string) []string {
+	var (
+		res []string
+		cur strings.Builder
+	)
+
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+
+		cur.WriteString(line)
+		cur.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			res = append(res, strings.TrimSpace(cur.String()))
+			cur.Reset()
+		}
+	}
+
+	if rest := strings.TrimSpace(cur.String()); rest != "" {
+		res = append(res, rest)
+	}
+	return res
+}
@@ -0,0 +1,288 @@
+package migrate_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"qore-be/internal/migrate"
+
+	"testing"
+	"testing/fstest"
+
+	_ "github.com/glebarez/go-sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var source = fstest.MapFS{
+	"0001_person.up.sql": {Data: []byte(`
+-- the people.
+CREATE TABLE person (
+	id INTEGER PRIMARY KEY,
+	name TEXT NOT NULL
+);
+CREATE INDEX idx_person_name ON person (name);
+`)},
+	"0001_person.down.sql": {Data: []byte(`DROP TABLE person;`)},
+	"0002_phone.up.sql": {Data: []byte(`
+CREATE TABLE phone (
+	id INTEGER PRIMARY KEY,
+	number TEXT NOT NULL
+);
+`)},
+	"0002_phone.down.sql": {Data: []byte(`DROP TABLE phone;`)},
+	"README.md":           {Data: []byte(`not a migration`)},
+}
+
+func newDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func newMigrator(t *testing.T, db *sql.DB) *migrate.Migrator {
+	m, err := migrate.New(db, migrate.WithDialect(migrate.DialectSQLite), migrate.WithSource(source))
+	require.NoError(t, err)
+	return m
+}
+
+func tables(t *testing.T, db *sql.DB) []string {
+	rows, err := db.Query("SELECT name FROM sqlite_master WHERE type = 'table' AND name IN ('person', 'phone') ORDER BY name")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	res := []string{}
+	for rows.Next() {
+		var name string
+		require.NoError(t, rows.Scan(&name))
+		res = append(res, name)
+	}
+	return res
+}
+
+func TestNew(t *testing.T) {
+	cases := []struct {
+		name    string
+		source  fstest.MapFS
+		dialect string
+		wantErr bool
+	}{
+		{name: "successfully", source: source, dialect: migrate.DialectSQLite},
+		{name: "with unknown dialect", source: source, dialect: "oracle", wantErr: true},
+		{
+			name:    "with missing down script",
+			source:  fstest.MapFS{"0001_person.up.sql": {Data: []byte(`SELECT 1;`)}},
+			dialect: migrate.DialectSQLite,
+			wantErr: true,
+		},
+		{
+			name: "with two names for a version",
+			source: fstest.MapFS{
+				"0001_person.up.sql":   {Data: []byte(`SELECT 1;`)},
+				"0001_people.down.sql": {Data: []byte(`SELECT 1;`)},
+			},
+			dialect: migrate.DialectSQLite,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := migrate.New(newDB(t), migrate.WithDialect(tc.dialect), migrate.WithSource(tc.source))
+			if tc.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, m)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, int64(2), m.Latest())
+		})
+	}
+
+	for _, dialect := range []string{migrate.DialectMySQL, migrate.DialectPostgres, migrate.DialectSQLite} {
+		t.Run("embedded "+dialect+" migrations", func(t *testing.T) {
+			m, err := migrate.New(newDB(t), migrate.WithDialect(dialect))
+			require.NoError(t, err)
+			assert.NotZero(t, m.Latest())
+		})
+	}
+}
+
+func TestMigrator_Embedded(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+
+	m, err := migrate.New(db, migrate.WithDialect(migrate.DialectSQLite))
+	require.NoError(t, err)
+
+	require.NoError(t, m.Up(ctx))
+	assert.Equal(t, []string{"person", "phone"}, tables(t, db))
+
+	require.NoError(t, m.To(ctx, 0))
+	assert.Empty(t, tables(t, db))
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+	m := newMigrator(t, db)
+
+	pending, err := m.Pending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, pending)
+
+	// reading the status does not create the schema_migrations table.
+	var n int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'schema_migrations'").Scan(&n))
+	assert.Zero(t, n)
+
+	// up applies everything, twice is a no-op.
+	require.NoError(t, m.Up(ctx))
+	require.NoError(t, m.Up(ctx))
+	assert.Equal(t, []string{"person", "phone"}, tables(t, db))
+
+	status, err := m.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, status, 2)
+	for _, s := range status {
+		assert.True(t, s.Applied)
+		assert.False(t, s.Dirty)
+		assert.NotNil(t, s.AppliedAt)
+	}
+
+	pending, err = m.Pending(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, pending)
+
+	// down reverts the last one only.
+	require.NoError(t, m.Down(ctx))
+	assert.Equal(t, []string{"person"}, tables(t, db))
+
+	pending, err = m.Pending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, pending)
+
+	// to goes either way.
+	require.NoError(t, m.To(ctx, 2))
+	assert.Equal(t, []string{"person", "phone"}, tables(t, db))
+
+	require.NoError(t, m.To(ctx, 0))
+	assert.Empty(t, tables(t, db))
+
+	assert.ErrorIs(t, m.Down(ctx), migrate.ErrNoMigration)
+	assert.ErrorIs(t, m.To(ctx, 3), migrate.ErrUnknownVersion)
+}
+
+func TestMigrator_Dirty(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+	m := newMigrator(t, db)
+
+	require.NoError(t, m.Up(ctx))
+
+	// a migration which failed halfway on a database without transactional DDL.
+	_, err := db.Exec("UPDATE schema_migrations SET dirty = TRUE WHERE version = 2")
+	require.NoError(t, err)
+
+	status, err := m.Status(ctx)
+	require.NoError(t, err)
+	assert.True(t, status[1].Dirty)
+
+	pending, err := m.Pending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, pending)
+
+	assert.ErrorIs(t, m.Up(ctx), migrate.ErrDirty)
+	assert.ErrorIs(t, m.Down(ctx), migrate.ErrDirty)
+}
+
+func TestMigrator_Failure(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+
+	m, err := migrate.New(db, migrate.WithDialect(migrate.DialectSQLite), migrate.WithSource(fstest.MapFS{
+		"0001_broken.up.sql":   {Data: []byte("CREATE TABLE person (id INTEGER PRIMARY KEY);\nCREATE TABLE person (id INTEGER PRIMARY KEY);")},
+		"0001_broken.down.sql": {Data: []byte(`DROP TABLE person;`)},
+	}))
+	require.NoError(t, err)
+
+	assert.Error(t, m.Up(ctx))
+
+	// SQLite has transactional DDL: nothing is left behind.
+	assert.Empty(t, tables(t, db))
+
+	pending, err := m.Pending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, pending)
+}
+
+func TestMigrator_EmbeddedConstraints(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db")+"?_pragma=foreign_keys(1)")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	m, err := migrate.New(db, migrate.WithDialect(migrate.DialectSQLite))
+	require.NoError(t, err)
+
+	exec := func(query string, args ...any) error {
+		_, err := db.Exec(query, args...)
+		return err
+	}
+	count := func(table string) int {
+		var n int
+		require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM "+table).Scan(&n))
+		return n
+	}
+
+	// the orphans left before the foreign keys are removed.
+	require.NoError(t, m.To(ctx, 4))
+	require.NoError(t, exec("INSERT INTO person_tag (person_id, tag) VALUES (42, 'vip')"))
+	require.NoError(t, m.To(ctx, 5))
+	assert.Zero(t, count("person_tag"))
+
+	// so are the duplicated tags and labels, the last label set kept.
+	require.NoError(t, exec("INSERT INTO person (id, name, age) VALUES (3, 'bart', 10)"))
+	require.NoError(t, exec("INSERT INTO person_tag (person_id, tag) VALUES (3, 'kid'), (3, 'kid')"))
+	require.NoError(t, exec(`INSERT INTO person_label (person_id, "key", value) VALUES (3, 'team', 'blue'), (3, 'team', 'red')`))
+	require.NoError(t, m.Up(ctx))
+	assert.Equal(t, 1, count("person_tag"))
+
+	var team string
+	require.NoError(t, db.QueryRow(`SELECT value FROM person_label WHERE person_id = 3 AND "key" = 'team'`).Scan(&team))
+	assert.Equal(t, "red", team)
+	require.NoError(t, exec("DELETE FROM person WHERE id = 3"))
+
+	require.NoError(t, exec("INSERT INTO person (id, name, age) VALUES (1, 'homer', 39), (2, 'marge', 36)"))
+	require.NoError(t, exec("INSERT INTO address (id, city) VALUES (1, 'springfield')"))
+	require.NoError(t, exec("INSERT INTO address_join (person_id, address_id) VALUES (1, 1)"))
+	require.NoError(t, exec("INSERT INTO phone (person_id, number) VALUES (1, '555-0142')"))
+	require.NoError(t, exec("INSERT INTO person_tag (person_id, tag) VALUES (1, 'vip')"))
+	require.NoError(t, exec(`INSERT INTO person_label (person_id, "key", value) VALUES (1, 'team', 'blue')`))
+	require.NoError(t, exec("INSERT INTO person_attribute (person_id, tenant, name, value) VALUES (1, 'default', 'level', '3')"))
+	require.NoError(t, exec("INSERT INTO person_relation (person_id, related_id, type) VALUES (2, 1, 'spouse')"))
+
+	// a person has an address, a tag and a label once.
+	assert.Error(t, exec("INSERT INTO address_join (person_id, address_id) VALUES (1, 1)"))
+	assert.Error(t, exec("INSERT INTO person_tag (person_id, tag) VALUES (1, 'vip')"))
+	assert.Error(t, exec(`INSERT INTO person_label (person_id, "key", value) VALUES (1, 'team', 'red')`))
+
+	// the rows of an unknown person are refused.
+	for _, query := range []string{
+		"INSERT INTO person_tag (person_id, tag) VALUES (42, 'vip')",
+		`INSERT INTO person_label (person_id, "key", value) VALUES (42, 'team', 'blue')`,
+		"INSERT INTO person_attribute (person_id, tenant, name, value) VALUES (42, 'default', 'level', '3')",
+		"INSERT INTO person_relation (person_id, related_id, type) VALUES (1, 42, 'parent')",
+	} {
+		assert.Error(t, exec(query), query)
+	}
+
+	// and deleted with their person.
+	require.NoError(t, exec("DELETE FROM person WHERE id = 1"))
+	for _, table := range []string{"phone", "address_join", "person_tag", "person_label", "person_attribute", "person_relation"} {
+		assert.Zero(t, count(table), table)
+	}
+
+	require.NoError(t, m.To(ctx, 0))
+}
@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS person_attribute;
+DROP TABLE IF EXISTS attribute_definition;
+DROP TABLE IF EXISTS person_label;
+DROP TABLE IF EXISTS person_tag;
+DROP TABLE IF EXISTS person_relation;
+DROP TABLE IF EXISTS person_merge;
+DROP TABLE IF EXISTS address_join;
+DROP TABLE IF EXISTS address;
+DROP TABLE IF EXISTS phone;
+DROP TABLE IF EXISTS person;
@@ -0,0 +1,91 @@
+-- Baseline schema, as created by the former AutoMigrate. The tables are only
+-- created when missing so that existing databases are adopted as they are.
+
+CREATE TABLE IF NOT EXISTS person (
+    id BIGINT NOT NULL AUTO_INCREMENT,
+    name LONGTEXT,
+    age BIGINT,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS phone (
+    id BIGINT NOT NULL AUTO_INCREMENT,
+    person_id BIGINT,
+    number LONGTEXT,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS address (
+    id BIGINT NOT NULL AUTO_INCREMENT,
+    city LONGTEXT,
+    state LONGTEXT,
+    street1 LONGTEXT,
+    street2 LONGTEXT,
+    zip LONGTEXT,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS address_join (
+    id BIGINT NOT NULL AUTO_INCREMENT,
+    person_id BIGINT,
+    address_id BIGINT,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS person_merge (
+    id BIGINT NOT NULL AUTO_INCREMENT,
+    source_id BIGINT,
+    target_id BIGINT,
+    source LONGTEXT,
+    target LONGTEXT,
+    phone_ids LONGTEXT,
+    address_join_ids LONGTEXT,
+    merged_at DATETIME(3) NULL,
+    unmerged_at DATETIME(3) NULL,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS person_relation (
+    id BIGINT NOT NULL AUTO_INCREMENT,
+    person_id BIGINT,
+    related_id BIGINT,
+    type LONGTEXT,
+    valid_from DATETIME(3) NULL,
+    valid_to DATETIME(3) NULL,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS person_tag (
+    id BIGINT NOT NULL AUTO_INCREMENT,
+    person_id BIGINT,
+    tag LONGTEXT,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS person_label (
+    id BIGINT NOT NULL AUTO_INCREMENT,
+    person_id BIGINT,
+    `key` LONGTEXT,
+    value LONGTEXT,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS attribute_definition (
+    id BIGINT NOT NULL AUTO_INCREMENT,
+    tenant LONGTEXT,
+    name LONGTEXT,
+    type LONGTEXT,
+    required BOOLEAN,
+    enum_values LONGTEXT,
+    regex LONGTEXT,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS person_attribute (
+    id BIGINT NOT NULL AUTO_INCREMENT,
+    person_id BIGINT,
+    tenant LONGTEXT,
+    name LONGTEXT,
+    value LONGTEXT,
+    PRIMARY KEY (id)
+);
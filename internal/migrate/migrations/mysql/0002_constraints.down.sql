@@ -0,0 +1,46 @@
+-- The orphans removed by the up script are not restored.
+
+ALTER TABLE person_attribute
+    DROP INDEX idx_person_attribute_value,
+    DROP INDEX idx_person_attribute_person,
+    MODIFY name LONGTEXT,
+    MODIFY tenant LONGTEXT;
+
+ALTER TABLE attribute_definition
+    DROP INDEX idx_attribute_definition_tenant_name,
+    MODIFY name LONGTEXT,
+    MODIFY tenant LONGTEXT;
+
+ALTER TABLE person_label
+    DROP INDEX idx_person_label_person_key,
+    MODIFY `key` LONGTEXT;
+
+ALTER TABLE person_tag
+    DROP INDEX idx_person_tag_tag,
+    DROP INDEX idx_person_tag_person_tag,
+    MODIFY tag LONGTEXT;
+
+ALTER TABLE person_relation
+    DROP INDEX idx_person_relation_related_id,
+    DROP INDEX idx_person_relation_person_id;
+
+ALTER TABLE person_merge
+    DROP INDEX idx_person_merge_target,
+    DROP INDEX idx_person_merge_source;
+
+ALTER TABLE address_join
+    DROP FOREIGN KEY fk_address_join_address,
+    DROP FOREIGN KEY fk_address_join_person;
+
+ALTER TABLE address_join
+    DROP INDEX idx_address_join_address_id,
+    DROP INDEX idx_address_join_person_address,
+    MODIFY address_id BIGINT,
+    MODIFY person_id BIGINT;
+
+ALTER TABLE phone
+    DROP FOREIGN KEY fk_phone_person;
+
+ALTER TABLE phone
+    DROP INDEX idx_phone_person_id,
+    MODIFY person_id BIGINT;
@@ -0,0 +1,79 @@
+-- Foreign keys, unique constraints and the indexes of the repository lookups.
+--
+-- The orphans are removed first, otherwise the constraints cannot be added.
+-- The tags, labels, attributes and relations of a merged person are kept
+-- while the merge can be reverted, so these tables have no foreign key.
+
+DELETE FROM phone
+WHERE person_id IS NULL OR person_id NOT IN (SELECT id FROM person);
+
+DELETE FROM address_join
+WHERE person_id IS NULL OR address_id IS NULL
+   OR person_id NOT IN (SELECT id FROM person)
+   OR address_id NOT IN (SELECT id FROM address);
+
+-- keep the first of the duplicated joins.
+DELETE j FROM address_join j
+JOIN address_join k ON k.person_id = j.person_id AND k.address_id = j.address_id AND k.id < j.id;
+
+DELETE FROM address
+WHERE id NOT IN (SELECT address_id FROM address_join);
+
+DELETE FROM person_tag
+WHERE person_id NOT IN (SELECT id FROM person)
+  AND person_id NOT IN (SELECT source_id FROM person_merge WHERE unmerged_at IS NULL);
+
+DELETE FROM person_label
+WHERE person_id NOT IN (SELECT id FROM person)
+  AND person_id NOT IN (SELECT source_id FROM person_merge WHERE unmerged_at IS NULL);
+
+DELETE FROM person_attribute
+WHERE person_id NOT IN (SELECT id FROM person)
+  AND person_id NOT IN (SELECT source_id FROM person_merge WHERE unmerged_at IS NULL);
+
+DELETE FROM person_relation
+WHERE (person_id NOT IN (SELECT id FROM person)
+       AND person_id NOT IN (SELECT source_id FROM person_merge WHERE unmerged_at IS NULL))
+   OR (related_id NOT IN (SELECT id FROM person)
+       AND related_id NOT IN (SELECT source_id FROM person_merge WHERE unmerged_at IS NULL));
+
+ALTER TABLE phone
+    MODIFY person_id BIGINT NOT NULL,
+    ADD INDEX idx_phone_person_id (person_id),
+    ADD CONSTRAINT fk_phone_person FOREIGN KEY (person_id) REFERENCES person (id) ON DELETE CASCADE;
+
+ALTER TABLE address_join
+    MODIFY person_id BIGINT NOT NULL,
+    MODIFY address_id BIGINT NOT NULL,
+    ADD UNIQUE INDEX idx_address_join_person_address (person_id, address_id),
+    ADD INDEX idx_address_join_address_id (address_id),
+    ADD CONSTRAINT fk_address_join_person FOREIGN KEY (person_id) REFERENCES person (id) ON DELETE CASCADE,
+    ADD CONSTRAINT fk_address_join_address FOREIGN KEY (address_id) REFERENCES address (id) ON DELETE CASCADE;
+
+ALTER TABLE person_merge
+    ADD INDEX idx_person_merge_source (source_id, unmerged_at),
+    ADD INDEX idx_person_merge_target (target_id, source_id);
+
+ALTER TABLE person_relation
+    ADD INDEX idx_person_relation_person_id (person_id),
+    ADD INDEX idx_person_relation_related_id (related_id);
+
+ALTER TABLE person_tag
+    MODIFY tag VARCHAR(255),
+    ADD INDEX idx_person_tag_person_tag (person_id, tag),
+    ADD INDEX idx_person_tag_tag (tag);
+
+ALTER TABLE person_label
+    MODIFY `key` VARCHAR(255),
+    ADD INDEX idx_person_label_person_key (person_id, `key`);
+
+ALTER TABLE attribute_definition
+    MODIFY tenant VARCHAR(255),
+    MODIFY name VARCHAR(255),
+    ADD INDEX idx_attribute_definition_tenant_name (tenant, name);
+
+ALTER TABLE person_attribute
+    MODIFY tenant VARCHAR(255),
+    MODIFY name VARCHAR(255),
+    ADD INDEX idx_person_attribute_person (person_id, tenant, name),
+    ADD INDEX idx_person_attribute_value (tenant, name, value(255));
@@ -0,0 +1,10 @@
+-- The tags, labels, custom attributes and relationships moved or deleted by
+-- a merge, restored by the unmerge.
+
+ALTER TABLE person_merge
+    ADD COLUMN tag_ids LONGTEXT,
+    ADD COLUMN label_ids LONGTEXT,
+    ADD COLUMN attribute_ids LONGTEXT,
+    ADD COLUMN relation_ids LONGTEXT,
+    ADD COLUMN related_ids LONGTEXT,
+    ADD COLUMN dropped LONGTEXT;
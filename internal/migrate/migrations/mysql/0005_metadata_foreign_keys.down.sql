@@ -0,0 +1,27 @@
+-- The orphans removed by the up script are not restored.
+
+ALTER TABLE person_relation
+    DROP FOREIGN KEY fk_person_relation_related,
+    DROP FOREIGN KEY fk_person_relation_person;
+
+ALTER TABLE person_relation
+    MODIFY related_id BIGINT,
+    MODIFY person_id BIGINT;
+
+ALTER TABLE person_attribute
+    DROP FOREIGN KEY fk_person_attribute_person;
+
+ALTER TABLE person_attribute
+    MODIFY person_id BIGINT;
+
+ALTER TABLE person_label
+    DROP FOREIGN KEY fk_person_label_person;
+
+ALTER TABLE person_label
+    MODIFY person_id BIGINT;
+
+ALTER TABLE person_tag
+    DROP FOREIGN KEY fk_person_tag_person;
+
+ALTER TABLE person_tag
+    MODIFY person_id BIGINT;
@@ -0,0 +1,9 @@
+-- The duplicates removed by the up script are not restored.
+
+ALTER TABLE person_label
+    DROP INDEX idx_person_label_person_key,
+    ADD INDEX idx_person_label_person_key (person_id, `key`);
+
+ALTER TABLE person_tag
+    DROP INDEX idx_person_tag_person_tag,
+    ADD INDEX idx_person_tag_person_tag (person_id, tag);
@@ -0,0 +1,20 @@
+-- Unique tags and labels of a person, so that concurrent writes cannot
+-- store one twice: the tags are inserted unless present and the labels are
+-- upserted.
+--
+-- The duplicates are removed first, keeping the first tag and the last
+-- label set.
+
+DELETE t FROM person_tag t
+JOIN person_tag k ON k.person_id = t.person_id AND k.tag = t.tag AND k.id < t.id;
+
+DELETE l FROM person_label l
+JOIN person_label k ON k.person_id = l.person_id AND k.`key` = l.`key` AND k.id > l.id;
+
+ALTER TABLE person_tag
+    DROP INDEX idx_person_tag_person_tag,
+    ADD UNIQUE INDEX idx_person_tag_person_tag (person_id, tag);
+
+ALTER TABLE person_label
+    DROP INDEX idx_person_label_person_key,
+    ADD UNIQUE INDEX idx_person_label_person_key (person_id, `key`);
@@ -0,0 +1,80 @@
+-- Baseline schema, the same as the MySQL one.
+
+CREATE TABLE IF NOT EXISTS person (
+    id BIGSERIAL PRIMARY KEY,
+    name TEXT,
+    age BIGINT
+);
+
+CREATE TABLE IF NOT EXISTS phone (
+    id BIGSERIAL PRIMARY KEY,
+    person_id BIGINT,
+    number TEXT
+);
+
+CREATE TABLE IF NOT EXISTS address (
+    id BIGSERIAL PRIMARY KEY,
+    city TEXT,
+    state TEXT,
+    street1 TEXT,
+    street2 TEXT,
+    zip TEXT
+);
+
+CREATE TABLE IF NOT EXISTS address_join (
+    id BIGSERIAL PRIMARY KEY,
+    person_id BIGINT,
+    address_id BIGINT
+);
+
+CREATE TABLE IF NOT EXISTS person_merge (
+    id BIGSERIAL PRIMARY KEY,
+    source_id BIGINT,
+    target_id BIGINT,
+    source TEXT,
+    target TEXT,
+    phone_ids TEXT,
+    address_join_ids TEXT,
+    merged_at TIMESTAMPTZ,
+    unmerged_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS person_relation (
+    id BIGSERIAL PRIMARY KEY,
+    person_id BIGINT,
+    related_id BIGINT,
+    type TEXT,
+    valid_from TIMESTAMPTZ,
+    valid_to TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS person_tag (
+    id BIGSERIAL PRIMARY KEY,
+    person_id BIGINT,
+    tag TEXT
+);
+
+CREATE TABLE IF NOT EXISTS person_label (
+    id BIGSERIAL PRIMARY KEY,
+    person_id BIGINT,
+    key TEXT,
+    value TEXT
+);
+
+CREATE TABLE IF NOT EXISTS attribute_definition (
+    id BIGSERIAL PRIMARY KEY,
+    tenant TEXT,
+    name TEXT,
+    type TEXT,
+    required BOOLEAN,
+    enum_values TEXT,
+    regex TEXT
+);
+
+CREATE TABLE IF NOT EXISTS person_attribute (
+    id BIGSERIAL PRIMARY KEY,
+    person_id BIGINT,
+    tenant TEXT,
+    name TEXT,
+    value TEXT
+);
@@ -0,0 +1,25 @@
+-- The orphans removed by the up script are not restored.
+
+DROP INDEX idx_person_attribute_value;
+DROP INDEX idx_person_attribute_person;
+DROP INDEX idx_attribute_definition_tenant_name;
+DROP INDEX idx_person_label_person_key;
+DROP INDEX idx_person_tag_tag;
+DROP INDEX idx_person_tag_person_tag;
+DROP INDEX idx_person_relation_related_id;
+DROP INDEX idx_person_relation_person_id;
+DROP INDEX idx_person_merge_target;
+DROP INDEX idx_person_merge_source;
+
+DROP INDEX idx_address_join_address_id;
+DROP INDEX idx_address_join_person_address;
+ALTER TABLE address_join
+    DROP CONSTRAINT fk_address_join_address,
+    DROP CONSTRAINT fk_address_join_person,
+    ALTER COLUMN address_id DROP NOT NULL,
+    ALTER COLUMN person_id DROP NOT NULL;
+
+DROP INDEX idx_phone_person_id;
+ALTER TABLE phone
+    DROP CONSTRAINT fk_phone_person,
+    ALTER COLUMN person_id DROP NOT NULL;
@@ -0,0 +1,69 @@
+-- Foreign keys, unique constraints and the indexes of the repository lookups.
+--
+-- The orphans are removed first, otherwise the constraints cannot be added.
+-- The tags, labels, attributes and relations of a merged person are kept
+-- while the merge can be reverted, so these tables have no foreign key.
+
+DELETE FROM phone
+WHERE person_id IS NULL OR person_id NOT IN (SELECT id FROM person);
+
+DELETE FROM address_join
+WHERE person_id IS NULL OR address_id IS NULL
+   OR person_id NOT IN (SELECT id FROM person)
+   OR address_id NOT IN (SELECT id FROM address);
+
+-- keep the first of the duplicated joins.
+DELETE FROM address_join j
+USING address_join k
+WHERE k.person_id = j.person_id AND k.address_id = j.address_id AND k.id < j.id;
+
+DELETE FROM address
+WHERE id NOT IN (SELECT address_id FROM address_join);
+
+DELETE FROM person_tag
+WHERE person_id NOT IN (SELECT id FROM person)
+  AND person_id NOT IN (SELECT source_id FROM person_merge WHERE unmerged_at IS NULL);
+
+DELETE FROM person_label
+WHERE person_id NOT IN (SELECT id FROM person)
+  AND person_id NOT IN (SELECT source_id FROM person_merge WHERE unmerged_at IS NULL);
+
+DELETE FROM person_attribute
+WHERE person_id NOT IN (SELECT id FROM person)
+  AND person_id NOT IN (SELECT source_id FROM person_merge WHERE unmerged_at IS NULL);
+
+DELETE FROM person_relation
+WHERE (person_id NOT IN (SELECT id FROM person)
+       AND person_id NOT IN (SELECT source_id FROM person_merge WHERE unmerged_at IS NULL))
+   OR (related_id NOT IN (SELECT id FROM person)
+       AND related_id NOT IN (SELECT source_id FROM person_merge WHERE unmerged_at IS NULL));
+
+ALTER TABLE phone
+    ALTER COLUMN person_id SET NOT NULL,
+    ADD CONSTRAINT fk_phone_person FOREIGN KEY (person_id) REFERENCES person (id) ON DELETE CASCADE;
+CREATE INDEX idx_phone_person_id ON phone (person_id);
+
+ALTER TABLE address_join
+    ALTER COLUMN person_id SET NOT NULL,
+    ALTER COLUMN address_id SET NOT NULL,
+    ADD CONSTRAINT fk_address_join_person FOREIGN KEY (person_id) REFERENCES person (id) ON DELETE CASCADE,
+    ADD CONSTRAINT fk_address_join_address FOREIGN KEY (address_id) REFERENCES address (id) ON DELETE CASCADE;
+CREATE UNIQUE INDEX idx_address_join_person_address ON address_join (person_id, address_id);
+CREATE INDEX idx_address_join_address_id ON address_join (address_id);
+
+CREATE INDEX idx_person_merge_source ON person_merge (source_id, unmerged_at);
+CREATE INDEX idx_person_merge_target ON person_merge (target_id, source_id);
+
+CREATE INDEX idx_person_relation_person_id ON person_relation (person_id);
+CREATE INDEX idx_person_relation_related_id ON person_relation (related_id);
+
+CREATE INDEX idx_person_tag_person_tag ON person_tag (person_id, tag);
+CREATE INDEX idx_person_tag_tag ON person_tag (tag);
+
+CREATE INDEX idx_person_label_person_key ON person_label (person_id, key);
+
+CREATE INDEX idx_attribute_definition_tenant_name ON attribute_definition (tenant, name);
+
+CREATE INDEX idx_person_attribute_person ON person_attribute (person_id, tenant, name);
+-- the values are left out of the index, whose rows are limited in size.
+CREATE INDEX idx_person_attribute_value ON person_attribute (tenant, name);
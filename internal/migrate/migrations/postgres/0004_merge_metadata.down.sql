@@ -0,0 +1,7 @@
+ALTER TABLE person_merge
+    DROP COLUMN dropped,
+    DROP COLUMN related_ids,
+    DROP COLUMN relation_ids,
+    DROP COLUMN attribute_ids,
+    DROP COLUMN label_ids,
+    DROP COLUMN tag_ids;
@@ -0,0 +1,10 @@
+-- The tags, labels, custom attributes and relationships moved or deleted by
+-- a merge, restored by the unmerge.
+
+ALTER TABLE person_merge
+    ADD COLUMN tag_ids TEXT,
+    ADD COLUMN label_ids TEXT,
+    ADD COLUMN attribute_ids TEXT,
+    ADD COLUMN relation_ids TEXT,
+    ADD COLUMN related_ids TEXT,
+    ADD COLUMN dropped TEXT;
@@ -0,0 +1,19 @@
+-- The orphans removed by the up script are not restored.
+
+ALTER TABLE person_relation
+    DROP CONSTRAINT fk_person_relation_related,
+    DROP CONSTRAINT fk_person_relation_person,
+    ALTER COLUMN related_id DROP NOT NULL,
+    ALTER COLUMN person_id DROP NOT NULL;
+
+ALTER TABLE person_attribute
+    DROP CONSTRAINT fk_person_attribute_person,
+    ALTER COLUMN person_id DROP NOT NULL;
+
+ALTER TABLE person_label
+    DROP CONSTRAINT fk_person_label_person,
+    ALTER COLUMN person_id DROP NOT NULL;
+
+ALTER TABLE person_tag
+    DROP CONSTRAINT fk_person_tag_person,
+    ALTER COLUMN person_id DROP NOT NULL;
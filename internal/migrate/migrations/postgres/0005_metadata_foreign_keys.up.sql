@@ -0,0 +1,37 @@
+-- Foreign keys of the tags, labels, custom attributes and relationships of
+-- the persons, deleted with them.
+--
+-- A merge now moves them to the target person, so that the merged person
+-- leaves none behind. The orphans are removed first, including those left
+-- by the merges made before: unmerging these persons does not restore them.
+
+DELETE FROM person_tag
+WHERE person_id IS NULL OR person_id NOT IN (SELECT id FROM person);
+
+DELETE FROM person_label
+WHERE person_id IS NULL OR person_id NOT IN (SELECT id FROM person);
+
+DELETE FROM person_attribute
+WHERE person_id IS NULL OR person_id NOT IN (SELECT id FROM person);
+
+DELETE FROM person_relation
+WHERE person_id IS NULL OR person_id NOT IN (SELECT id FROM person)
+   OR related_id IS NULL OR related_id NOT IN (SELECT id FROM person);
+
+ALTER TABLE person_tag
+    ALTER COLUMN person_id SET NOT NULL,
+    ADD CONSTRAINT fk_person_tag_person FOREIGN KEY (person_id) REFERENCES person (id) ON DELETE CASCADE;
+
+ALTER TABLE person_label
+    ALTER COLUMN person_id SET NOT NULL,
+    ADD CONSTRAINT fk_person_label_person FOREIGN KEY (person_id) REFERENCES person (id) ON DELETE CASCADE;
+
+ALTER TABLE person_attribute
+    ALTER COLUMN person_id SET NOT NULL,
+    ADD CONSTRAINT fk_person_attribute_person FOREIGN KEY (person_id) REFERENCES person (id) ON DELETE CASCADE;
+
+ALTER TABLE person_relation
+    ALTER COLUMN person_id SET NOT NULL,
+    ALTER COLUMN related_id SET NOT NULL,
+    ADD CONSTRAINT fk_person_relation_person FOREIGN KEY (person_id) REFERENCES person (id) ON DELETE CASCADE,
+    ADD CONSTRAINT fk_person_relation_related FOREIGN KEY (related_id) REFERENCES person (id) ON DELETE CASCADE;
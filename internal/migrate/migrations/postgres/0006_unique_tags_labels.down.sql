@@ -0,0 +1,7 @@
+-- The duplicates removed by the up script are not restored.
+
+DROP INDEX idx_person_label_person_key;
+CREATE INDEX idx_person_label_person_key ON person_label (person_id, key);
+
+DROP INDEX idx_person_tag_person_tag;
+CREATE INDEX idx_person_tag_person_tag ON person_tag (person_id, tag);
@@ -0,0 +1,20 @@
+-- Unique tags and labels of a person, so that concurrent writes cannot
+-- store one twice: the tags are inserted unless present and the labels are
+-- upserted.
+--
+-- The duplicates are removed first, keeping the first tag and the last
+-- label set.
+
+DELETE FROM person_tag t
+USING person_tag k
+WHERE k.person_id = t.person_id AND k.tag = t.tag AND k.id < t.id;
+
+DELETE FROM person_label l
+USING person_label k
+WHERE k.person_id = l.person_id AND k.key = l.key AND k.id > l.id;
+
+DROP INDEX idx_person_tag_person_tag;
+CREATE UNIQUE INDEX idx_person_tag_person_tag ON person_tag (person_id, tag);
+
+DROP INDEX idx_person_label_person_key;
+CREATE UNIQUE INDEX idx_person_label_person_key ON person_label (person_id, key);
@@ -0,0 +1,80 @@
+-- Baseline schema, the same as the MySQL one.
+
+CREATE TABLE IF NOT EXISTS person (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT,
+    age BIGINT
+);
+
+CREATE TABLE IF NOT EXISTS phone (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    person_id BIGINT,
+    number TEXT
+);
+
+CREATE TABLE IF NOT EXISTS address (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    city TEXT,
+    state TEXT,
+    street1 TEXT,
+    street2 TEXT,
+    zip TEXT
+);
+
+CREATE TABLE IF NOT EXISTS address_join (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    person_id BIGINT,
+    address_id BIGINT
+);
+
+CREATE TABLE IF NOT EXISTS person_merge (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    source_id BIGINT,
+    target_id BIGINT,
+    source TEXT,
+    target TEXT,
+    phone_ids TEXT,
+    address_join_ids TEXT,
+    merged_at DATETIME,
+    unmerged_at DATETIME
+);
+
+CREATE TABLE IF NOT EXISTS person_relation (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    person_id BIGINT,
+    related_id BIGINT,
+    type TEXT,
+    valid_from DATETIME,
+    valid_to DATETIME
+);
+
+CREATE TABLE IF NOT EXISTS person_tag (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    person_id BIGINT,
+    tag TEXT
+);
+
+CREATE TABLE IF NOT EXISTS person_label (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    person_id BIGINT,
+    "key" TEXT,
+    value TEXT
+);
+
+CREATE TABLE IF NOT EXISTS attribute_definition (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    tenant TEXT,
+    name TEXT,
+    type TEXT,
+    required BOOLEAN,
+    enum_values TEXT,
+    regex TEXT
+);
+
+CREATE TABLE IF NOT EXISTS person_attribute (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    person_id BIGINT,
+    tenant TEXT,
+    name TEXT,
+    value TEXT
+);
@@ -0,0 +1,30 @@
+-- The orphans removed by the up script are not restored.
+
+DROP INDEX idx_person_attribute_value;
+DROP INDEX idx_person_attribute_person;
+DROP INDEX idx_attribute_definition_tenant_name;
+DROP INDEX idx_person_label_person_key;
+DROP INDEX idx_person_tag_tag;
+DROP INDEX idx_person_tag_person_tag;
+DROP INDEX idx_person_relation_related_id;
+DROP INDEX idx_person_relation_person_id;
+DROP INDEX idx_person_merge_target;
+DROP INDEX idx_person_merge_source;
+
+CREATE TABLE address_join_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    person_id BIGINT,
+    address_id BIGINT
+);
+INSERT INTO address_join_old (id, person_id, address_id) SELECT id, person_id, address_id FROM address_join;
+DROP TABLE address_join;
+ALTER TABLE address_join_old RENAME TO address_join;
+
+CREATE TABLE phone_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    person_id BIGINT,
+    number TEXT
+);
+INSERT INTO phone_old (id, person_id, number) SELECT id, person_id, number FROM phone;
+DROP TABLE phone;
+ALTER TABLE phone_old RENAME TO phone;
@@ -0,0 +1,81 @@
+-- Foreign keys, unique constraints and the indexes of the repository lookups.
+--
+-- The orphans are removed first, otherwise the constraints cannot be added.
+-- The tags, labels, attributes and relations of a merged person are kept
+-- while the merge can be reverted, so these tables have no foreign key.
+--
+-- SQLite cannot add a constraint to a table: phone and address_join are
+-- rebuilt.
+
+DELETE FROM phone
+WHERE person_id IS NULL OR person_id NOT IN (SELECT id FROM person);
+
+DELETE FROM address_join
+WHERE person_id IS NULL OR address_id IS NULL
+   OR person_id NOT IN (SELECT id FROM person)
+   OR address_id NOT IN (SELECT id FROM address);
+
+-- keep the first of the duplicated joins.
+DELETE FROM address_join
+WHERE EXISTS (
+    SELECT 1 FROM address_join k
+    WHERE k.person_id = address_join.person_id AND k.address_id = address_join.address_id AND k.id < address_join.id
+);
+
+DELETE FROM address
+WHERE id NOT IN (SELECT address_id FROM address_join);
+
+DELETE FROM person_tag
+WHERE person_id NOT IN (SELECT id FROM person)
+  AND person_id NOT IN (SELECT source_id FROM person_merge WHERE unmerged_at IS NULL);
+
+DELETE FROM person_label
+WHERE person_id NOT IN (SELECT id FROM person)
+  AND person_id NOT IN (SELECT source_id FROM person_merge WHERE unmerged_at IS NULL);
+
+DELETE FROM person_attribute
+WHERE person_id NOT IN (SELECT id FROM person)
+  AND person_id NOT IN (SELECT source_id FROM person_merge WHERE unmerged_at IS NULL);
+
+DELETE FROM person_relation
+WHERE (person_id NOT IN (SELECT id FROM person)
+       AND person_id NOT IN (SELECT source_id FROM person_merge WHERE unmerged_at IS NULL))
+   OR (related_id NOT IN (SELECT id FROM person)
+       AND related_id NOT IN (SELECT source_id FROM person_merge WHERE unmerged_at IS NULL));
+
+CREATE TABLE phone_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    person_id BIGINT NOT NULL REFERENCES person (id) ON DELETE CASCADE,
+    number TEXT
+);
+INSERT INTO phone_new (id, person_id, number) SELECT id, person_id, number FROM phone;
+DROP TABLE phone;
+ALTER TABLE phone_new RENAME TO phone;
+CREATE INDEX idx_phone_person_id ON phone (person_id);
+
+CREATE TABLE address_join_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    person_id BIGINT NOT NULL REFERENCES person (id) ON DELETE CASCADE,
+    address_id BIGINT NOT NULL REFERENCES address (id) ON DELETE CASCADE
+);
+INSERT INTO address_join_new (id, person_id, address_id) SELECT id, person_id, address_id FROM address_join;
+DROP TABLE address_join;
+ALTER TABLE address_join_new RENAME TO address_join;
+CREATE UNIQUE INDEX idx_address_join_person_address ON address_join (person_id, address_id);
+CREATE INDEX idx_address_join_address_id ON address_join (address_id);
+
+CREATE INDEX idx_person_merge_source ON person_merge (source_id, unmerged_at);
+CREATE INDEX idx_person_merge_target ON person_merge (target_id, source_id);
+
+CREATE INDEX idx_person_relation_person_id ON person_relation (person_id);
+CREATE INDEX idx_person_relation_related_id ON person_relation (related_id);
+
+CREATE INDEX idx_person_tag_person_tag ON person_tag (person_id, tag);
+CREATE INDEX idx_person_tag_tag ON person_tag (tag);
+
+CREATE INDEX idx_person_label_person_key ON person_label (person_id, "key");
+
+CREATE INDEX idx_attribute_definition_tenant_name ON attribute_definition (tenant, name);
+
+CREATE INDEX idx_person_attribute_person ON person_attribute (person_id, tenant, name);
+CREATE INDEX idx_person_attribute_value ON person_attribute (tenant, name, value);
@@ -0,0 +1,10 @@
+-- The blocking keys of the persons (see dedup.BlockingKeys), looked up to
+-- find the possible duplicates of a person without scanning the table. The
+-- keys of the existing persons are indexed by the application at start.
+
+CREATE TABLE person_blocking_key (
+    person_id BIGINT NOT NULL REFERENCES person (id) ON DELETE CASCADE,
+    blocking_key VARCHAR(64) NOT NULL,
+    PRIMARY KEY (person_id, blocking_key)
+);
+CREATE INDEX idx_person_blocking_key_key ON person_blocking_key (blocking_key);
@@ -0,0 +1,6 @@
+ALTER TABLE person_merge DROP COLUMN dropped;
+ALTER TABLE person_merge DROP COLUMN related_ids;
+ALTER TABLE person_merge DROP COLUMN relation_ids;
+ALTER TABLE person_merge DROP COLUMN attribute_ids;
+ALTER TABLE person_merge DROP COLUMN label_ids;
+ALTER TABLE person_merge DROP COLUMN tag_ids;
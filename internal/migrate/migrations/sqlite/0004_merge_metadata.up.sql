@@ -0,0 +1,9 @@
+-- The tags, labels, custom attributes and relationships moved or deleted by
+-- a merge, restored by the unmerge.
+
+ALTER TABLE person_merge ADD COLUMN tag_ids TEXT;
+ALTER TABLE person_merge ADD COLUMN label_ids TEXT;
+ALTER TABLE person_merge ADD COLUMN attribute_ids TEXT;
+ALTER TABLE person_merge ADD COLUMN relation_ids TEXT;
+ALTER TABLE person_merge ADD COLUMN related_ids TEXT;
+ALTER TABLE person_merge ADD COLUMN dropped TEXT;
@@ -0,0 +1,51 @@
+-- The orphans removed by the up script are not restored.
+
+CREATE TABLE person_tag_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    person_id BIGINT,
+    tag TEXT
+);
+INSERT INTO person_tag_old (id, person_id, tag) SELECT id, person_id, tag FROM person_tag;
+DROP TABLE person_tag;
+ALTER TABLE person_tag_old RENAME TO person_tag;
+CREATE INDEX idx_person_tag_person_tag ON person_tag (person_id, tag);
+CREATE INDEX idx_person_tag_tag ON person_tag (tag);
+
+CREATE TABLE person_label_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    person_id BIGINT,
+    "key" TEXT,
+    value TEXT
+);
+INSERT INTO person_label_old (id, person_id, "key", value) SELECT id, person_id, "key", value FROM person_label;
+DROP TABLE person_label;
+ALTER TABLE person_label_old RENAME TO person_label;
+CREATE INDEX idx_person_label_person_key ON person_label (person_id, "key");
+
+CREATE TABLE person_attribute_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    person_id BIGINT,
+    tenant TEXT,
+    name TEXT,
+    value TEXT
+);
+INSERT INTO person_attribute_old (id, person_id, tenant, name, value) SELECT id, person_id, tenant, name, value FROM person_attribute;
+DROP TABLE person_attribute;
+ALTER TABLE person_attribute_old RENAME TO person_attribute;
+CREATE INDEX idx_person_attribute_person ON person_attribute (person_id, tenant, name);
+CREATE INDEX idx_person_attribute_value ON person_attribute (tenant, name, value);
+
+CREATE TABLE person_relation_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    person_id BIGINT,
+    related_id BIGINT,
+    type TEXT,
+    valid_from DATETIME,
+    valid_to DATETIME
+);
+INSERT INTO person_relation_old (id, person_id, related_id, type, valid_from, valid_to)
+SELECT id, person_id, related_id, type, valid_from, valid_to FROM person_relation;
+DROP TABLE person_relation;
+ALTER TABLE person_relation_old RENAME TO person_relation;
+CREATE INDEX idx_person_relation_person_id ON person_relation (person_id);
+CREATE INDEX idx_person_relation_related_id ON person_relation (related_id);
@@ -0,0 +1,71 @@
+-- Foreign keys of the tags, labels, custom attributes and relationships of
+-- the persons, deleted with them.
+--
+-- A merge now moves them to the target person, so that the merged person
+-- leaves none behind. The orphans are removed first, including those left
+-- by the merges made before: unmerging these persons does not restore them.
+--
+-- SQLite cannot add a constraint to a table: the tables are rebuilt.
+
+DELETE FROM person_tag
+WHERE person_id IS NULL OR person_id NOT IN (SELECT id FROM person);
+
+DELETE FROM person_label
+WHERE person_id IS NULL OR person_id NOT IN (SELECT id FROM person);
+
+DELETE FROM person_attribute
+WHERE person_id IS NULL OR person_id NOT IN (SELECT id FROM person);
+
+DELETE FROM person_relation
+WHERE person_id IS NULL OR person_id NOT IN (SELECT id FROM person)
+   OR related_id IS NULL OR related_id NOT IN (SELECT id FROM person);
+
+CREATE TABLE person_tag_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    person_id BIGINT NOT NULL REFERENCES person (id) ON DELETE CASCADE,
+    tag TEXT
+);
+INSERT INTO person_tag_new (id, person_id, tag) SELECT id, person_id, tag FROM person_tag;
+DROP TABLE person_tag;
+ALTER TABLE person_tag_new RENAME TO person_tag;
+CREATE INDEX idx_person_tag_person_tag ON person_tag (person_id, tag);
+CREATE INDEX idx_person_tag_tag ON person_tag (tag);
+
+CREATE TABLE person_label_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    person_id BIGINT NOT NULL REFERENCES person (id) ON DELETE CASCADE,
+    "key" TEXT,
+    value TEXT
+);
+INSERT INTO person_label_new (id, person_id, "key", value) SELECT id, person_id, "key", value FROM person_label;
+DROP TABLE person_label;
+ALTER TABLE person_label_new RENAME TO person_label;
+CREATE INDEX idx_person_label_person_key ON person_label (person_id, "key");
+
+CREATE TABLE person_attribute_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    person_id BIGINT NOT NULL REFERENCES person (id) ON DELETE CASCADE,
+    tenant TEXT,
+    name TEXT,
+    value TEXT
+);
+INSERT INTO person_attribute_new (id, person_id, tenant, name, value) SELECT id, person_id, tenant, name, value FROM person_attribute;
+DROP TABLE person_attribute;
+ALTER TABLE person_attribute_new RENAME TO person_attribute;
+CREATE INDEX idx_person_attribute_person ON person_attribute (person_id, tenant, name);
+CREATE INDEX idx_person_attribute_value ON person_attribute (tenant, name, value);
+
+CREATE TABLE person_relation_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    person_id BIGINT NOT NULL REFERENCES person (id) ON DELETE CASCADE,
+    related_id BIGINT NOT NULL REFERENCES person (id) ON DELETE CASCADE,
+    type TEXT,
+    valid_from DATETIME,
+    valid_to DATETIME
+);
+INSERT INTO person_relation_new (id, person_id, related_id, type, valid_from, valid_to)
+SELECT id, person_id, related_id, type, valid_from, valid_to FROM person_relation;
+DROP TABLE person_relation;
+ALTER TABLE person_relation_new RENAME TO person_relation;
+CREATE INDEX idx_person_relation_person_id ON person_relation (person_id);
+CREATE INDEX idx_person_relation_related_id ON person_relation (related_id);
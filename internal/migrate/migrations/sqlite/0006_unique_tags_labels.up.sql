@@ -0,0 +1,24 @@
+-- Unique tags and labels of a person, so that concurrent writes cannot
+-- store one twice: the tags are inserted unless present and the labels are
+-- upserted.
+--
+-- The duplicates are removed first, keeping the first tag and the last
+-- label set.
+
+DELETE FROM person_tag
+WHERE EXISTS (
+    SELECT 1 FROM person_tag k
+    WHERE k.person_id = person_tag.person_id AND k.tag = person_tag.tag AND k.id < person_tag.id
+);
+
+DELETE FROM person_label
+WHERE EXISTS (
+    SELECT 1 FROM person_label k
+    WHERE k.person_id = person_label.person_id AND k."key" = person_label."key" AND k.id > person_label.id
+);
+
+DROP INDEX idx_person_tag_person_tag;
+CREATE UNIQUE INDEX idx_person_tag_person_tag ON person_tag (person_id, tag);
+
+DROP INDEX idx_person_label_person_key;
+CREATE UNIQUE INDEX idx_person_label_person_key ON person_label (person_id, "key");
@@ -7,6 +7,8 @@ import (
 	dto "qore-be/internal/domain/dto"
 
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // PersonRepository is an autogenerated mock type for the Repository type
@@ -38,24 +40,24 @@ func (_m *PersonRepository) Add(_a0 context.Context, _a1 dto.PersonDTO) (dto.Per
 	return r0, r1
 }
 
-// GetAll provides a mock function with given fields: _a0, _a1, _a2
-func (_m *PersonRepository) GetAll(_a0 context.Context, _a1 int, _a2 int) ([]dto.PersonDTO, error) {
+// AddTags provides a mock function with given fields: _a0, _a1, _a2
+func (_m *PersonRepository) AddTags(_a0 context.Context, _a1 int, _a2 []string) ([]string, error) {
 	ret := _m.Called(_a0, _a1, _a2)
 
-	var r0 []dto.PersonDTO
+	var r0 []string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int, int) ([]dto.PersonDTO, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int, []string) ([]string, error)); ok {
 		return rf(_a0, _a1, _a2)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int, int) []dto.PersonDTO); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int, []string) []string); ok {
 		r0 = rf(_a0, _a1, _a2)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]dto.PersonDTO)
+			r0 = ret.Get(0).([]string)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, int, []string) error); ok {
 		r1 = rf(_a0, _a1, _a2)
 	} else {
 		r1 = ret.Error(1)
@@ -64,6 +66,58 @@ func (_m *PersonRepository) GetAll(_a0 context.Context, _a1 int, _a2 int) ([]dto
 	return r0, r1
 }
 
+// GetAll provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *PersonRepository) GetAll(_a0 context.Context, _a1 int, _a2 int, _a3 dto.PersonFilter) ([]dto.PersonDTO, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 []dto.PersonDTO
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, dto.PersonFilter) ([]dto.PersonDTO, error)); ok {
+		return rf(_a0, _a1, _a2, _a3)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, dto.PersonFilter) []dto.PersonDTO); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]dto.PersonDTO)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, dto.PersonFilter) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBlocks provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *PersonRepository) GetBlocks(_a0 context.Context, _a1 int, _a2 int, _a3 int) ([][]dto.PersonDTO, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 [][]dto.PersonDTO
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) ([][]dto.PersonDTO, error)); ok {
+		return rf(_a0, _a1, _a2, _a3)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) [][]dto.PersonDTO); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([][]dto.PersonDTO)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, int) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetByID provides a mock function with given fields: _a0, _a1
 func (_m *PersonRepository) GetByID(_a0 context.Context, _a1 int) (dto.PersonDTO, error) {
 	ret := _m.Called(_a0, _a1)
@@ -88,6 +142,186 @@ func (_m *PersonRepository) GetByID(_a0 context.Context, _a1 int) (dto.PersonDTO
 	return r0, r1
 }
 
+// GetCandidates provides a mock function with given fields: _a0, _a1, _a2
+func (_m *PersonRepository) GetCandidates(_a0 context.Context, _a1 []string, _a2 int) ([]dto.PersonDTO, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 []dto.PersonDTO
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string, int) ([]dto.PersonDTO, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []string, int) []dto.PersonDTO); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]dto.PersonDTO)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []string, int) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTagCounts provides a mock function with given fields: _a0
+func (_m *PersonRepository) GetTagCounts(_a0 context.Context) ([]dto.TagCountDTO, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []dto.TagCountDTO
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]dto.TagCountDTO, error)); ok {
+		return rf(_a0)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []dto.TagCountDTO); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]dto.TagCountDTO)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Merge provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *PersonRepository) Merge(_a0 context.Context, _a1 int, _a2 int, _a3 dto.PersonDTO) (dto.PersonDTO, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 dto.PersonDTO
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, dto.PersonDTO) (dto.PersonDTO, error)); ok {
+		return rf(_a0, _a1, _a2, _a3)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, dto.PersonDTO) dto.PersonDTO); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r0 = ret.Get(0).(dto.PersonDTO)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, dto.PersonDTO) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveLabel provides a mock function with given fields: _a0, _a1, _a2
+func (_m *PersonRepository) RemoveLabel(_a0 context.Context, _a1 int, _a2 string) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveTag provides a mock function with given fields: _a0, _a1, _a2
+func (_m *PersonRepository) RemoveTag(_a0 context.Context, _a1 int, _a2 string) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ResolveAlias provides a mock function with given fields: _a0, _a1
+func (_m *PersonRepository) ResolveAlias(_a0 context.Context, _a1 int) (int, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (int, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) int); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetAttributes provides a mock function with given fields: _a0, _a1, _a2
+func (_m *PersonRepository) SetAttributes(_a0 context.Context, _a1 int, _a2 map[string]interface{}) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, map[string]interface{}) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetLabel provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *PersonRepository) SetLabel(_a0 context.Context, _a1 int, _a2 string, _a3 string) error {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, string) error); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Unmerge provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *PersonRepository) Unmerge(_a0 context.Context, _a1 int, _a2 int, _a3 time.Time) (dto.PersonDTO, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 dto.PersonDTO
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, time.Time) (dto.PersonDTO, error)); ok {
+		return rf(_a0, _a1, _a2, _a3)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, time.Time) dto.PersonDTO); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r0 = ret.Get(0).(dto.PersonDTO)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, time.Time) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 type mockConstructorTestingTNewPersonRepository interface {
 	mock.TestingT
 	Cleanup(func())
@@ -14,19 +14,45 @@ type PersonService struct {
 	mock.Mock
 }
 
+// AddTags provides a mock function with given fields: _a0, _a1, _a2
+func (_m *PersonService) AddTags(_a0 context.Context, _a1 int, _a2 []string) ([]string, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, []string) ([]string, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, []string) []string); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, []string) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Create provides a mock function with given fields: _a0, _a1
-func (_m *PersonService) Create(_a0 context.Context, _a1 dto.PersonDTO) (dto.PersonDTO, error) {
+func (_m *PersonService) Create(_a0 context.Context, _a1 dto.PersonDTO) (dto.CreatedPersonDTO, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 dto.PersonDTO
+	var r0 dto.CreatedPersonDTO
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, dto.PersonDTO) (dto.PersonDTO, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, dto.PersonDTO) (dto.CreatedPersonDTO, error)); ok {
 		return rf(_a0, _a1)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, dto.PersonDTO) dto.PersonDTO); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, dto.PersonDTO) dto.CreatedPersonDTO); ok {
 		r0 = rf(_a0, _a1)
 	} else {
-		r0 = ret.Get(0).(dto.PersonDTO)
+		r0 = ret.Get(0).(dto.CreatedPersonDTO)
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, dto.PersonDTO) error); ok {
@@ -38,20 +64,20 @@ func (_m *PersonService) Create(_a0 context.Context, _a1 dto.PersonDTO) (dto.Per
 	return r0, r1
 }
 
-// GetAll provides a mock function with given fields: _a0, _a1, _a2
-func (_m *PersonService) GetAll(_a0 context.Context, _a1 int, _a2 int) ([]dto.PersonDTO, error) {
+// Duplicates provides a mock function with given fields: _a0, _a1, _a2
+func (_m *PersonService) Duplicates(_a0 context.Context, _a1 int, _a2 int) ([]dto.DuplicateGroupDTO, error) {
 	ret := _m.Called(_a0, _a1, _a2)
 
-	var r0 []dto.PersonDTO
+	var r0 []dto.DuplicateGroupDTO
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int, int) ([]dto.PersonDTO, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) ([]dto.DuplicateGroupDTO, error)); ok {
 		return rf(_a0, _a1, _a2)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int, int) []dto.PersonDTO); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) []dto.DuplicateGroupDTO); ok {
 		r0 = rf(_a0, _a1, _a2)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]dto.PersonDTO)
+			r0 = ret.Get(0).([]dto.DuplicateGroupDTO)
 		}
 	}
 
@@ -64,6 +90,32 @@ func (_m *PersonService) GetAll(_a0 context.Context, _a1 int, _a2 int) ([]dto.Pe
 	return r0, r1
 }
 
+// GetAll provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *PersonService) GetAll(_a0 context.Context, _a1 int, _a2 int, _a3 dto.PersonFilter) ([]dto.PersonDTO, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 []dto.PersonDTO
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, dto.PersonFilter) ([]dto.PersonDTO, error)); ok {
+		return rf(_a0, _a1, _a2, _a3)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, dto.PersonFilter) []dto.PersonDTO); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]dto.PersonDTO)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, dto.PersonFilter) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetByID provides a mock function with given fields: _a0, _a1
 func (_m *PersonService) GetByID(_a0 context.Context, _a1 int) (*dto.PersonDTO, error) {
 	ret := _m.Called(_a0, _a1)
@@ -90,6 +142,148 @@ func (_m *PersonService) GetByID(_a0 context.Context, _a1 int) (*dto.PersonDTO,
 	return r0, r1
 }
 
+// Merge provides a mock function with given fields: _a0, _a1, _a2
+func (_m *PersonService) Merge(_a0 context.Context, _a1 int, _a2 dto.MergeDTO) (dto.PersonDTO, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 dto.PersonDTO
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, dto.MergeDTO) (dto.PersonDTO, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, dto.MergeDTO) dto.PersonDTO); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(dto.PersonDTO)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, dto.MergeDTO) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveLabel provides a mock function with given fields: _a0, _a1, _a2
+func (_m *PersonService) RemoveLabel(_a0 context.Context, _a1 int, _a2 string) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveTag provides a mock function with given fields: _a0, _a1, _a2
+func (_m *PersonService) RemoveTag(_a0 context.Context, _a1 int, _a2 string) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetAttributes provides a mock function with given fields: _a0, _a1, _a2
+func (_m *PersonService) SetAttributes(_a0 context.Context, _a1 int, _a2 map[string]interface{}) (map[string]interface{}, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 map[string]interface{}
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, map[string]interface{}) (map[string]interface{}, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, map[string]interface{}) map[string]interface{}); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]interface{})
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, map[string]interface{}) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetLabel provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *PersonService) SetLabel(_a0 context.Context, _a1 int, _a2 string, _a3 string) error {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string, string) error); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Tags provides a mock function with given fields: _a0
+func (_m *PersonService) Tags(_a0 context.Context) ([]dto.TagCountDTO, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []dto.TagCountDTO
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]dto.TagCountDTO, error)); ok {
+		return rf(_a0)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []dto.TagCountDTO); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]dto.TagCountDTO)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Unmerge provides a mock function with given fields: _a0, _a1, _a2
+func (_m *PersonService) Unmerge(_a0 context.Context, _a1 int, _a2 int) (dto.PersonDTO, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 dto.PersonDTO
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) (dto.PersonDTO, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) dto.PersonDTO); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(dto.PersonDTO)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 type mockConstructorTestingTNewPersonService interface {
 	mock.TestingT
 	Cleanup(func())
@@ -0,0 +1,331 @@
+package person
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"qore-be/internal/cache"
+	"qore-be/internal/database"
+	"qore-be/internal/domain/dto"
+	"qore-be/internal/logging"
+	"qore-be/internal/tenant"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// CacheMetrics records the outcome of the cache lookups.
+type CacheMetrics interface {
+	CacheRequest(cache string, result string)
+}
+
+// Cache lookup results reported to the metrics.
+const (
+	CacheHit   = "hit"
+	CacheMiss  = "miss"
+	CacheError = "error"
+)
+
+// CachedRepo caches the persons read by ID, by tenant as their custom
+// attributes are, along with the IDs not found. The persons written through
+// it are evicted, and so are all the persons of a tenant by EvictTenant.
+//
+// The persons are loaded from the primary database: a replica lagging
+// behind a write would otherwise be cached until the TTL.
+type CachedRepo struct {
+	repo    Repository
+	cache   cache.Cache
+	ttls    atomic.Pointer[cacheTTLs]
+	metrics CacheMetrics
+
+	// loads collapses the concurrent lookups of a person. The generations
+	// are bumped by the writes, so that the loads started before a write are
+	// neither joined nor cached.
+	loads       singleflight.Group
+	generations [256]atomic.Uint64
+}
+
+// CacheOption ..
+type CacheOption func(*CachedRepo) error
+
+// NewCachedRepository wraps the repository with the cache. By default the
+// persons are cached for a minute and the IDs not found for 5 seconds.
+func NewCachedRepository(repo Repository, c cache.Cache, opts ...CacheOption) (*CachedRepo, error) {
+	if repo == nil {
+		return nil, fmt.Errorf("nil repository")
+	}
+	if c == nil {
+		return nil, fmt.Errorf("nil cache")
+	}
+
+	r := &CachedRepo{
+		repo:  repo,
+		cache: c,
+	}
+	r.ttls.Store(&cacheTTLs{ttl: time.Minute, negative: 5 * time.Second})
+
+	for _, opt := range opts {
+		if err := opt(r); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// WithCacheTTL sets how long the persons, and the IDs not found, are cached.
+// A zero negativeTTL disables the caching of the IDs not found.
+func WithCacheTTL(ttl time.Duration, negativeTTL time.Duration) CacheOption {
+	return func(r *CachedRepo) error {
+		return r.SetTTL(ttl, negativeTTL)
+	}
+}
+
+type cacheTTLs struct {
+	ttl      time.Duration
+	negative time.Duration
+}
+
+// SetTTL changes how long the persons, and the IDs not found, are cached from
+// now on.
+func (r *CachedRepo) SetTTL(ttl time.Duration, negativeTTL time.Duration) error {
+	if ttl <= 0 || negativeTTL < 0 {
+		return fmt.Errorf("invalid cache ttl: %v, %v", ttl, negativeTTL)
+	}
+	r.ttls.Store(&cacheTTLs{ttl: ttl, negative: negativeTTL})
+	return nil
+}
+
+// WithCacheMetrics records the hits and misses of the cache.
+func WithCacheMetrics(m CacheMetrics) CacheOption {
+	return func(r *CachedRepo) error {
+		if m == nil {
+			return fmt.Errorf("nil cache metrics")
+		}
+		r.metrics = m
+		return nil
+	}
+}
+
+// GetByID implements Repository, reading through the cache.
+func (r *CachedRepo) GetByID(ctx context.Context, id int) (dto.PersonDTO, error) {
+	key := cacheKey(id)
+	field, cacheable := r.field(ctx)
+
+	if cacheable {
+		if p, ok, err := r.lookup(ctx, key, field); ok {
+			return p, err
+		}
+	}
+
+	generation := r.generation(id).Load()
+	ch := r.loads.DoChan(fmt.Sprintf("%s/%s/%d", key, field, generation), func() (any, error) {
+		// the load is shared: it outlives the caller that started it.
+		ctx := database.NewPrimaryContext(context.WithoutCancel(ctx))
+
+		p, err := r.repo.GetByID(ctx, id)
+		if cacheable && (err == nil || errors.Is(err, ErrRecordNotFound)) && r.generation(id).Load() == generation {
+			r.store(ctx, key, field, p, err)
+		}
+		return p, err
+	})
+
+	select {
+	case <-ctx.Done():
+		return dto.PersonDTO{}, ctx.Err()
+	case res := <-ch:
+		return res.Val.(dto.PersonDTO), res.Err
+	}
+}
+
+// tenantGenerationTTL is how long the generation of a tenant is kept at
+// least, longer than the persons are cached.
+const tenantGenerationTTL = 24 * time.Hour
+
+// field returns the cache field of the persons of the tenant of ctx: the
+// tenant and its generation, bumped by EvictTenant, so that the persons
+// cached before are no longer read. The persons are not cached when the
+// generation cannot be read.
+func (r *CachedRepo) field(ctx context.Context) (string, bool) {
+	t := tenant.FromContext(ctx)
+
+	generation, _, err := r.cache.Get(ctx, tenantGenerationKey, t)
+	if err != nil {
+		logging.FromContext(ctx).Warn("failed to read the person cache", "error", err.Error())
+		r.record(CacheError)
+		return t, false
+	}
+	if len(generation) == 0 {
+		return t, true
+	}
+	return t + "@" + string(generation), true
+}
+
+// EvictTenant evicts all the persons of the tenant of ctx, e.g. once a
+// custom attribute definition is deleted along with its values.
+func (r *CachedRepo) EvictTenant(ctx context.Context) {
+	ctx = context.WithoutCancel(ctx)
+	generation := strconv.FormatInt(time.Now().UnixNano(), 36)
+	if err := r.cache.Set(ctx, tenantGenerationKey, tenant.FromContext(ctx), []byte(generation), max(tenantGenerationTTL, r.ttls.Load().ttl)); err != nil {
+		logging.FromContext(ctx).Error("failed to evict the tenant persons from the cache", "error", err.Error())
+	}
+}
+
+// lookup returns whether the person is cached, and if so the person or
+// ErrRecordNotFound.
+func (r *CachedRepo) lookup(ctx context.Context, key string, field string) (dto.PersonDTO, bool, error) {
+	b, ok, err := r.cache.Get(ctx, key, field)
+	switch {
+	case err != nil:
+		logging.FromContext(ctx).Warn("failed to read the person cache", "error", err.Error())
+		r.record(CacheError)
+		return dto.PersonDTO{}, false, nil
+	case !ok:
+		r.record(CacheMiss)
+		return dto.PersonDTO{}, false, nil
+	}
+
+	var p *dto.PersonDTO
+	if err := json.Unmarshal(b, &p); err != nil {
+		logging.FromContext(ctx).Warn("failed to decode a cached person", "error", err.Error())
+		r.record(CacheError)
+		return dto.PersonDTO{}, false, nil
+	}
+
+	r.record(CacheHit)
+	if p == nil {
+		return dto.PersonDTO{}, true, ErrRecordNotFound
+	}
+	return *p, true, nil
+}
+
+// store caches the person, or its absence when err is ErrRecordNotFound.
+func (r *CachedRepo) store(ctx context.Context, key string, field string, p dto.PersonDTO, err error) {
+	ttls := r.ttls.Load()
+	value, ttl := []byte("null"), ttls.negative
+	if err == nil {
+		b, err := json.Marshal(p)
+		if err != nil {
+			logging.FromContext(ctx).Warn("failed to encode a person for the cache", "error", err.Error())
+			return
+		}
+		value, ttl = b, ttls.ttl
+	}
+	if ttl == 0 {
+		return
+	}
+
+	if err := r.cache.Set(ctx, key, field, value, ttl); err != nil {
+		logging.FromContext(ctx).Warn("failed to write the person cache", "error", err.Error())
+	}
+}
+
+// evict removes the persons from the cache once written, whether the write
+// succeeded or not.
+func (r *CachedRepo) evict(ctx context.Context, ids ...int) {
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		r.generation(id).Add(1)
+		keys[i] = cacheKey(id)
+	}
+
+	if err := r.cache.Delete(context.WithoutCancel(ctx), keys...); err != nil {
+		logging.FromContext(ctx).Error("failed to evict persons from the cache", "ids", ids, "error", err.Error())
+	}
+}
+
+func (r *CachedRepo) generation(id int) *atomic.Uint64 {
+	return &r.generations[uint(id)%uint(len(r.generations))]
+}
+
+func (r *CachedRepo) record(result string) {
+	if r.metrics != nil {
+		r.metrics.CacheRequest("person", result)
+	}
+}
+
+// tenantGenerationKey is the cache key of the generations of the tenants.
+const tenantGenerationKey = "person-generation"
+
+func cacheKey(id int) string {
+	return "person:" + strconv.Itoa(id)
+}
+
+// Add implements Repository. The ID may be cached as not found.
+func (r *CachedRepo) Add(ctx context.Context, d dto.PersonDTO) (dto.PersonDTO, error) {
+	p, err := r.repo.Add(ctx, d)
+	if err == nil {
+		r.evict(ctx, p.ID)
+	}
+	return p, err
+}
+
+// GetAll implements Repository.
+func (r *CachedRepo) GetAll(ctx context.Context, offset int, limit int, filter dto.PersonFilter) ([]dto.PersonDTO, error) {
+	return r.repo.GetAll(ctx, offset, limit, filter)
+}
+
+// GetBlocks implements Repository.
+func (r *CachedRepo) GetBlocks(ctx context.Context, offset int, limit int, size int) ([][]dto.PersonDTO, error) {
+	return r.repo.GetBlocks(ctx, offset, limit, size)
+}
+
+// GetCandidates implements Repository.
+func (r *CachedRepo) GetCandidates(ctx context.Context, keys []string, limit int) ([]dto.PersonDTO, error) {
+	return r.repo.GetCandidates(ctx, keys, limit)
+}
+
+// Merge implements Repository.
+func (r *CachedRepo) Merge(ctx context.Context, targetID int, sourceID int, survivor dto.PersonDTO) (dto.PersonDTO, error) {
+	defer r.evict(ctx, targetID, sourceID)
+	return r.repo.Merge(ctx, targetID, sourceID, survivor)
+}
+
+// Unmerge implements Repository.
+func (r *CachedRepo) Unmerge(ctx context.Context, targetID int, sourceID int, notBefore time.Time) (dto.PersonDTO, error) {
+	defer r.evict(ctx, targetID, sourceID)
+	return r.repo.Unmerge(ctx, targetID, sourceID, notBefore)
+}
+
+// ResolveAlias implements Repository.
+func (r *CachedRepo) ResolveAlias(ctx context.Context, id int) (int, error) {
+	return r.repo.ResolveAlias(ctx, id)
+}
+
+// AddTags implements Repository.
+func (r *CachedRepo) AddTags(ctx context.Context, personID int, tags []string) ([]string, error) {
+	defer r.evict(ctx, personID)
+	return r.repo.AddTags(ctx, personID, tags)
+}
+
+// RemoveTag implements Repository.
+func (r *CachedRepo) RemoveTag(ctx context.Context, personID int, tag string) error {
+	defer r.evict(ctx, personID)
+	return r.repo.RemoveTag(ctx, personID, tag)
+}
+
+// GetTagCounts implements Repository.
+func (r *CachedRepo) GetTagCounts(ctx context.Context) ([]dto.TagCountDTO, error) {
+	return r.repo.GetTagCounts(ctx)
+}
+
+// SetLabel implements Repository.
+func (r *CachedRepo) SetLabel(ctx context.Context, personID int, key string, value string) error {
+	defer r.evict(ctx, personID)
+	return r.repo.SetLabel(ctx, personID, key, value)
+}
+
+// RemoveLabel implements Repository.
+func (r *CachedRepo) RemoveLabel(ctx context.Context, personID int, key string) error {
+	defer r.evict(ctx, personID)
+	return r.repo.RemoveLabel(ctx, personID, key)
+}
+
+// SetAttributes implements Repository.
+func (r *CachedRepo) SetAttributes(ctx context.Context, personID int, values map[string]any) error {
+	defer r.evict(ctx, personID)
+	return r.repo.SetAttributes(ctx, personID, values)
+}
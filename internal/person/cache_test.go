@@ -0,0 +1,221 @@
+package person_test
+
+import (
+	"context"
+	"qore-be/internal/cache"
+	"qore-be/internal/domain/dto"
+	"qore-be/internal/mocks"
+	"qore-be/internal/person"
+	"qore-be/internal/person/persontest"
+	"qore-be/internal/tenant"
+	"sync"
+	"time"
+
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type cacheMetrics struct {
+	mu      sync.Mutex
+	results map[string]int
+}
+
+func (m *cacheMetrics) CacheRequest(_ string, result string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.results[result]++
+}
+
+func TestCachedRepository(t *testing.T) {
+	backends := map[string]func(t *testing.T) cache.Cache{
+		"memory": func(t *testing.T) cache.Cache {
+			c, err := cache.NewLRU(100)
+			require.NoError(t, err)
+			return c
+		},
+		"redis": func(t *testing.T) cache.Cache {
+			return cache.NewRedis(redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()}), "qore:")
+		},
+	}
+
+	for name, newCache := range backends {
+		t.Run(name, func(t *testing.T) {
+			persontest.TestRepository(t, func(t *testing.T) person.Repository {
+				repo, err := person.NewCachedRepository(person.NewMemoryRepository(), newCache(t))
+				require.NoError(t, err)
+				return repo
+			})
+		})
+	}
+}
+
+func TestNewCachedRepository(t *testing.T) {
+	c, err := cache.NewLRU(10)
+	require.NoError(t, err)
+
+	_, err = person.NewCachedRepository(nil, c)
+	assert.Error(t, err)
+
+	_, err = person.NewCachedRepository(person.NewMemoryRepository(), nil)
+	assert.Error(t, err)
+
+	_, err = person.NewCachedRepository(person.NewMemoryRepository(), c, person.WithCacheTTL(0, 0))
+	assert.Error(t, err)
+
+	_, err = person.NewCachedRepository(person.NewMemoryRepository(), c, person.WithCacheMetrics(nil))
+	assert.Error(t, err)
+}
+
+func TestCachedRepository_GetByID(t *testing.T) {
+	ctx := tenant.NewContext(context.Background(), "acme")
+	c, err := cache.NewLRU(10)
+	require.NoError(t, err)
+
+	repo := mocks.NewPersonRepository(t)
+	metrics := &cacheMetrics{results: map[string]int{}}
+	cached, err := person.NewCachedRepository(repo, c, person.WithCacheMetrics(metrics))
+	require.NoError(t, err)
+
+	repo.On("GetByID", mock.Anything, 1).Return(dto.PersonDTO{ID: 1, Name: "homer"}, nil).Twice()
+	repo.On("GetByID", mock.Anything, 2).Return(dto.PersonDTO{}, person.ErrRecordNotFound).Once()
+	repo.On("SetLabel", mock.Anything, 1, "team", "red").Return(nil).Once()
+
+	for i := 0; i < 2; i++ {
+		p, err := cached.GetByID(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "homer", p.Name)
+
+		// the IDs not found are cached too.
+		_, err = cached.GetByID(ctx, 2)
+		assert.ErrorIs(t, err, person.ErrRecordNotFound)
+	}
+	assert.Equal(t, map[string]int{person.CacheMiss: 2, person.CacheHit: 2}, metrics.results)
+
+	// the persons written are read again.
+	require.NoError(t, cached.SetLabel(ctx, 1, "team", "red"))
+	_, err = cached.GetByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 3, metrics.results[person.CacheMiss])
+}
+
+func TestCachedRepository_SetTTL(t *testing.T) {
+	ctx := context.Background()
+	c, err := cache.NewLRU(10)
+	require.NoError(t, err)
+
+	repo := mocks.NewPersonRepository(t)
+	cached, err := person.NewCachedRepository(repo, c)
+	require.NoError(t, err)
+
+	assert.Error(t, cached.SetTTL(0, 0))
+
+	// the IDs not found are no longer cached.
+	require.NoError(t, cached.SetTTL(time.Minute, 0))
+	repo.On("GetByID", mock.Anything, 2).Return(dto.PersonDTO{}, person.ErrRecordNotFound).Twice()
+	for i := 0; i < 2; i++ {
+		_, err = cached.GetByID(ctx, 2)
+		assert.ErrorIs(t, err, person.ErrRecordNotFound)
+	}
+}
+
+func TestCachedRepository_Tenants(t *testing.T) {
+	c, err := cache.NewLRU(10)
+	require.NoError(t, err)
+
+	repo := mocks.NewPersonRepository(t)
+	cached, err := person.NewCachedRepository(repo, c)
+	require.NoError(t, err)
+
+	// the custom attributes differ by tenant.
+	repo.On("GetByID", mock.Anything, 1).Return(dto.PersonDTO{ID: 1}, nil).Twice()
+	for _, id := range []string{"acme", "globex", "acme", "globex"} {
+		_, err := cached.GetByID(tenant.NewContext(context.Background(), id), 1)
+		require.NoError(t, err)
+	}
+}
+
+func TestCachedRepository_EvictTenant(t *testing.T) {
+	acme := tenant.NewContext(context.Background(), "acme")
+	globex := tenant.NewContext(context.Background(), "globex")
+	c, err := cache.NewLRU(10)
+	require.NoError(t, err)
+
+	repo := mocks.NewPersonRepository(t)
+	cached, err := person.NewCachedRepository(repo, c)
+	require.NoError(t, err)
+
+	repo.On("GetByID", mock.Anything, 1).Return(dto.PersonDTO{ID: 1}, nil).Times(3)
+	for _, ctx := range []context.Context{acme, globex, acme, globex} {
+		_, err := cached.GetByID(ctx, 1)
+		require.NoError(t, err)
+	}
+
+	// the persons of the tenant are read again, not those of the others.
+	cached.EvictTenant(acme)
+	for _, ctx := range []context.Context{acme, globex, acme, globex} {
+		_, err := cached.GetByID(ctx, 1)
+		require.NoError(t, err)
+	}
+}
+
+func TestCachedRepository_ConcurrentMisses(t *testing.T) {
+	ctx := context.Background()
+	c, err := cache.NewLRU(10)
+	require.NoError(t, err)
+
+	release := make(chan struct{})
+	repo := mocks.NewPersonRepository(t)
+	repo.On("GetByID", mock.Anything, 1).
+		Run(func(mock.Arguments) { <-release }).
+		Return(dto.PersonDTO{ID: 1, Name: "homer"}, nil).Once()
+
+	cached, err := person.NewCachedRepository(repo, c)
+	require.NoError(t, err)
+
+	// the concurrent misses are served by a single load.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p, err := cached.GetByID(ctx, 1)
+			assert.NoError(t, err)
+			assert.Equal(t, "homer", p.Name)
+		}()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+}
+
+func TestCachedRepository_CanceledLookup(t *testing.T) {
+	c, err := cache.NewLRU(10)
+	require.NoError(t, err)
+
+	release := make(chan struct{})
+	repo := mocks.NewPersonRepository(t)
+	repo.On("GetByID", mock.Anything, 1).
+		Run(func(mock.Arguments) { <-release }).
+		Return(dto.PersonDTO{ID: 1, Name: "homer"}, nil).Once()
+
+	cached, err := person.NewCachedRepository(repo, c)
+	require.NoError(t, err)
+
+	// the caller gives up, the load completes and is cached.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = cached.GetByID(ctx, 1)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		p, err := cached.GetByID(context.Background(), 1)
+		return err == nil && p.Name == "homer"
+	}, time.Second, 10*time.Millisecond)
+}
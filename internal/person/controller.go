@@ -4,11 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log/slog"
 	"net/http"
+	"qore-be/internal/attribute"
+	"qore-be/internal/breaker"
 	"qore-be/internal/domain/dto"
+	"qore-be/internal/logging"
 	"qore-be/internal/utils"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,15 +20,23 @@ import (
 //
 //go:generate mockery --name=Service --structname=PersonService  --case underscore --output=../mocks/ --filename=person_service.go
 type Service interface {
-	Create(context.Context, dto.PersonDTO) (dto.PersonDTO, error)
+	Create(context.Context, dto.PersonDTO) (dto.CreatedPersonDTO, error)
 	GetByID(context.Context, int) (*dto.PersonDTO, error)
-	GetAll(context.Context, int, int) ([]dto.PersonDTO, error)
+	GetAll(context.Context, int, int, dto.PersonFilter) ([]dto.PersonDTO, error)
+	Duplicates(context.Context, int, int) ([]dto.DuplicateGroupDTO, error)
+	Merge(context.Context, int, dto.MergeDTO) (dto.PersonDTO, error)
+	Unmerge(context.Context, int, int) (dto.PersonDTO, error)
+	AddTags(context.Context, int, []string) ([]string, error)
+	RemoveTag(context.Context, int, string) error
+	Tags(context.Context) ([]dto.TagCountDTO, error)
+	SetLabel(context.Context, int, string, string) error
+	RemoveLabel(context.Context, int, string) error
+	SetAttributes(context.Context, int, map[string]any) (map[string]any, error)
 }
 
 // Controller represents the person controller.
 type Controller struct {
 	svc Service
-	log *slog.Logger
 }
 
 // ControllerOption ..
@@ -49,10 +60,6 @@ func NewController(opts ...ControllerOption) (*Controller, error) {
 		return nil, errMissingPersonService
 	}
 
-	if ctrl.log == nil {
-		ctrl.log = slog.Default()
-	}
-
 	return ctrl, nil
 }
 
@@ -76,36 +83,48 @@ func (c *Controller) Create(ctx *gin.Context) {
 	}
 
 	p, err := c.svc.Create(ctx, req)
-	if err != nil {
-		c.log.Error("failed to create person", "error", err.Error(), "person", p)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	var dupErr *DuplicateError
+	switch {
+	case err == nil:
+		logging.FromContext(ctx).Info("person successfully created", "person", p)
+		ctx.JSON(http.StatusCreated, p)
+	case errors.Is(err, attribute.ErrInvalidAttribute):
+		logging.FromContext(ctx).Error("failed to create person", "error", err.Error(), "person", req)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.As(err, &dupErr):
+		logging.FromContext(ctx).Error("failed to create person", "error", err.Error(), "person", req)
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error(), "duplicates": dupErr.Candidates})
+	default:
+		logging.FromContext(ctx).Error("failed to create person", "error", err.Error(), "person", req)
+		breaker.WriteError(ctx, err)
 	}
-
-	c.log.Info("person successfully created", "person", p)
-	//ctx.JSON(http.StatusCreated, p)
-	ctx.JSON(http.StatusOK, p)
 }
 
 // GetByID retrieves a perons by its ID.
 func (c *Controller) GetByID(ctx *gin.Context) {
 	id, err := strconv.Atoi(ctx.Param("id"))
 	if err != nil {
-		c.log.Error("invalid request", "error", err.Error())
+		logging.FromContext(ctx).Error("invalid request", "error", err.Error())
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	p, err := c.svc.GetByID(ctx, id)
+	var mergedErr *MergedError
 	switch {
 	case err == nil:
 		ctx.JSON(http.StatusOK, p)
+	case errors.As(err, &mergedErr):
+		// not cached by the clients: the merge can be reverted.
+		location := *ctx.Request.URL
+		location.Path = replaceSegment(location.Path, ctx.Param("id"), strconv.Itoa(mergedErr.TargetID))
+		ctx.Redirect(http.StatusTemporaryRedirect, location.RequestURI())
 	case errors.Is(err, ErrRecordNotFound):
-		c.log.Error("failed to get person data", "error", err.Error())
+		logging.FromContext(ctx).Error("failed to get person data", "error", err.Error())
 		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 	default:
-		c.log.Error("failed to get person data", "error", err.Error())
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		logging.FromContext(ctx).Error("failed to get person data", "error", err.Error())
+		breaker.WriteError(ctx, err)
 		return
 	}
 }
@@ -115,15 +134,37 @@ func (c *Controller) GetAll(ctx *gin.Context) {
 	page := utils.StringToInt(ctx.Query("page"), 0)
 	limit := utils.StringToInt(ctx.Query("limit"), 25)
 	if page < 0 || limit < 1 {
-		c.log.Error("invalid request", "page", page, "limit", limit)
+		logging.FromContext(ctx).Error("invalid request", "page", page, "limit", limit)
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
 		return
 	}
 
-	persons, err := c.svc.GetAll(ctx, page, limit)
-	if err != nil {
-		c.log.Error("failed to get person data", "error", err.Error())
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	filter := dto.PersonFilter{
+		TagMode: ctx.Query("tag_mode"),
+		Sort:    ctx.Query("sort"),
+	}
+	for _, v := range ctx.QueryArray("tags") {
+		filter.Tags = append(filter.Tags, strings.Split(v, ",")...)
+	}
+	for k, v := range ctx.Request.URL.Query() {
+		if name, ok := strings.CutPrefix(k, attributePrefix); ok && len(v) > 0 {
+			if filter.Attributes == nil {
+				filter.Attributes = map[string]any{}
+			}
+			filter.Attributes[name] = v[0]
+		}
+	}
+
+	persons, err := c.svc.GetAll(ctx, page, limit, filter)
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrInvalidTag), errors.Is(err, ErrInvalidFilter), errors.Is(err, attribute.ErrInvalidAttribute):
+		logging.FromContext(ctx).Error("invalid request", "error", err.Error())
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	default:
+		logging.FromContext(ctx).Error("failed to get person data", "error", err.Error())
+		breaker.WriteError(ctx, err)
 		return
 	}
 
@@ -133,3 +174,234 @@ func (c *Controller) GetAll(ctx *gin.Context) {
 		"size":    limit,
 	})
 }
+
+// Duplicates reports the stored persons that possibly are the same human.
+func (c *Controller) Duplicates(ctx *gin.Context) {
+	page := utils.StringToInt(ctx.Query("page"), 0)
+	limit := utils.StringToInt(ctx.Query("limit"), 25)
+	if page < 0 || limit < 1 {
+		logging.FromContext(ctx).Error("invalid request", "page", page, "limit", limit)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	groups, err := c.svc.Duplicates(ctx, page, limit)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to get duplicates report", "error", err.Error())
+		breaker.WriteError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"content": groups,
+		"page":    page,
+		"size":    limit,
+	})
+}
+
+// Merge merges the person given in the request body into the person of the path.
+func (c *Controller) Merge(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		logging.FromContext(ctx).Error("invalid request", "error", err.Error())
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req dto.MergeDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	p, err := c.svc.Merge(ctx, id, req)
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, p)
+	case errors.Is(err, ErrInvalidMerge):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrRecordNotFound):
+		logging.FromContext(ctx).Error("failed to merge persons", "error", err.Error())
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		logging.FromContext(ctx).Error("failed to merge persons", "error", err.Error())
+		breaker.WriteError(ctx, err)
+	}
+}
+
+// Unmerge reverts the merge of the person given in the request body into the person of the path.
+func (c *Controller) Unmerge(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		logging.FromContext(ctx).Error("invalid request", "error", err.Error())
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req dto.UnmergeDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	p, err := c.svc.Unmerge(ctx, id, req.SourceID)
+	switch {
+	case err == nil:
+		ctx.JSON(http.StatusOK, p)
+	case errors.Is(err, ErrMergeExpired):
+		logging.FromContext(ctx).Error("failed to unmerge persons", "error", err.Error())
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrRecordNotFound):
+		logging.FromContext(ctx).Error("failed to unmerge persons", "error", err.Error())
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		logging.FromContext(ctx).Error("failed to unmerge persons", "error", err.Error())
+		breaker.WriteError(ctx, err)
+	}
+}
+
+// AddTags tags a person.
+func (c *Controller) AddTags(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		logging.FromContext(ctx).Error("invalid request", "error", err.Error())
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req dto.TagsDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tags, err := c.svc.AddTags(ctx, id, req.Tags)
+	if err != nil {
+		c.handleMetadataError(ctx, "failed to tag person", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+// RemoveTag removes a tag from a person.
+func (c *Controller) RemoveTag(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		logging.FromContext(ctx).Error("invalid request", "error", err.Error())
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.svc.RemoveTag(ctx, id, ctx.Param("tag")); err != nil {
+		c.handleMetadataError(ctx, "failed to untag person", err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// Tags lists every tag with the number of persons tagged with it.
+func (c *Controller) Tags(ctx *gin.Context) {
+	counts, err := c.svc.Tags(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to get tags", "error", err.Error())
+		breaker.WriteError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"content": counts,
+		"size":    len(counts),
+	})
+}
+
+// SetLabel sets the value of a person label.
+func (c *Controller) SetLabel(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		logging.FromContext(ctx).Error("invalid request", "error", err.Error())
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req dto.LabelDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	key := ctx.Param("key")
+	if err := c.svc.SetLabel(ctx, id, key, req.Value); err != nil {
+		c.handleMetadataError(ctx, "failed to label person", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"key": key, "value": req.Value})
+}
+
+// RemoveLabel removes a label from a person.
+func (c *Controller) RemoveLabel(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		logging.FromContext(ctx).Error("invalid request", "error", err.Error())
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.svc.RemoveLabel(ctx, id, ctx.Param("key")); err != nil {
+		c.handleMetadataError(ctx, "failed to remove person label", err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// SetAttributes replaces the custom attributes of a person.
+func (c *Controller) SetAttributes(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		logging.FromContext(ctx).Error("invalid request", "error", err.Error())
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req dto.AttributesDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	attrs, err := c.svc.SetAttributes(ctx, id, req.Attributes)
+	if err != nil {
+		c.handleMetadataError(ctx, "failed to set person attributes", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dto.AttributesDTO{Attributes: attrs})
+}
+
+func (c *Controller) handleMetadataError(ctx *gin.Context, msg string, err error) {
+	logging.FromContext(ctx).Error(msg, "error", err.Error())
+
+	switch {
+	case errors.Is(err, ErrInvalidTag), errors.Is(err, ErrInvalidLabel), errors.Is(err, attribute.ErrInvalidAttribute):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		breaker.WriteError(ctx, err)
+	}
+}
+
+// replaceSegment replaces the last path segment equal to old by new.
+func replaceSegment(path, old, new string) string {
+	segments := strings.Split(path, "/")
+	for i := len(segments) - 1; i >= 0; i-- {
+		if segments[i] == old {
+			segments[i] = new
+			break
+		}
+	}
+	return strings.Join(segments, "/")
+}
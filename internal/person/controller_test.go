@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"qore-be/internal/breaker"
 	"qore-be/internal/domain/dto"
 	"qore-be/internal/mocks"
 	"qore-be/internal/person"
+	"time"
 
 	"testing"
 
@@ -61,12 +63,41 @@ func TestNewPersonController_Create(t *testing.T) {
 			svc: func(t *testing.T) person.Service {
 				s := mocks.NewPersonService(t)
 				s.On("Create", mock.Anything, mock.Anything).
-					Return(dto.PersonDTO{Name: "name"}, nil)
+					Return(dto.CreatedPersonDTO{PersonDTO: dto.PersonDTO{Name: "name"}}, nil)
 
 				return s
 			},
 			in:             validReq,
-			expectedStatus: 200,
+			expectedStatus: 201,
+		},
+		{
+			name: "with possible duplicates (warn)",
+			svc: func(t *testing.T) person.Service {
+				s := mocks.NewPersonService(t)
+				s.On("Create", mock.Anything, mock.Anything).
+					Return(dto.CreatedPersonDTO{
+						PersonDTO:  dto.PersonDTO{ID: 2, Name: "name"},
+						Duplicates: []dto.DuplicateDTO{{Person: dto.PersonDTO{ID: 1, Name: "name"}, Score: 1}},
+					}, nil)
+
+				return s
+			},
+			in:             validReq,
+			expectedStatus: 201,
+		},
+		{
+			name: "with possible duplicates (block)",
+			svc: func(t *testing.T) person.Service {
+				s := mocks.NewPersonService(t)
+				s.On("Create", mock.Anything, mock.Anything).
+					Return(dto.CreatedPersonDTO{}, &person.DuplicateError{
+						Candidates: []dto.DuplicateDTO{{Person: dto.PersonDTO{ID: 1, Name: "name"}, Score: 1}},
+					})
+
+				return s
+			},
+			in:             validReq,
+			expectedStatus: 409,
 		},
 		{
 			name: "with invalid request",
@@ -81,7 +112,7 @@ func TestNewPersonController_Create(t *testing.T) {
 			svc: func(t *testing.T) person.Service {
 				s := mocks.NewPersonService(t)
 				s.On("Create", mock.Anything, mock.Anything).
-					Return(dto.PersonDTO{}, fmt.Errorf("error"))
+					Return(dto.CreatedPersonDTO{}, fmt.Errorf("error"))
 
 				return s
 			},
@@ -166,6 +197,18 @@ func TestNewPersonController_GetByID(t *testing.T) {
 			req:            "1",
 			expectedStatus: 404,
 		},
+		{
+			name: "with open breaker",
+			svc: func(t *testing.T) person.Service {
+				s := mocks.NewPersonService(t)
+				s.On("GetByID", mock.Anything, mock.Anything).
+					Return(nil, &breaker.RejectedError{Err: breaker.ErrOpen, RetryAfter: 3 * time.Second})
+
+				return s
+			},
+			req:            "1",
+			expectedStatus: 503,
+		},
 	}
 
 	for _, tc := range cases {
@@ -184,6 +227,9 @@ func TestNewPersonController_GetByID(t *testing.T) {
 
 			srv.ServeHTTP(rec, req)
 			assert.Equal(t, tc.expectedStatus, rec.Code)
+			if tc.expectedStatus == 503 {
+				assert.Equal(t, "3", rec.Header().Get("Retry-After"))
+			}
 
 			var resp map[string]interface{}
 			err = json.Unmarshal(rec.Body.Bytes(), &resp)
@@ -205,7 +251,7 @@ func TestNewPersonController_GetAll(t *testing.T) {
 			name: "successfully",
 			svc: func(t *testing.T) person.Service {
 				s := mocks.NewPersonService(t)
-				s.On("GetAll", mock.Anything, mock.Anything, mock.Anything).
+				s.On("GetAll", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 					Return([]dto.PersonDTO{{Name: "name"}}, nil)
 
 				return s
@@ -217,7 +263,7 @@ func TestNewPersonController_GetAll(t *testing.T) {
 			name: "successfully (page 2, page size 30)",
 			svc: func(t *testing.T) person.Service {
 				s := mocks.NewPersonService(t)
-				s.On("GetAll", mock.Anything, mock.Anything, mock.Anything).
+				s.On("GetAll", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 					Return([]dto.PersonDTO{{Name: "name"}}, nil)
 
 				return s
@@ -225,6 +271,30 @@ func TestNewPersonController_GetAll(t *testing.T) {
 			req:            "?page=1&limit=20",
 			expectedStatus: 200,
 		},
+		{
+			name: "successfully (with tags)",
+			svc: func(t *testing.T) person.Service {
+				s := mocks.NewPersonService(t)
+				s.On("GetAll", mock.Anything, 0, 25, dto.PersonFilter{Tags: []string{"vip", "new", "blocked"}, TagMode: "or"}).
+					Return([]dto.PersonDTO{{Name: "name"}}, nil)
+
+				return s
+			},
+			req:            "?tags=vip,new&tags=blocked&tag_mode=or",
+			expectedStatus: 200,
+		},
+		{
+			name: "with invalid tag mode",
+			svc: func(t *testing.T) person.Service {
+				s := mocks.NewPersonService(t)
+				s.On("GetAll", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, fmt.Errorf("%w: error", person.ErrInvalidTag))
+
+				return s
+			},
+			req:            "?tags=vip&tag_mode=xor",
+			expectedStatus: 400,
+		},
 		{
 			name: "with invalid page number",
 			svc: func(t *testing.T) person.Service {
@@ -245,7 +315,7 @@ func TestNewPersonController_GetAll(t *testing.T) {
 			name: "with internal error",
 			svc: func(t *testing.T) person.Service {
 				s := mocks.NewPersonService(t)
-				s.On("GetAll", mock.Anything, mock.Anything, mock.Anything).
+				s.On("GetAll", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 					Return(nil, fmt.Errorf("error"))
 
 				return s
@@ -280,3 +350,413 @@ func TestNewPersonController_GetAll(t *testing.T) {
 		})
 	}
 }
+
+func TestNewPersonController_Duplicates(t *testing.T) {
+	cases := []struct {
+		name           string
+		query          string
+		svc            func(*testing.T) person.Service
+		expectedStatus int
+	}{
+		{
+			name:  "successfully",
+			query: "?page=1&limit=10",
+			svc: func(t *testing.T) person.Service {
+				s := mocks.NewPersonService(t)
+				s.On("Duplicates", mock.Anything, 1, 10).
+					Return([]dto.DuplicateGroupDTO{{Score: 0.9, Members: []dto.PersonDTO{{ID: 1}, {ID: 2}}}}, nil)
+
+				return s
+			},
+			expectedStatus: 200,
+		},
+		{
+			name:  "with invalid limit",
+			query: "?limit=0",
+			svc: func(t *testing.T) person.Service {
+				return mocks.NewPersonService(t)
+			},
+			expectedStatus: 400,
+		},
+		{
+			name: "with internal error",
+			svc: func(t *testing.T) person.Service {
+				s := mocks.NewPersonService(t)
+				s.On("Duplicates", mock.Anything, 0, 25).
+					Return(nil, fmt.Errorf("error"))
+
+				return s
+			},
+			expectedStatus: 500,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl, err := person.NewController(person.WithService(tc.svc(t)))
+			require.NoError(t, err)
+
+			srv := gin.Default()
+			gin.SetMode(gin.TestMode)
+
+			srv.GET("/person/duplicates", ctrl.Duplicates)
+
+			rec := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodGet, "/person/duplicates"+tc.query, nil)
+			require.NoError(t, err)
+
+			srv.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+
+			var resp map[string]interface{}
+			err = json.Unmarshal(rec.Body.Bytes(), &resp)
+			assert.NoError(t, err)
+
+			assert.NotEmpty(t, resp)
+		})
+	}
+}
+
+func TestNewPersonController_GetByID_Merged(t *testing.T) {
+	s := mocks.NewPersonService(t)
+	s.On("GetByID", mock.Anything, 1).
+		Return(nil, &person.MergedError{ID: 1, TargetID: 7})
+
+	ctrl, err := person.NewController(person.WithService(s))
+	require.NoError(t, err)
+
+	srv := gin.Default()
+	gin.SetMode(gin.TestMode)
+
+	srv.GET("/person/:id/info", ctrl.GetByID)
+
+	rec := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/person/1/info?fields=name", nil)
+	require.NoError(t, err)
+
+	srv.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusTemporaryRedirect, rec.Code)
+	assert.Equal(t, "/person/7/info?fields=name", rec.Header().Get("Location"))
+}
+
+func TestNewPersonController_Merge(t *testing.T) {
+	cases := []struct {
+		name           string
+		svc            func(*testing.T) person.Service
+		id             string
+		in             interface{}
+		expectedStatus int
+	}{
+		{
+			name: "successfully",
+			svc: func(t *testing.T) person.Service {
+				s := mocks.NewPersonService(t)
+				s.On("Merge", mock.Anything, 1, dto.MergeDTO{SourceID: 2, Rules: map[string]string{"name": "source"}}).
+					Return(dto.PersonDTO{ID: 1, Name: "name"}, nil)
+
+				return s
+			},
+			id:             "1",
+			in:             dto.MergeDTO{SourceID: 2, Rules: map[string]string{"name": "source"}},
+			expectedStatus: 200,
+		},
+		{
+			name: "with invalid id",
+			svc: func(t *testing.T) person.Service {
+				return mocks.NewPersonService(t)
+			},
+			id:             "$$",
+			in:             dto.MergeDTO{SourceID: 2},
+			expectedStatus: 400,
+		},
+		{
+			name: "with missing source id",
+			svc: func(t *testing.T) person.Service {
+				return mocks.NewPersonService(t)
+			},
+			id:             "1",
+			in:             map[string]string{},
+			expectedStatus: 400,
+		},
+		{
+			name: "with invalid merge",
+			svc: func(t *testing.T) person.Service {
+				s := mocks.NewPersonService(t)
+				s.On("Merge", mock.Anything, 1, mock.Anything).
+					Return(dto.PersonDTO{}, fmt.Errorf("%w: error", person.ErrInvalidMerge))
+
+				return s
+			},
+			id:             "1",
+			in:             dto.MergeDTO{SourceID: 1},
+			expectedStatus: 400,
+		},
+		{
+			name: "with not-found error",
+			svc: func(t *testing.T) person.Service {
+				s := mocks.NewPersonService(t)
+				s.On("Merge", mock.Anything, 1, mock.Anything).
+					Return(dto.PersonDTO{}, person.ErrRecordNotFound)
+
+				return s
+			},
+			id:             "1",
+			in:             dto.MergeDTO{SourceID: 2},
+			expectedStatus: 404,
+		},
+		{
+			name: "with internal error",
+			svc: func(t *testing.T) person.Service {
+				s := mocks.NewPersonService(t)
+				s.On("Merge", mock.Anything, 1, mock.Anything).
+					Return(dto.PersonDTO{}, fmt.Errorf("error"))
+
+				return s
+			},
+			id:             "1",
+			in:             dto.MergeDTO{SourceID: 2},
+			expectedStatus: 500,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl, err := person.NewController(person.WithService(tc.svc(t)))
+			require.NoError(t, err)
+
+			srv := gin.Default()
+			gin.SetMode(gin.TestMode)
+
+			srv.POST("/:id/merge", ctrl.Merge)
+
+			rec := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.in)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, "/"+tc.id+"/merge", bytes.NewBuffer(data))
+			require.NoError(t, err)
+
+			srv.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestNewPersonController_Unmerge(t *testing.T) {
+	cases := []struct {
+		name           string
+		svc            func(*testing.T) person.Service
+		id             string
+		in             interface{}
+		expectedStatus int
+	}{
+		{
+			name: "successfully",
+			svc: func(t *testing.T) person.Service {
+				s := mocks.NewPersonService(t)
+				s.On("Unmerge", mock.Anything, 1, 2).
+					Return(dto.PersonDTO{ID: 2, Name: "name"}, nil)
+
+				return s
+			},
+			id:             "1",
+			in:             dto.UnmergeDTO{SourceID: 2},
+			expectedStatus: 200,
+		},
+		{
+			name: "with invalid id",
+			svc: func(t *testing.T) person.Service {
+				return mocks.NewPersonService(t)
+			},
+			id:             "$$",
+			in:             dto.UnmergeDTO{SourceID: 2},
+			expectedStatus: 400,
+		},
+		{
+			name: "with missing source id",
+			svc: func(t *testing.T) person.Service {
+				return mocks.NewPersonService(t)
+			},
+			id:             "1",
+			in:             map[string]string{},
+			expectedStatus: 400,
+		},
+		{
+			name: "with expired merge",
+			svc: func(t *testing.T) person.Service {
+				s := mocks.NewPersonService(t)
+				s.On("Unmerge", mock.Anything, 1, 2).
+					Return(dto.PersonDTO{}, person.ErrMergeExpired)
+
+				return s
+			},
+			id:             "1",
+			in:             dto.UnmergeDTO{SourceID: 2},
+			expectedStatus: 409,
+		},
+		{
+			name: "with not-found error",
+			svc: func(t *testing.T) person.Service {
+				s := mocks.NewPersonService(t)
+				s.On("Unmerge", mock.Anything, 1, 2).
+					Return(dto.PersonDTO{}, person.ErrRecordNotFound)
+
+				return s
+			},
+			id:             "1",
+			in:             dto.UnmergeDTO{SourceID: 2},
+			expectedStatus: 404,
+		},
+		{
+			name: "with internal error",
+			svc: func(t *testing.T) person.Service {
+				s := mocks.NewPersonService(t)
+				s.On("Unmerge", mock.Anything, 1, 2).
+					Return(dto.PersonDTO{}, fmt.Errorf("error"))
+
+				return s
+			},
+			id:             "1",
+			in:             dto.UnmergeDTO{SourceID: 2},
+			expectedStatus: 500,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl, err := person.NewController(person.WithService(tc.svc(t)))
+			require.NoError(t, err)
+
+			srv := gin.Default()
+			gin.SetMode(gin.TestMode)
+
+			srv.POST("/:id/unmerge", ctrl.Unmerge)
+
+			rec := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.in)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, "/"+tc.id+"/unmerge", bytes.NewBuffer(data))
+			require.NoError(t, err)
+
+			srv.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestNewPersonController_Tags(t *testing.T) {
+	t.Run("add tags", func(t *testing.T) {
+		s := mocks.NewPersonService(t)
+		s.On("AddTags", mock.Anything, 1, []string{"vip"}).Return([]string{"vip"}, nil)
+		s.On("AddTags", mock.Anything, 2, []string{"vip"}).Return(nil, person.ErrRecordNotFound)
+		s.On("AddTags", mock.Anything, 3, []string{""}).Return(nil, person.ErrInvalidTag)
+
+		ctrl, err := person.NewController(person.WithService(s))
+		require.NoError(t, err)
+
+		srv := gin.Default()
+		gin.SetMode(gin.TestMode)
+		srv.POST("/person/:id/tags", ctrl.AddTags)
+
+		for _, tc := range []struct {
+			id             string
+			in             interface{}
+			expectedStatus int
+		}{
+			{id: "1", in: dto.TagsDTO{Tags: []string{"vip"}}, expectedStatus: 200},
+			{id: "2", in: dto.TagsDTO{Tags: []string{"vip"}}, expectedStatus: 404},
+			{id: "3", in: dto.TagsDTO{Tags: []string{""}}, expectedStatus: 400},
+			{id: "1", in: dto.TagsDTO{}, expectedStatus: 400},
+			{id: "$$", in: dto.TagsDTO{Tags: []string{"vip"}}, expectedStatus: 400},
+		} {
+			data, err := json.Marshal(tc.in)
+			require.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodPost, "/person/"+tc.id+"/tags", bytes.NewBuffer(data))
+			require.NoError(t, err)
+
+			srv.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+		}
+	})
+
+	t.Run("remove tag", func(t *testing.T) {
+		s := mocks.NewPersonService(t)
+		s.On("RemoveTag", mock.Anything, 1, "vip").Return(nil)
+		s.On("RemoveTag", mock.Anything, 1, "other").Return(person.ErrRecordNotFound)
+
+		ctrl, err := person.NewController(person.WithService(s))
+		require.NoError(t, err)
+
+		srv := gin.Default()
+		gin.SetMode(gin.TestMode)
+		srv.DELETE("/person/:id/tags/:tag", ctrl.RemoveTag)
+
+		for tag, expectedStatus := range map[string]int{"vip": 204, "other": 404} {
+			rec := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodDelete, "/person/1/tags/"+tag, nil)
+			require.NoError(t, err)
+
+			srv.ServeHTTP(rec, req)
+			assert.Equal(t, expectedStatus, rec.Code)
+		}
+	})
+
+	t.Run("list tags", func(t *testing.T) {
+		s := mocks.NewPersonService(t)
+		s.On("Tags", mock.Anything).Return([]dto.TagCountDTO{{Tag: "vip", Count: 2}}, nil)
+
+		ctrl, err := person.NewController(person.WithService(s))
+		require.NoError(t, err)
+
+		srv := gin.Default()
+		gin.SetMode(gin.TestMode)
+		srv.GET("/person/tags", ctrl.Tags)
+
+		rec := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, "/person/tags", nil)
+		require.NoError(t, err)
+
+		srv.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"content":[{"tag":"vip","count":2}],"size":1}`, rec.Body.String())
+	})
+}
+
+func TestNewPersonController_Labels(t *testing.T) {
+	s := mocks.NewPersonService(t)
+	s.On("SetLabel", mock.Anything, 1, "team", "blue").Return(nil)
+	s.On("SetLabel", mock.Anything, 2, "team", "blue").Return(person.ErrRecordNotFound)
+	s.On("RemoveLabel", mock.Anything, 1, "team").Return(nil)
+
+	ctrl, err := person.NewController(person.WithService(s))
+	require.NoError(t, err)
+
+	srv := gin.Default()
+	gin.SetMode(gin.TestMode)
+	srv.PUT("/person/:id/labels/:key", ctrl.SetLabel)
+	srv.DELETE("/person/:id/labels/:key", ctrl.RemoveLabel)
+
+	for _, tc := range []struct {
+		method         string
+		path           string
+		expectedStatus int
+	}{
+		{method: http.MethodPut, path: "/person/1/labels/team", expectedStatus: 200},
+		{method: http.MethodPut, path: "/person/2/labels/team", expectedStatus: 404},
+		{method: http.MethodDelete, path: "/person/1/labels/team", expectedStatus: 204},
+	} {
+		rec := httptest.NewRecorder()
+		req, err := http.NewRequest(tc.method, tc.path, bytes.NewBufferString(`{"value":"blue"}`))
+		require.NoError(t, err)
+
+		srv.ServeHTTP(rec, req)
+		assert.Equal(t, tc.expectedStatus, rec.Code, tc.path)
+	}
+}
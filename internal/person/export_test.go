@@ -0,0 +1,65 @@
+package person
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"qore-be/internal/database"
+	"qore-be/internal/domain/dto"
+	"qore-be/internal/domain/entities"
+
+	"gorm.io/gorm"
+)
+
+// GetByIDSequential is the former GetByID, reading the person, its phone,
+// address join and address one after the other, kept for the benchmarks.
+func (r *Repo) GetByIDSequential(ctx context.Context, id int) (dto.PersonDTO, error) {
+	ctx, cancel := database.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	db := r.reader(ctx)
+
+	pr := &entities.Person{}
+	if err := db.First(&pr, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.PersonDTO{}, ErrRecordNotFound
+		}
+		return dto.PersonDTO{}, fmt.Errorf("failed to get person data: %v", err)
+	}
+
+	ph := &entities.Phone{}
+	if err := db.First(&ph, "person_id = ?", id).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return dto.PersonDTO{}, fmt.Errorf("failed to get phone data: %v", err)
+	}
+
+	pAddr := &entities.PersonAddress{}
+	if err := db.First(&pAddr, "person_id = ?", id).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return dto.PersonDTO{}, fmt.Errorf("failed to get address_join data: %v", err)
+	}
+
+	addr := &entities.Address{}
+	if err := db.First(&addr, "id = ?", pAddr.AddressID).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return dto.PersonDTO{}, fmt.Errorf("failed to get address: %v", err)
+	}
+
+	meta, err := r.metadata(ctx, db, []int{id})
+	if err != nil {
+		return dto.PersonDTO{}, err
+	}
+
+	return dto.PersonDTO{
+		ID:      pr.ID,
+		Name:    pr.Name,
+		Age:     pr.Age,
+		Number:  ph.Number,
+		State:   addr.State,
+		City:    addr.City,
+		Street1: addr.Street1,
+		Street2: addr.Street2,
+		Zip:     addr.Zip,
+
+		Tags:       meta[id].tags,
+		Labels:     meta[id].labels,
+		Attributes: meta[id].attributes,
+	}, nil
+}
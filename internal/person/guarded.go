@@ -0,0 +1,123 @@
+package person
+
+import (
+	"context"
+	"qore-be/internal/breaker"
+	"qore-be/internal/domain/dto"
+	"time"
+)
+
+// expected are the person errors the breaker does not count as failures.
+var expected = []error{ErrRecordNotFound, ErrMergeExpired, ErrInvalidFilter}
+
+// GuardedRepo guards a repository with a circuit breaker, which also bounds
+// the concurrent calls of each operation.
+type GuardedRepo struct {
+	repo    Repository
+	breaker *breaker.Breaker
+}
+
+// NewGuardedRepository wraps the repository with the breaker. The operations
+// are named "person.<method>", e.g. "person.GetAll".
+func NewGuardedRepository(repo Repository, b *breaker.Breaker) *GuardedRepo {
+	if repo == nil || b == nil {
+		panic("nil repository or breaker")
+	}
+	return &GuardedRepo{repo: repo, breaker: b}
+}
+
+// Add implements Repository.
+func (r *GuardedRepo) Add(ctx context.Context, d dto.PersonDTO) (dto.PersonDTO, error) {
+	return breaker.Call(ctx, r.breaker, "person.Add", func(ctx context.Context) (dto.PersonDTO, error) {
+		return r.repo.Add(ctx, d)
+	}, expected...)
+}
+
+// GetByID implements Repository.
+func (r *GuardedRepo) GetByID(ctx context.Context, id int) (dto.PersonDTO, error) {
+	return breaker.Call(ctx, r.breaker, "person.GetByID", func(ctx context.Context) (dto.PersonDTO, error) {
+		return r.repo.GetByID(ctx, id)
+	}, expected...)
+}
+
+// GetAll implements Repository.
+func (r *GuardedRepo) GetAll(ctx context.Context, offset int, limit int, filter dto.PersonFilter) ([]dto.PersonDTO, error) {
+	return breaker.Call(ctx, r.breaker, "person.GetAll", func(ctx context.Context) ([]dto.PersonDTO, error) {
+		return r.repo.GetAll(ctx, offset, limit, filter)
+	}, expected...)
+}
+
+// GetBlocks implements Repository.
+func (r *GuardedRepo) GetBlocks(ctx context.Context, offset int, limit int, size int) ([][]dto.PersonDTO, error) {
+	return breaker.Call(ctx, r.breaker, "person.GetBlocks", func(ctx context.Context) ([][]dto.PersonDTO, error) {
+		return r.repo.GetBlocks(ctx, offset, limit, size)
+	}, expected...)
+}
+
+// GetCandidates implements Repository.
+func (r *GuardedRepo) GetCandidates(ctx context.Context, keys []string, limit int) ([]dto.PersonDTO, error) {
+	return breaker.Call(ctx, r.breaker, "person.GetCandidates", func(ctx context.Context) ([]dto.PersonDTO, error) {
+		return r.repo.GetCandidates(ctx, keys, limit)
+	}, expected...)
+}
+
+// Merge implements Repository.
+func (r *GuardedRepo) Merge(ctx context.Context, targetID int, sourceID int, survivor dto.PersonDTO) (dto.PersonDTO, error) {
+	return breaker.Call(ctx, r.breaker, "person.Merge", func(ctx context.Context) (dto.PersonDTO, error) {
+		return r.repo.Merge(ctx, targetID, sourceID, survivor)
+	}, expected...)
+}
+
+// Unmerge implements Repository.
+func (r *GuardedRepo) Unmerge(ctx context.Context, targetID int, sourceID int, notBefore time.Time) (dto.PersonDTO, error) {
+	return breaker.Call(ctx, r.breaker, "person.Unmerge", func(ctx context.Context) (dto.PersonDTO, error) {
+		return r.repo.Unmerge(ctx, targetID, sourceID, notBefore)
+	}, expected...)
+}
+
+// ResolveAlias implements Repository.
+func (r *GuardedRepo) ResolveAlias(ctx context.Context, id int) (int, error) {
+	return breaker.Call(ctx, r.breaker, "person.ResolveAlias", func(ctx context.Context) (int, error) {
+		return r.repo.ResolveAlias(ctx, id)
+	}, expected...)
+}
+
+// AddTags implements Repository.
+func (r *GuardedRepo) AddTags(ctx context.Context, personID int, tags []string) ([]string, error) {
+	return breaker.Call(ctx, r.breaker, "person.AddTags", func(ctx context.Context) ([]string, error) {
+		return r.repo.AddTags(ctx, personID, tags)
+	}, expected...)
+}
+
+// RemoveTag implements Repository.
+func (r *GuardedRepo) RemoveTag(ctx context.Context, personID int, tag string) error {
+	return r.breaker.Do(ctx, "person.RemoveTag", func(ctx context.Context) error {
+		return r.repo.RemoveTag(ctx, personID, tag)
+	}, expected...)
+}
+
+// GetTagCounts implements Repository.
+func (r *GuardedRepo) GetTagCounts(ctx context.Context) ([]dto.TagCountDTO, error) {
+	return breaker.Call(ctx, r.breaker, "person.GetTagCounts", r.repo.GetTagCounts, expected...)
+}
+
+// SetLabel implements Repository.
+func (r *GuardedRepo) SetLabel(ctx context.Context, personID int, key string, value string) error {
+	return r.breaker.Do(ctx, "person.SetLabel", func(ctx context.Context) error {
+		return r.repo.SetLabel(ctx, personID, key, value)
+	}, expected...)
+}
+
+// RemoveLabel implements Repository.
+func (r *GuardedRepo) RemoveLabel(ctx context.Context, personID int, key string) error {
+	return r.breaker.Do(ctx, "person.RemoveLabel", func(ctx context.Context) error {
+		return r.repo.RemoveLabel(ctx, personID, key)
+	}, expected...)
+}
+
+// SetAttributes implements Repository.
+func (r *GuardedRepo) SetAttributes(ctx context.Context, personID int, values map[string]any) error {
+	return r.breaker.Do(ctx, "person.SetAttributes", func(ctx context.Context) error {
+		return r.repo.SetAttributes(ctx, personID, values)
+	}, expected...)
+}
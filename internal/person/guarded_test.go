@@ -0,0 +1,46 @@
+package person_test
+
+import (
+	"context"
+	"fmt"
+	"qore-be/internal/breaker"
+	"qore-be/internal/domain/dto"
+	"qore-be/internal/mocks"
+	"qore-be/internal/person"
+	"time"
+
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGuardedRepository(t *testing.T) {
+	ctx := context.Background()
+	b, err := breaker.New(breaker.WithFailureThreshold(2), breaker.WithOpenTimeout(time.Minute))
+	require.NoError(t, err)
+
+	repo := mocks.NewPersonRepository(t)
+	repo.On("GetByID", mock.Anything, 1).Return(dto.PersonDTO{}, person.ErrRecordNotFound).Times(3)
+	repo.On("GetByID", mock.Anything, 2).Return(dto.PersonDTO{}, fmt.Errorf("connection refused")).Times(2)
+	guarded := person.NewGuardedRepository(repo, b)
+
+	// the persons not found are no database failures.
+	for i := 0; i < 3; i++ {
+		_, err = guarded.GetByID(ctx, 1)
+		assert.ErrorIs(t, err, person.ErrRecordNotFound)
+	}
+	assert.Equal(t, breaker.StateClosed, b.State())
+
+	for i := 0; i < 2; i++ {
+		_, err = guarded.GetByID(ctx, 2)
+		assert.Error(t, err)
+	}
+	assert.Equal(t, breaker.StateOpen, b.State())
+
+	// the repository is no longer called.
+	_, err = guarded.GetByID(ctx, 1)
+	assert.ErrorIs(t, err, breaker.ErrOpen)
+	assert.ErrorIs(t, guarded.SetLabel(ctx, 1, "team", "red"), breaker.ErrOpen)
+}
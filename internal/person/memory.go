@@ -0,0 +1,857 @@
+package person
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"qore-be/internal/dedup"
+	"qore-be/internal/domain/dto"
+	"qore-be/internal/domain/entities"
+	"qore-be/internal/tenant"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryRepo is a person repository keeping the persons in memory, with the
+// same semantics as the database one. It is safe for concurrent use.
+type MemoryRepo struct {
+	mu sync.RWMutex
+
+	// the rows of each table by ID, as with the database.
+	persons    map[int]entities.Person
+	phones     map[int]entities.Phone
+	addresses  map[int]entities.Address
+	joins      map[int]entities.PersonAddress
+	merges     map[int]entities.PersonMerge
+	tags       map[int]entities.PersonTag
+	labels     map[int]entities.PersonLabel
+	attributes map[int]entities.PersonAttribute
+
+	// lastIDs holds the last ID of each table.
+	lastIDs map[string]int
+}
+
+// NewMemoryRepository creates a new, empty, in-memory person repository.
+func NewMemoryRepository() *MemoryRepo {
+	return &MemoryRepo{
+		persons:    map[int]entities.Person{},
+		phones:     map[int]entities.Phone{},
+		addresses:  map[int]entities.Address{},
+		joins:      map[int]entities.PersonAddress{},
+		merges:     map[int]entities.PersonMerge{},
+		tags:       map[int]entities.PersonTag{},
+		labels:     map[int]entities.PersonLabel{},
+		attributes: map[int]entities.PersonAttribute{},
+		lastIDs:    map[string]int{},
+	}
+}
+
+// nextID returns a new row ID of the table: as with the database, the IDs
+// of each table follow each other.
+func (r *MemoryRepo) nextID(table string) int {
+	r.lastIDs[table]++
+	return r.lastIDs[table]
+}
+
+// Add saves a new person.
+func (r *MemoryRepo) Add(ctx context.Context, d dto.PersonDTO) (dto.PersonDTO, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	values, err := encodeAttributes(d.Attributes)
+	if err != nil {
+		return dto.PersonDTO{}, err
+	}
+
+	pr := entities.Person{ID: r.nextID(entities.Person{}.TableName()), Name: d.Name, Age: d.Age}
+	r.persons[pr.ID] = pr
+
+	ph := entities.Phone{ID: r.nextID(entities.Phone{}.TableName()), PersonID: pr.ID, Number: d.Number}
+	r.phones[ph.ID] = ph
+
+	addr := entities.Address{
+		ID:      r.nextID(entities.Address{}.TableName()),
+		City:    d.City,
+		State:   d.State,
+		Street1: d.Street1,
+		Street2: d.Street2,
+		Zip:     d.Zip,
+	}
+	r.addresses[addr.ID] = addr
+
+	join := entities.PersonAddress{ID: r.nextID(entities.PersonAddress{}.TableName()), PersonID: pr.ID, AddressID: addr.ID}
+	r.joins[join.ID] = join
+
+	r.createAttributes(tenant.FromContext(ctx), pr.ID, values)
+
+	return dto.PersonDTO{
+		ID:      pr.ID,
+		Name:    pr.Name,
+		Age:     pr.Age,
+		Number:  ph.Number,
+		State:   addr.State,
+		City:    addr.City,
+		Street1: addr.Street1,
+		Street2: addr.Street2,
+		Zip:     addr.Zip,
+
+		Attributes: d.Attributes,
+	}, nil
+}
+
+// GetByID retrieves a person by its ID.
+func (r *MemoryRepo) GetByID(ctx context.Context, id int) (dto.PersonDTO, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.get(ctx, id)
+}
+
+func (r *MemoryRepo) get(ctx context.Context, id int) (dto.PersonDTO, error) {
+	pr, ok := r.persons[id]
+	if !ok {
+		return dto.PersonDTO{}, ErrRecordNotFound
+	}
+
+	ph, addr := r.details(id)
+	meta, err := r.metadata(ctx, []int{id})
+	if err != nil {
+		return dto.PersonDTO{}, err
+	}
+
+	return dto.PersonDTO{
+		ID:      pr.ID,
+		Name:    pr.Name,
+		Age:     pr.Age,
+		Number:  ph.Number,
+		State:   addr.State,
+		City:    addr.City,
+		Street1: addr.Street1,
+		Street2: addr.Street2,
+		Zip:     addr.Zip,
+
+		Tags:       meta[id].tags,
+		Labels:     meta[id].labels,
+		Attributes: meta[id].attributes,
+	}, nil
+}
+
+// details returns the first phone and address of a person.
+func (r *MemoryRepo) details(id int) (entities.Phone, entities.Address) {
+	var ph entities.Phone
+	for _, p := range r.phones {
+		if p.PersonID == id && (ph.ID == 0 || p.ID < ph.ID) {
+			ph = p
+		}
+	}
+
+	var join entities.PersonAddress
+	for _, j := range r.joins {
+		if j.PersonID == id && (join.ID == 0 || j.ID < join.ID) {
+			join = j
+		}
+	}
+
+	return ph, r.addresses[join.AddressID]
+}
+
+// GetAll retrieves the persons matching the filter.
+func (r *MemoryRepo) GetAll(ctx context.Context, offset int, limit int, filter dto.PersonFilter) ([]dto.PersonDTO, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t := tenant.FromContext(ctx)
+	wanted, err := encodeAttributes(filter.Attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	var persons []entities.Person
+	for _, p := range r.persons {
+		if r.hasTags(p.ID, filter) && r.hasAttributes(t, p.ID, wanted) {
+			persons = append(persons, p)
+		}
+	}
+
+	if err := r.sort(persons, t, filter); err != nil {
+		return nil, err
+	}
+
+	persons = page(persons, offset, limit)
+
+	ids := make([]int, len(persons))
+	for i, p := range persons {
+		ids[i] = p.ID
+	}
+
+	meta, err := r.metadata(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	dtos := make([]dto.PersonDTO, len(persons))
+	for i, p := range persons {
+		dtos[i] = dto.PersonDTO{
+			ID:         p.ID,
+			Name:       p.Name,
+			Age:        p.Age,
+			Tags:       meta[p.ID].tags,
+			Labels:     meta[p.ID].labels,
+			Attributes: meta[p.ID].attributes,
+		}
+	}
+	return dtos, nil
+}
+
+func (r *MemoryRepo) hasTags(id int, filter dto.PersonFilter) bool {
+	if len(filter.Tags) == 0 {
+		return true
+	}
+
+	found := map[string]bool{}
+	for _, t := range r.tags {
+		if t.PersonID == id && slices.Contains(filter.Tags, t.Tag) {
+			found[t.Tag] = true
+		}
+	}
+
+	if filter.TagMode == dto.TagModeOr {
+		return len(found) > 0
+	}
+
+	distinct := map[string]bool{}
+	for _, t := range filter.Tags {
+		distinct[t] = true
+	}
+	return len(found) == len(distinct)
+}
+
+func (r *MemoryRepo) hasAttributes(t string, id int, wanted map[string]string) bool {
+	for name, value := range wanted {
+		if v, ok := r.attribute(t, id, name); !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+// attribute returns the encoded value of a person attribute.
+func (r *MemoryRepo) attribute(t string, id int, name string) (string, bool) {
+	for _, a := range r.attributes {
+		if a.PersonID == id && a.Tenant == t && a.Name == name {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+// sort orders the persons as the database does: the persons without the
+// sorted attribute come first, the ties are broken by ID.
+func (r *MemoryRepo) sort(persons []entities.Person, t string, filter dto.PersonFilter) error {
+	dir := 1
+	if filter.SortDesc {
+		dir = -1
+	}
+
+	var compare func(a, b entities.Person) int
+	switch {
+	case filter.Sort == "" || filter.Sort == "id":
+		compare = func(a, b entities.Person) int { return dir * cmp.Compare(a.ID, b.ID) }
+	case filter.Sort == "name":
+		compare = func(a, b entities.Person) int { return dir * strings.Compare(a.Name, b.Name) }
+	case filter.Sort == "age":
+		compare = func(a, b entities.Person) int { return dir * cmp.Compare(a.Age, b.Age) }
+	case strings.HasPrefix(filter.Sort, attributePrefix):
+		name := strings.TrimPrefix(filter.Sort, attributePrefix)
+		compare = func(a, b entities.Person) int {
+			va, oka := r.attribute(t, a.ID, name)
+			vb, okb := r.attribute(t, b.ID, name)
+			switch {
+			case !oka || !okb:
+				// false < true: a missing attribute is the smallest value.
+				return dir * compareBool(oka, okb)
+			case filter.SortNumeric:
+				return dir * cmp.Compare(number(va), number(vb))
+			default:
+				return dir * strings.Compare(va, vb)
+			}
+		}
+	default:
+		return fmt.Errorf("%w: unknown sort %q", ErrInvalidFilter, filter.Sort)
+	}
+
+	slices.SortFunc(persons, func(a, b entities.Person) int {
+		if c := compare(a, b); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return nil
+}
+
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case b:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// number converts an encoded value as the database CAST does, 0 when it is
+// not a number.
+func number(v string) float64 {
+	f, _ := strconv.ParseFloat(v, 64)
+	return f
+}
+
+// page returns the page of the persons, all of them when the limit is negative.
+func page(persons []entities.Person, offset int, limit int) []entities.Person {
+	if offset > 0 {
+		if offset >= len(persons) {
+			return nil
+		}
+		persons = persons[offset:]
+	}
+
+	if limit >= 0 && limit < len(persons) {
+		persons = persons[:limit]
+	}
+	return persons
+}
+
+// GetBlocks retrieves, with their phone and address, the persons sharing a
+// blocking key, by key: the keys shared by several persons are taken in
+// order, limit keys after the offset first ones, each with up to size
+// persons.
+func (r *MemoryRepo) GetBlocks(_ context.Context, offset int, limit int, size int) ([][]dto.PersonDTO, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	byKey := map[string][]int{}
+	for id, keys := range r.blockingKeys() {
+		for _, k := range keys {
+			byKey[k] = append(byKey[k], id)
+		}
+	}
+
+	keys := make([]string, 0, len(byKey))
+	for k, ids := range byKey {
+		if len(ids) > 1 {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+
+	offset = min(offset, len(keys))
+	keys = keys[offset:]
+	if limit >= 0 && limit < len(keys) {
+		keys = keys[:limit]
+	}
+
+	blocks := make([][]dto.PersonDTO, len(keys))
+	for i, k := range keys {
+		ids := byKey[k]
+		slices.Sort(ids)
+		if size >= 0 && size < len(ids) {
+			ids = ids[:size]
+		}
+		blocks[i] = r.withDetails(ids)
+	}
+	return blocks, nil
+}
+
+// GetCandidates retrieves, with their phone and address, the persons
+// sharing any of the blocking keys, those sharing the most keys first, up to
+// limit persons.
+func (r *MemoryRepo) GetCandidates(_ context.Context, keys []string, limit int) ([]dto.PersonDTO, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	shared := map[int]int{}
+	for id, pk := range r.blockingKeys() {
+		for _, k := range pk {
+			if slices.Contains(keys, k) {
+				shared[id]++
+			}
+		}
+	}
+
+	ids := make([]int, 0, len(shared))
+	for id := range shared {
+		ids = append(ids, id)
+	}
+	slices.SortFunc(ids, func(a, b int) int {
+		return cmp.Or(cmp.Compare(shared[b], shared[a]), cmp.Compare(a, b))
+	})
+	if limit >= 0 && limit < len(ids) {
+		ids = ids[:limit]
+	}
+	slices.Sort(ids)
+
+	return r.withDetails(ids), nil
+}
+
+// blockingKeys returns the blocking keys of every person, of its name and
+// phone numbers.
+func (r *MemoryRepo) blockingKeys() map[int][]string {
+	phones := make([]entities.Phone, 0, len(r.phones))
+	for _, ph := range r.phones {
+		phones = append(phones, ph)
+	}
+	slices.SortFunc(phones, func(a, b entities.Phone) int { return cmp.Compare(a.ID, b.ID) })
+
+	numbers := map[int][]string{}
+	for _, ph := range phones {
+		numbers[ph.PersonID] = append(numbers[ph.PersonID], ph.Number)
+	}
+
+	keys := make(map[int][]string, len(r.persons))
+	for id, p := range r.persons {
+		keys[id] = dedup.BlockingKeys(p.Name, numbers[id]...)
+	}
+	return keys
+}
+
+// withDetails returns the persons with their first phone and address.
+func (r *MemoryRepo) withDetails(ids []int) []dto.PersonDTO {
+	dtos := make([]dto.PersonDTO, len(ids))
+	for i, id := range ids {
+		p := r.persons[id]
+		ph, addr := r.details(id)
+		dtos[i] = dto.PersonDTO{
+			ID:      p.ID,
+			Name:    p.Name,
+			Age:     p.Age,
+			Number:  ph.Number,
+			State:   addr.State,
+			City:    addr.City,
+			Street1: addr.Street1,
+			Street2: addr.Street2,
+			Zip:     addr.Zip,
+		}
+	}
+	return dtos
+}
+
+// Merge moves the source person phones, addresses, tags, labels and custom
+// attributes to the target person, updates the target with the survivor data
+// then removes the source person.
+func (r *MemoryRepo) Merge(ctx context.Context, targetID int, sourceID int, survivor dto.PersonDTO) (dto.PersonDTO, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	target, ok := r.persons[targetID]
+	if !ok {
+		return dto.PersonDTO{}, ErrRecordNotFound
+	}
+
+	source, ok := r.persons[sourceID]
+	if !ok {
+		return dto.PersonDTO{}, ErrRecordNotFound
+	}
+
+	merge := entities.PersonMerge{
+		ID:       r.nextID(entities.PersonMerge{}.TableName()),
+		SourceID: sourceID,
+		TargetID: targetID,
+		Source:   source,
+		Target:   target,
+		MergedAt: time.Now().UTC(),
+	}
+
+	for id, ph := range r.phones {
+		if ph.PersonID == sourceID {
+			merge.PhoneIDs = append(merge.PhoneIDs, id)
+			ph.PersonID = targetID
+			r.phones[id] = ph
+		}
+	}
+
+	for id, j := range r.joins {
+		if j.PersonID == sourceID {
+			merge.AddressJoinIDs = append(merge.AddressJoinIDs, id)
+			j.PersonID = targetID
+			r.joins[id] = j
+		}
+	}
+
+	merge.TagIDs, merge.Dropped.Tags = mergeRows(r.tags, sourceID, targetID,
+		func(t entities.PersonTag) (int, string) { return t.PersonID, t.Tag },
+		func(t entities.PersonTag, id int) entities.PersonTag { t.PersonID = id; return t })
+	merge.LabelIDs, merge.Dropped.Labels = mergeRows(r.labels, sourceID, targetID,
+		func(l entities.PersonLabel) (int, string) { return l.PersonID, l.Key },
+		func(l entities.PersonLabel, id int) entities.PersonLabel { l.PersonID = id; return l })
+	merge.AttributeIDs, merge.Dropped.Attributes = mergeRows(r.attributes, sourceID, targetID,
+		func(a entities.PersonAttribute) (int, string) { return a.PersonID, a.Tenant + "/" + a.Name },
+		func(a entities.PersonAttribute, id int) entities.PersonAttribute { a.PersonID = id; return a })
+
+	target.Name, target.Age = survivor.Name, survivor.Age
+	r.persons[targetID] = target
+	delete(r.persons, sourceID)
+	r.merges[merge.ID] = merge
+
+	return r.get(ctx, targetID)
+}
+
+// Unmerge reverts a merge recorded after notBefore: the source person is
+// restored with its phones, addresses, tags, labels and custom attributes
+// and the target gets its data back.
+func (r *MemoryRepo) Unmerge(ctx context.Context, targetID int, sourceID int, notBefore time.Time) (dto.PersonDTO, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	merge, ok := r.activeMerge(func(m entities.PersonMerge) bool {
+		return m.TargetID == targetID && m.SourceID == sourceID
+	})
+	if !ok {
+		return dto.PersonDTO{}, ErrRecordNotFound
+	}
+
+	if merge.MergedAt.Before(notBefore) {
+		return dto.PersonDTO{}, ErrMergeExpired
+	}
+
+	target, ok := r.persons[targetID]
+	if !ok {
+		return dto.PersonDTO{}, ErrRecordNotFound
+	}
+
+	if _, ok := r.persons[sourceID]; ok {
+		return dto.PersonDTO{}, fmt.Errorf("failed to restore person data: duplicate id %d", sourceID)
+	}
+	r.persons[sourceID] = merge.Source
+
+	for _, id := range merge.PhoneIDs {
+		if ph, ok := r.phones[id]; ok && ph.PersonID == targetID {
+			ph.PersonID = sourceID
+			r.phones[id] = ph
+		}
+	}
+
+	for _, id := range merge.AddressJoinIDs {
+		if j, ok := r.joins[id]; ok && j.PersonID == targetID {
+			j.PersonID = sourceID
+			r.joins[id] = j
+		}
+	}
+
+	unmergeRows(r.tags, merge.TagIDs, merge.Dropped.Tags, targetID, sourceID,
+		func(t entities.PersonTag) (int, int) { return t.ID, t.PersonID },
+		func(t entities.PersonTag, id int) entities.PersonTag { t.PersonID = id; return t })
+	unmergeRows(r.labels, merge.LabelIDs, merge.Dropped.Labels, targetID, sourceID,
+		func(l entities.PersonLabel) (int, int) { return l.ID, l.PersonID },
+		func(l entities.PersonLabel, id int) entities.PersonLabel { l.PersonID = id; return l })
+	unmergeRows(r.attributes, merge.AttributeIDs, merge.Dropped.Attributes, targetID, sourceID,
+		func(a entities.PersonAttribute) (int, int) { return a.ID, a.PersonID },
+		func(a entities.PersonAttribute, id int) entities.PersonAttribute { a.PersonID = id; return a })
+
+	target.Name, target.Age = merge.Target.Name, merge.Target.Age
+	r.persons[targetID] = target
+
+	now := time.Now().UTC()
+	merge.UnmergedAt = &now
+	r.merges[merge.ID] = merge
+
+	return r.get(ctx, sourceID)
+}
+
+// mergeRows moves the rows of the source person to the target one, but those
+// whose key the target already has, which are deleted. It returns the IDs of
+// the moved rows and the deleted rows.
+func mergeRows[T any](rows map[int]T, sourceID int, targetID int, key func(T) (int, string), move func(T, int) T) ([]int, []T) {
+	has := map[string]bool{}
+	for _, row := range rows {
+		if personID, k := key(row); personID == targetID {
+			has[k] = true
+		}
+	}
+
+	ids := make([]int, 0, len(rows))
+	for id := range rows {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	var (
+		moved   []int
+		dropped []T
+	)
+	for _, id := range ids {
+		row := rows[id]
+		personID, k := key(row)
+		switch {
+		case personID != sourceID:
+		case has[k]:
+			dropped = append(dropped, row)
+			delete(rows, id)
+		default:
+			moved = append(moved, id)
+			rows[id] = move(row, targetID)
+		}
+	}
+	return moved, dropped
+}
+
+// unmergeRows moves the rows merged into the target person back to the
+// source one and restores the deleted rows.
+func unmergeRows[T any](rows map[int]T, moved []int, dropped []T, targetID int, sourceID int, key func(T) (int, int), move func(T, int) T) {
+	for _, id := range moved {
+		if row, ok := rows[id]; ok {
+			if _, personID := key(row); personID == targetID {
+				rows[id] = move(row, sourceID)
+			}
+		}
+	}
+
+	for _, row := range dropped {
+		id, _ := key(row)
+		rows[id] = row
+	}
+}
+
+// activeMerge returns the last merge matching and not unmerged.
+func (r *MemoryRepo) activeMerge(match func(entities.PersonMerge) bool) (entities.PersonMerge, bool) {
+	var (
+		res   entities.PersonMerge
+		found bool
+	)
+	for _, m := range r.merges {
+		if m.UnmergedAt == nil && match(m) && (!found || m.ID > res.ID) {
+			res, found = m, true
+		}
+	}
+	return res, found
+}
+
+// ResolveAlias returns the ID of the person a merged person ID is an alias of.
+func (r *MemoryRepo) ResolveAlias(_ context.Context, id int) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	merge, ok := r.activeMerge(func(m entities.PersonMerge) bool { return m.SourceID == id })
+	if !ok {
+		return 0, ErrRecordNotFound
+	}
+	return merge.TargetID, nil
+}
+
+// AddTags tags a person, already present tags are ignored. It returns all
+// the tags of the person.
+func (r *MemoryRepo) AddTags(ctx context.Context, personID int, tags []string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.persons[personID]; !ok {
+		return nil, ErrRecordNotFound
+	}
+
+	known := map[string]bool{}
+	for _, t := range r.tags {
+		if t.PersonID == personID {
+			known[t.Tag] = true
+		}
+	}
+
+	for _, t := range tags {
+		if known[t] {
+			continue
+		}
+		known[t] = true
+		row := entities.PersonTag{ID: r.nextID(entities.PersonTag{}.TableName()), PersonID: personID, Tag: t}
+		r.tags[row.ID] = row
+	}
+
+	meta, err := r.metadata(ctx, []int{personID})
+	if err != nil {
+		return nil, err
+	}
+	return meta[personID].tags, nil
+}
+
+// RemoveTag removes a tag from a person.
+func (r *MemoryRepo) RemoveTag(_ context.Context, personID int, tag string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	removed := false
+	for id, t := range r.tags {
+		if t.PersonID == personID && t.Tag == tag {
+			delete(r.tags, id)
+			removed = true
+		}
+	}
+
+	if !removed {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetTagCounts retrieves every tag with the number of persons tagged with it.
+func (r *MemoryRepo) GetTagCounts(_ context.Context) ([]dto.TagCountDTO, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	persons := map[string]map[int]bool{}
+	for _, t := range r.tags {
+		if persons[t.Tag] == nil {
+			persons[t.Tag] = map[int]bool{}
+		}
+		persons[t.Tag][t.PersonID] = true
+	}
+
+	counts := make([]dto.TagCountDTO, 0, len(persons))
+	for tag, ids := range persons {
+		counts = append(counts, dto.TagCountDTO{Tag: tag, Count: len(ids)})
+	}
+
+	slices.SortFunc(counts, func(a, b dto.TagCountDTO) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Tag, b.Tag)
+	})
+	return counts, nil
+}
+
+// SetLabel sets the value of a person label.
+func (r *MemoryRepo) SetLabel(_ context.Context, personID int, key string, value string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.persons[personID]; !ok {
+		return ErrRecordNotFound
+	}
+
+	for id, l := range r.labels {
+		if l.PersonID == personID && l.Key == key {
+			l.Value = value
+			r.labels[id] = l
+			return nil
+		}
+	}
+
+	label := entities.PersonLabel{ID: r.nextID(entities.PersonLabel{}.TableName()), PersonID: personID, Key: key, Value: value}
+	r.labels[label.ID] = label
+	return nil
+}
+
+// RemoveLabel removes a label from a person.
+func (r *MemoryRepo) RemoveLabel(_ context.Context, personID int, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	removed := false
+	for id, l := range r.labels {
+		if l.PersonID == personID && l.Key == key {
+			delete(r.labels, id)
+			removed = true
+		}
+	}
+
+	if !removed {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// SetAttributes replaces the custom attributes of a person.
+func (r *MemoryRepo) SetAttributes(ctx context.Context, personID int, values map[string]any) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.persons[personID]; !ok {
+		return ErrRecordNotFound
+	}
+
+	encoded, err := encodeAttributes(values)
+	if err != nil {
+		return err
+	}
+
+	t := tenant.FromContext(ctx)
+	for id, a := range r.attributes {
+		if a.PersonID == personID && a.Tenant == t {
+			delete(r.attributes, id)
+		}
+	}
+
+	r.createAttributes(t, personID, encoded)
+	return nil
+}
+
+func (r *MemoryRepo) createAttributes(t string, personID int, values map[string]string) {
+	for name, value := range values {
+		row := entities.PersonAttribute{ID: r.nextID(entities.PersonAttribute{}.TableName()), PersonID: personID, Tenant: t, Name: name, Value: value}
+		r.attributes[row.ID] = row
+	}
+}
+
+// metadata retrieves the tags, labels and custom attributes of the given
+// persons. The attributes are decoded, as read from the database.
+func (r *MemoryRepo) metadata(ctx context.Context, ids []int) (map[int]personMetadata, error) {
+	meta := map[int]personMetadata{}
+	if len(ids) == 0 {
+		return meta, nil
+	}
+
+	t := tenant.FromContext(ctx)
+	for _, id := range ids {
+		var m personMetadata
+
+		for _, tag := range r.tags {
+			if tag.PersonID == id {
+				m.tags = append(m.tags, tag.Tag)
+			}
+		}
+		slices.Sort(m.tags)
+
+		for _, l := range r.labels {
+			if l.PersonID == id {
+				if m.labels == nil {
+					m.labels = map[string]string{}
+				}
+				m.labels[l.Key] = l.Value
+			}
+		}
+
+		for _, a := range r.attributes {
+			if a.PersonID != id || a.Tenant != t {
+				continue
+			}
+
+			var v any
+			if err := json.Unmarshal([]byte(a.Value), &v); err != nil {
+				return nil, fmt.Errorf("failed to decode attribute %q: %v", a.Name, err)
+			}
+			if m.attributes == nil {
+				m.attributes = map[string]any{}
+			}
+			m.attributes[a.Name] = v
+		}
+
+		meta[id] = m
+	}
+
+	return meta, nil
+}
+
+// encodeAttributes encodes the attribute values as they are stored.
+func encodeAttributes(values map[string]any) (map[string]string, error) {
+	res := make(map[string]string, len(values))
+	for name, v := range values {
+		value, err := encodeAttribute(v)
+		if err != nil {
+			return nil, err
+		}
+		res[name] = value
+	}
+	return res, nil
+}
@@ -0,0 +1,14 @@
+package person_test
+
+import (
+	"qore-be/internal/person"
+	"qore-be/internal/person/persontest"
+
+	"testing"
+)
+
+func TestMemoryRepository(t *testing.T) {
+	persontest.TestRepository(t, func(*testing.T) person.Repository {
+		return person.NewMemoryRepository()
+	})
+}
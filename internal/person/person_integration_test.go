@@ -1,3 +1,258 @@
 package person_test
 
-// TODO.
+import (
+	"context"
+	"qore-be/internal/cache"
+	"qore-be/internal/database"
+	"qore-be/internal/database/databasetest"
+	"qore-be/internal/dedup"
+	"qore-be/internal/domain/dto"
+	"qore-be/internal/domain/entities"
+	"qore-be/internal/person"
+	"qore-be/internal/person/persontest"
+	"time"
+
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestRepository(t *testing.T) {
+	for _, driver := range databasetest.Drivers() {
+		t.Run(driver, func(t *testing.T) {
+			persontest.TestRepository(t, func(t *testing.T) person.Repository {
+				return person.NewRepository(databasetest.Open(t, driver))
+			})
+		})
+	}
+}
+
+func TestRepository_Constraints(t *testing.T) {
+	for _, driver := range databasetest.Drivers() {
+		t.Run(driver, func(t *testing.T) {
+			testConstraints(t, databasetest.Open(t, driver))
+		})
+	}
+}
+
+func TestRepository_GetByIDPartial(t *testing.T) {
+	for _, driver := range databasetest.Drivers() {
+		t.Run(driver, func(t *testing.T) {
+			ctx := context.Background()
+			db := databasetest.Open(t, driver)
+			repo := person.NewRepository(db)
+
+			p, err := repo.Add(ctx, persontest.NewPerson("moe", 45))
+			require.NoError(t, err)
+
+			// the first phone of the person is returned.
+			require.NoError(t, db.Create(&entities.Phone{PersonID: p.ID, Number: "555-0142"}).Error)
+
+			got, err := repo.GetByID(ctx, p.ID)
+			require.NoError(t, err)
+			assert.Equal(t, p, got)
+
+			// a person without phone nor address has them empty.
+			require.NoError(t, db.Where("person_id = ?", p.ID).Delete(&entities.Phone{}).Error)
+			require.NoError(t, db.Where("person_id = ?", p.ID).Delete(&entities.PersonAddress{}).Error)
+
+			got, err = repo.GetByID(ctx, p.ID)
+			require.NoError(t, err)
+			assert.Equal(t, dto.PersonDTO{ID: p.ID, Name: "moe", Age: 45}, got)
+
+			_, err = repo.GetByID(ctx, p.ID+1000)
+			assert.ErrorIs(t, err, person.ErrRecordNotFound)
+		})
+	}
+}
+
+func TestRepository_GetByIDStatements(t *testing.T) {
+	ctx := context.Background()
+	db := databasetest.Open(t, database.DriverSQLite)
+	repo := person.NewRepository(db)
+
+	p, err := repo.Add(ctx, persontest.NewPerson("moe", 45))
+	require.NoError(t, err)
+	_, err = repo.AddTags(ctx, p.ID, []string{"bar", "owner"})
+	require.NoError(t, err)
+	require.NoError(t, repo.SetLabel(ctx, p.ID, "team", "red"))
+
+	// the subqueries are only rendered, in a dry run.
+	statements := 0
+	count := func(tx *gorm.DB) {
+		if !tx.DryRun {
+			statements++
+		}
+	}
+	require.NoError(t, db.Callback().Query().Before("gorm:query").Register("test:count", count))
+	require.NoError(t, db.Callback().Raw().Before("gorm:raw").Register("test:count", count))
+	require.NoError(t, db.Callback().Row().Before("gorm:row").Register("test:count", count))
+
+	// the person, its phone, address and metadata are read at once.
+	got, err := repo.GetByID(ctx, p.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, statements)
+	assert.Equal(t, []string{"bar", "owner"}, got.Tags)
+	assert.Equal(t, map[string]string{"team": "red"}, got.Labels)
+	assert.Equal(t, "555-0100", got.Number)
+}
+
+func TestRepository_Replicas(t *testing.T) {
+	ctx := context.Background()
+	primary := databasetest.Open(t, database.DriverSQLite)
+	replica := databasetest.Open(t, database.DriverSQLite)
+
+	replicas, err := database.NewReplicas(primary, []*gorm.DB{replica})
+	require.NoError(t, err)
+	repo := person.NewRepository(primary, person.WithReplicas(replicas))
+
+	// the writes go to the primary and the lookups to the replica, where the
+	// person is not replicated.
+	p, err := repo.Add(ctx, persontest.NewPerson("apu", 40))
+	require.NoError(t, err)
+
+	_, err = repo.GetByID(ctx, p.ID)
+	assert.ErrorIs(t, err, person.ErrRecordNotFound)
+
+	persons, err := repo.GetAll(ctx, 0, 10, dto.PersonFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, persons)
+
+	got, err := repo.GetByID(database.NewPrimaryContext(ctx), p.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "apu", got.Name)
+
+	blocks, err := repo.GetBlocks(ctx, 0, 10, 10)
+	require.NoError(t, err)
+	assert.Empty(t, blocks)
+
+	// the merged persons are read back from the primary.
+	q, err := repo.Add(ctx, persontest.NewPerson("apu nahasapeemapetilon", 40))
+	require.NoError(t, err)
+
+	merged, err := repo.Merge(ctx, p.ID, q.ID, p)
+	require.NoError(t, err)
+	assert.Equal(t, p.ID, merged.ID)
+
+	unmerged, err := repo.Unmerge(ctx, p.ID, q.ID, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, q.ID, unmerged.ID)
+
+	// the cache loads from the primary, not caching the lagging replica.
+	c, err := cache.NewLRU(10)
+	require.NoError(t, err)
+	cached, err := person.NewCachedRepository(repo, c)
+	require.NoError(t, err)
+
+	got, err = cached.GetByID(ctx, p.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "apu", got.Name)
+}
+
+func TestRepository_IndexBlockingKeys(t *testing.T) {
+	ctx := context.Background()
+	db := databasetest.Open(t, database.DriverSQLite)
+	repo := person.NewRepository(db)
+
+	p, err := repo.Add(ctx, persontest.NewPerson("moe", 45))
+	require.NoError(t, err)
+	q, err := repo.Add(ctx, persontest.NewPerson("barney", 40))
+	require.NoError(t, err)
+
+	// the persons stored before the keys have none.
+	require.NoError(t, db.Where("person_id = ?", q.ID).Delete(&entities.PersonBlockingKey{}).Error)
+	persons, err := repo.GetCandidates(ctx, dedup.BlockingKeys("barney"), 10)
+	require.NoError(t, err)
+	assert.Empty(t, persons)
+
+	n, err := repo.IndexBlockingKeys(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	persons, err = repo.GetCandidates(ctx, dedup.BlockingKeys("barney"), 10)
+	require.NoError(t, err)
+	require.Len(t, persons, 1)
+	assert.Equal(t, q.ID, persons[0].ID)
+
+	n, err = repo.IndexBlockingKeys(ctx, 1)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	persons, err = repo.GetCandidates(ctx, dedup.BlockingKeys("", "555-0100"), 10)
+	require.NoError(t, err)
+	assert.Len(t, persons, 2)
+	assert.Equal(t, p.ID, persons[0].ID)
+}
+
+func TestRepository_MergeRelations(t *testing.T) {
+	for _, driver := range databasetest.Drivers() {
+		t.Run(driver, func(t *testing.T) {
+			ctx := context.Background()
+			db := databasetest.Open(t, driver)
+			repo := person.NewRepository(db)
+
+			target, err := repo.Add(ctx, persontest.NewPerson("carl", 40))
+			require.NoError(t, err)
+			source, err := repo.Add(ctx, persontest.NewPerson("carl carlson", 40))
+			require.NoError(t, err)
+			other, err := repo.Add(ctx, persontest.NewPerson("lenny", 41))
+			require.NoError(t, err)
+
+			rels := []entities.Relationship{
+				{PersonID: source.ID, RelatedID: other.ID, Type: "friend"},
+				{PersonID: other.ID, RelatedID: source.ID, Type: "friend"},
+				{PersonID: source.ID, RelatedID: target.ID, Type: "sibling"},
+			}
+			require.NoError(t, db.Create(&rels).Error)
+
+			relations := func() []entities.Relationship {
+				res := []entities.Relationship{}
+				require.NoError(t, db.Order("id").Find(&res).Error)
+				for i := range res {
+					res[i].ValidFrom, res[i].ValidTo = nil, nil
+				}
+				return res
+			}
+
+			// the relationships follow the person, but those between both
+			// persons which are deleted.
+			_, err = repo.Merge(ctx, target.ID, source.ID, target)
+			require.NoError(t, err)
+			assert.Equal(t, []entities.Relationship{
+				{ID: rels[0].ID, PersonID: target.ID, RelatedID: other.ID, Type: "friend"},
+				{ID: rels[1].ID, PersonID: other.ID, RelatedID: target.ID, Type: "friend"},
+			}, relations())
+
+			_, err = repo.Unmerge(ctx, target.ID, source.ID, time.Now().Add(-time.Hour))
+			require.NoError(t, err)
+			assert.Equal(t, rels, relations())
+		})
+	}
+}
+
+func testConstraints(t *testing.T, db *gorm.DB) {
+	p, err := person.NewRepository(db).Add(context.Background(), persontest.NewPerson("flanders", 60))
+	require.NoError(t, err)
+
+	var join entities.PersonAddress
+	require.NoError(t, db.First(&join, "person_id = ?", p.ID).Error)
+
+	// a person has an address once.
+	err = db.Create(&entities.PersonAddress{PersonID: p.ID, AddressID: join.AddressID}).Error
+	assert.Error(t, err)
+
+	// the phones and addresses of an unknown person are refused.
+	err = db.Create(&entities.Phone{PersonID: p.ID + 1000, Number: "555-0199"}).Error
+	assert.Error(t, err)
+
+	// and deleted with their person.
+	require.NoError(t, db.Delete(&entities.Person{ID: p.ID}).Error)
+
+	var phones, joins int64
+	require.NoError(t, db.Model(&entities.Phone{}).Where("person_id = ?", p.ID).Count(&phones).Error)
+	require.NoError(t, db.Model(&entities.PersonAddress{}).Where("person_id = ?", p.ID).Count(&joins).Error)
+	assert.Zero(t, phones)
+	assert.Zero(t, joins)
+}
@@ -0,0 +1,395 @@
+package persontest
+
+import (
+	"context"
+	"qore-be/internal/dedup"
+	"qore-be/internal/domain/dto"
+	"qore-be/internal/person"
+	"qore-be/internal/tenant"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// NewPerson returns a person with every detail set.
+func NewPerson(name string, age int) dto.PersonDTO {
+	return dto.PersonDTO{
+		Name:    name,
+		Age:     age,
+		Number:  "555-0100",
+		City:    "Springfield",
+		State:   "IL",
+		Street1: "742 Evergreen Terrace",
+		Zip:     "62701",
+	}
+}
+
+// TestRepository runs the conformance tests every person.Repository must
+// pass. newRepo returns an empty repository.
+func TestRepository(t *testing.T, newRepo func(t *testing.T) person.Repository) {
+	ctx := tenant.NewContext(context.Background(), "acme")
+
+	add := func(t *testing.T, repo person.Repository, d dto.PersonDTO) dto.PersonDTO {
+		res, err := repo.Add(ctx, d)
+		require.NoError(t, err)
+		require.NotZero(t, res.ID)
+		return res
+	}
+
+	names := func(persons []dto.PersonDTO) []string {
+		res := []string{}
+		for _, p := range persons {
+			res = append(res, p.Name)
+		}
+		return res
+	}
+
+	t.Run("add and get", func(t *testing.T) {
+		repo := newRepo(t)
+
+		in := NewPerson("homer", 39)
+		in.Attributes = map[string]any{"level": 3}
+		created := add(t, repo, in)
+		assert.Equal(t, map[string]any{"level": 3}, created.Attributes)
+
+		got, err := repo.GetByID(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, dto.PersonDTO{
+			ID:         created.ID,
+			Name:       "homer",
+			Age:        39,
+			Number:     "555-0100",
+			City:       "Springfield",
+			State:      "IL",
+			Street1:    "742 Evergreen Terrace",
+			Zip:        "62701",
+			Attributes: map[string]any{"level": float64(3)},
+		}, got)
+
+		// the attributes are scoped by tenant.
+		got, err = repo.GetByID(context.Background(), created.ID)
+		require.NoError(t, err)
+		assert.Nil(t, got.Attributes)
+
+		_, err = repo.GetByID(ctx, created.ID+1000)
+		assert.ErrorIs(t, err, person.ErrRecordNotFound)
+	})
+
+	t.Run("ids", func(t *testing.T) {
+		repo := newRepo(t)
+
+		first := add(t, repo, NewPerson("marge", 36))
+		second := add(t, repo, NewPerson("bart", 10))
+		assert.Equal(t, first.ID+1, second.ID)
+	})
+
+	t.Run("concurrent adds", func(t *testing.T) {
+		repo := newRepo(t)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := repo.Add(ctx, NewPerson("clone", 1))
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		persons, err := repo.GetAll(ctx, 0, 20, dto.PersonFilter{})
+		require.NoError(t, err)
+		assert.Len(t, persons, 10)
+	})
+
+	t.Run("pagination", func(t *testing.T) {
+		repo := newRepo(t)
+		for _, name := range []string{"a", "b", "c", "d", "e"} {
+			add(t, repo, NewPerson(name, 1))
+		}
+
+		persons, err := repo.GetAll(ctx, 1, 2, dto.PersonFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"b", "c"}, names(persons))
+
+		persons, err = repo.GetAll(ctx, 4, 10, dto.PersonFilter{SortDesc: true})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a"}, names(persons))
+
+		persons, err = repo.GetAll(ctx, 10, 10, dto.PersonFilter{})
+		require.NoError(t, err)
+		assert.Empty(t, persons)
+	})
+
+	t.Run("blocks", func(t *testing.T) {
+		repo := newRepo(t)
+		homer := add(t, repo, NewPerson("homer simpson", 39))
+		marge := add(t, repo, NewPerson("marge simpson", 36))
+		moe := NewPerson("moe szyslak", 50)
+		moe.Number = "555-0199"
+		add(t, repo, moe)
+
+		// the persons sharing their last name, then their phone number.
+		blocks, err := repo.GetBlocks(ctx, 0, 10, 10)
+		require.NoError(t, err)
+		assert.Equal(t, [][]dto.PersonDTO{{homer, marge}, {homer, marge}}, blocks)
+
+		blocks, err = repo.GetBlocks(ctx, 1, 10, 1)
+		require.NoError(t, err)
+		assert.Equal(t, [][]dto.PersonDTO{{homer}}, blocks)
+
+		blocks, err = repo.GetBlocks(ctx, 2, 10, 10)
+		require.NoError(t, err)
+		assert.Empty(t, blocks)
+	})
+
+	t.Run("tags and labels", func(t *testing.T) {
+		repo := newRepo(t)
+		p := add(t, repo, NewPerson("marge", 36))
+		q := add(t, repo, NewPerson("bart", 10))
+
+		tags, err := repo.AddTags(ctx, p.ID, []string{"vip", "blue", "vip"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"blue", "vip"}, tags)
+
+		_, err = repo.AddTags(ctx, q.ID, []string{"vip"})
+		require.NoError(t, err)
+
+		_, err = repo.AddTags(ctx, p.ID+1000, []string{"vip"})
+		assert.ErrorIs(t, err, person.ErrRecordNotFound)
+
+		counts, err := repo.GetTagCounts(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []dto.TagCountDTO{{Tag: "vip", Count: 2}, {Tag: "blue", Count: 1}}, counts)
+
+		require.NoError(t, repo.RemoveTag(ctx, p.ID, "blue"))
+		assert.ErrorIs(t, repo.RemoveTag(ctx, p.ID, "blue"), person.ErrRecordNotFound)
+
+		require.NoError(t, repo.SetLabel(ctx, p.ID, "team", "red"))
+		require.NoError(t, repo.SetLabel(ctx, p.ID, "team", "green"))
+		assert.ErrorIs(t, repo.SetLabel(ctx, p.ID+1000, "team", "red"), person.ErrRecordNotFound)
+
+		got, err := repo.GetByID(ctx, p.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"vip"}, got.Tags)
+		assert.Equal(t, map[string]string{"team": "green"}, got.Labels)
+
+		require.NoError(t, repo.RemoveLabel(ctx, p.ID, "team"))
+		assert.ErrorIs(t, repo.RemoveLabel(ctx, p.ID, "team"), person.ErrRecordNotFound)
+	})
+
+	t.Run("attributes", func(t *testing.T) {
+		repo := newRepo(t)
+		p := add(t, repo, NewPerson("lisa", 8))
+
+		require.NoError(t, repo.SetAttributes(ctx, p.ID, map[string]any{"score": 10, "house": "742"}))
+		require.NoError(t, repo.SetAttributes(ctx, p.ID, map[string]any{"score": 11}))
+		assert.ErrorIs(t, repo.SetAttributes(ctx, p.ID+1000, map[string]any{"score": 1}), person.ErrRecordNotFound)
+
+		got, err := repo.GetByID(ctx, p.ID)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"score": float64(11)}, got.Attributes)
+	})
+
+	t.Run("filter and sort", func(t *testing.T) {
+		repo := newRepo(t)
+
+		ids := map[string]int{}
+		for i, name := range []string{"lisa", "maggie", "abe", "ned"} {
+			ids[name] = add(t, repo, NewPerson(name, 8+i)).ID
+		}
+
+		require.NoError(t, repo.SetAttributes(ctx, ids["lisa"], map[string]any{"score": 10, "house": "742"}))
+		require.NoError(t, repo.SetAttributes(ctx, ids["maggie"], map[string]any{"score": 9, "house": "742"}))
+		require.NoError(t, repo.SetAttributes(ctx, ids["abe"], map[string]any{"score": 100, "house": "10"}))
+
+		_, err := repo.AddTags(ctx, ids["lisa"], []string{"kid", "smart"})
+		require.NoError(t, err)
+		_, err = repo.AddTags(ctx, ids["maggie"], []string{"kid"})
+		require.NoError(t, err)
+
+		cases := []struct {
+			name     string
+			filter   dto.PersonFilter
+			expected []string
+		}{
+			{
+				name:     "all tags",
+				filter:   dto.PersonFilter{Tags: []string{"kid", "smart"}},
+				expected: []string{"lisa"},
+			},
+			{
+				name:     "any tag",
+				filter:   dto.PersonFilter{Tags: []string{"kid", "smart"}, TagMode: dto.TagModeOr},
+				expected: []string{"lisa", "maggie"},
+			},
+			{
+				name:     "attribute",
+				filter:   dto.PersonFilter{Attributes: map[string]any{"house": "742"}},
+				expected: []string{"lisa", "maggie"},
+			},
+			{
+				name:     "attribute and tag",
+				filter:   dto.PersonFilter{Attributes: map[string]any{"house": "742"}, Tags: []string{"smart"}},
+				expected: []string{"lisa"},
+			},
+			{
+				name:     "name",
+				filter:   dto.PersonFilter{Sort: "name"},
+				expected: []string{"abe", "lisa", "maggie", "ned"},
+			},
+			{
+				name:     "age, descending",
+				filter:   dto.PersonFilter{Sort: "age", SortDesc: true},
+				expected: []string{"ned", "abe", "maggie", "lisa"},
+			},
+			{
+				name:     "attribute sorted as text",
+				filter:   dto.PersonFilter{Sort: "attr.score"},
+				expected: []string{"ned", "lisa", "abe", "maggie"},
+			},
+			{
+				name:     "attribute sorted as numbers",
+				filter:   dto.PersonFilter{Sort: "attr.score", SortNumeric: true},
+				expected: []string{"ned", "maggie", "lisa", "abe"},
+			},
+			{
+				name:     "attribute sorted as numbers, descending",
+				filter:   dto.PersonFilter{Sort: "attr.score", SortNumeric: true, SortDesc: true},
+				expected: []string{"abe", "lisa", "maggie", "ned"},
+			},
+		}
+
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				persons, err := repo.GetAll(ctx, 0, 100, tc.filter)
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, names(persons))
+			})
+		}
+
+		_, err = repo.GetAll(ctx, 0, 10, dto.PersonFilter{Sort: "phone"})
+		assert.ErrorIs(t, err, person.ErrInvalidFilter)
+	})
+
+	t.Run("duplicate candidates", func(t *testing.T) {
+		repo := newRepo(t)
+		homer := add(t, repo, NewPerson("Homer Simpson", 39))
+		marge := add(t, repo, dto.PersonDTO{Name: "Marge Simpson", Number: "555-0199"})
+		add(t, repo, dto.PersonDTO{Name: "Ned Flanders", Number: "555-0142"})
+
+		// the persons sharing the most keys come first.
+		keys := dedup.BlockingKeys("Homer Simpson", "555-0100")
+		persons, err := repo.GetCandidates(ctx, keys, 1)
+		require.NoError(t, err)
+		require.Len(t, persons, 1)
+		assert.Equal(t, homer.ID, persons[0].ID)
+		assert.Equal(t, "555-0100", persons[0].Number)
+		assert.Equal(t, "Springfield", persons[0].City)
+
+		persons, err = repo.GetCandidates(ctx, keys, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Homer Simpson", "Marge Simpson"}, names(persons))
+
+		persons, err = repo.GetCandidates(ctx, dedup.BlockingKeys("", "(555) 0142"), 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Ned Flanders"}, names(persons))
+
+		// the keys follow the merges.
+		_, err = repo.Merge(ctx, homer.ID, marge.ID, dto.PersonDTO{Name: "Homer Simpson"})
+		require.NoError(t, err)
+		persons, err = repo.GetCandidates(ctx, dedup.BlockingKeys("", "555-0199"), 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Homer Simpson"}, names(persons))
+
+		persons, err = repo.GetCandidates(ctx, nil, 10)
+		require.NoError(t, err)
+		assert.Empty(t, persons)
+	})
+
+	t.Run("merge and unmerge", func(t *testing.T) {
+		repo := newRepo(t)
+		target := add(t, repo, NewPerson("moe", 45))
+		source := add(t, repo, NewPerson("moe szyslak", 46))
+
+		_, err := repo.Merge(ctx, target.ID, source.ID+1000, dto.PersonDTO{Name: "moe"})
+		assert.ErrorIs(t, err, person.ErrRecordNotFound)
+
+		merged, err := repo.Merge(ctx, target.ID, source.ID, dto.PersonDTO{Name: "moe szyslak", Age: 46})
+		require.NoError(t, err)
+		assert.Equal(t, target.ID, merged.ID)
+		assert.Equal(t, "moe szyslak", merged.Name)
+		assert.Equal(t, 46, merged.Age)
+
+		alias, err := repo.ResolveAlias(ctx, source.ID)
+		require.NoError(t, err)
+		assert.Equal(t, target.ID, alias)
+
+		_, err = repo.GetByID(ctx, source.ID)
+		assert.ErrorIs(t, err, person.ErrRecordNotFound)
+
+		_, err = repo.Unmerge(ctx, target.ID, source.ID, time.Now().Add(time.Hour))
+		assert.ErrorIs(t, err, person.ErrMergeExpired)
+
+		restored, err := repo.Unmerge(ctx, target.ID, source.ID, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, source.ID, restored.ID)
+		assert.Equal(t, "moe szyslak", restored.Name)
+		assert.Equal(t, "555-0100", restored.Number)
+		assert.Equal(t, "Springfield", restored.City)
+
+		got, err := repo.GetByID(ctx, target.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "moe", got.Name)
+		assert.Equal(t, "555-0100", got.Number)
+
+		_, err = repo.ResolveAlias(ctx, source.ID)
+		assert.ErrorIs(t, err, person.ErrRecordNotFound)
+
+		_, err = repo.Unmerge(ctx, target.ID, source.ID, time.Now().Add(-time.Hour))
+		assert.ErrorIs(t, err, person.ErrRecordNotFound)
+	})
+
+	t.Run("merge and unmerge the metadata", func(t *testing.T) {
+		repo := newRepo(t)
+		target := add(t, repo, NewPerson("lenny", 40))
+		source := add(t, repo, NewPerson("lenny leonard", 41))
+
+		_, err := repo.AddTags(ctx, target.ID, []string{"vip"})
+		require.NoError(t, err)
+		require.NoError(t, repo.SetLabel(ctx, target.ID, "team", "blue"))
+		require.NoError(t, repo.SetAttributes(ctx, target.ID, map[string]any{"level": 1}))
+
+		_, err = repo.AddTags(ctx, source.ID, []string{"vip", "plant"})
+		require.NoError(t, err)
+		require.NoError(t, repo.SetLabel(ctx, source.ID, "team", "red"))
+		require.NoError(t, repo.SetLabel(ctx, source.ID, "shift", "night"))
+		require.NoError(t, repo.SetAttributes(ctx, source.ID, map[string]any{"level": 2, "sector": "7G"}))
+
+		// the target keeps its own values.
+		merged, err := repo.Merge(ctx, target.ID, source.ID, dto.PersonDTO{Name: "lenny"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"plant", "vip"}, merged.Tags)
+		assert.Equal(t, map[string]string{"team": "blue", "shift": "night"}, merged.Labels)
+		assert.Equal(t, map[string]any{"level": float64(1), "sector": "7G"}, merged.Attributes)
+
+		counts, err := repo.GetTagCounts(ctx)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []dto.TagCountDTO{{Tag: "plant", Count: 1}, {Tag: "vip", Count: 1}}, counts)
+
+		restored, err := repo.Unmerge(ctx, target.ID, source.ID, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"plant", "vip"}, restored.Tags)
+		assert.Equal(t, map[string]string{"team": "red", "shift": "night"}, restored.Labels)
+		assert.Equal(t, map[string]any{"level": float64(2), "sector": "7G"}, restored.Attributes)
+
+		got, err := repo.GetByID(ctx, target.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"vip"}, got.Tags)
+		assert.Equal(t, map[string]string{"team": "blue"}, got.Labels)
+		assert.Equal(t, map[string]any{"level": float64(1)}, got.Attributes)
+	})
+}
@@ -2,40 +2,85 @@ package person
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"qore-be/internal/database"
+	"qore-be/internal/dedup"
 	"qore-be/internal/domain/dto"
 	"qore-be/internal/domain/entities"
+	"qore-be/internal/tenant"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var (
 	ErrRecordNotFound = fmt.Errorf("not found")
+	ErrMergeExpired   = fmt.Errorf("merge retention window expired")
+	ErrInvalidFilter  = fmt.Errorf("invalid filter")
 )
 
+// attributePrefix prefixes the custom attributes the persons can be filtered and sorted by.
+const attributePrefix = "attr."
+
 // Repo represents the person repository interface.
 type Repo struct {
-	db *gorm.DB
+	db       *gorm.DB
+	replicas *database.Replicas
+	timeout  time.Duration
 }
 
 // NewRepository create a new instance of the person repository.
-func NewRepository(db *gorm.DB) *Repo {
+func NewRepository(db *gorm.DB, opts ...RepoOption) *Repo {
 	if db == nil {
 		panic("nil db")
 	}
 
-	if err := db.AutoMigrate(&entities.Person{}, &entities.Phone{}, &entities.Address{}, &entities.PersonAddress{}); err != nil {
-		panic(err)
+	r := &Repo{
+		db: db,
 	}
 
-	return &Repo{
-		db: db,
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// RepoOption ..
+type RepoOption func(*Repo)
+
+// WithQueryTimeout bounds the queries of every repository call.
+func WithQueryTimeout(d time.Duration) RepoOption {
+	return func(r *Repo) {
+		r.timeout = d
+	}
+}
+
+// WithReplicas sends the lookups, GetByID, GetAll and GetBlocks, to the read
+// replicas.
+func WithReplicas(replicas *database.Replicas) RepoOption {
+	return func(r *Repo) {
+		r.replicas = replicas
 	}
 }
 
+// reader returns the database the lookups read from.
+func (r *Repo) reader(ctx context.Context) *gorm.DB {
+	if r.replicas == nil {
+		return r.db.WithContext(ctx)
+	}
+	return r.replicas.Reader(ctx).WithContext(ctx)
+}
+
 // Add saves new user to the database.
 func (r *Repo) Add(ctx context.Context, d dto.PersonDTO) (dto.PersonDTO, error) {
+	ctx, cancel := database.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
 	pr := entities.Person{
 		Age:  d.Age,
 		Name: d.Name,
@@ -52,7 +97,7 @@ func (r *Repo) Add(ctx context.Context, d dto.PersonDTO) (dto.PersonDTO, error)
 		Street2: d.Street2,
 		Zip:     d.Zip,
 	}
-	err := r.db.Transaction(func(tx *gorm.DB) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		if res := tx.Create(&pr); res != nil && res.Error != nil {
 			return res.Error
 		}
@@ -73,10 +118,15 @@ func (r *Repo) Add(ctx context.Context, d dto.PersonDTO) (dto.PersonDTO, error)
 			return res.Error
 		}
 
-		return nil
+		if err := createBlockingKeys(tx, pr.ID, dedup.BlockingKeys(pr.Name, ph.Number)); err != nil {
+			return err
+		}
+
+		return createAttributes(tx, tenant.FromContext(ctx), pr.ID, d.Attributes)
 	})
 
 	return dto.PersonDTO{
+		ID:      pr.ID,
 		Name:    pr.Name,
 		Age:     pr.Age,
 		Number:  ph.Number,
@@ -85,66 +135,998 @@ func (r *Repo) Add(ctx context.Context, d dto.PersonDTO) (dto.PersonDTO, error)
 		Street1: addr.Street1,
 		Street2: addr.Street2,
 		Zip:     addr.Zip,
+
+		Attributes: d.Attributes,
 	}, err
 }
 
+// personRow is a person along with its phone number and address.
+type personRow struct {
+	ID      int
+	Name    string
+	Age     int
+	Number  string
+	City    string
+	State   string
+	Street1 string
+	Street2 string
+	Zip     string
+}
+
+// detailColumns are the columns of personRow selected by details.
+const detailColumns = "person.id, person.name, person.age, " +
+	"COALESCE(phone.number, '') AS number, " +
+	"COALESCE(address.city, '') AS city, " +
+	"COALESCE(address.state, '') AS state, " +
+	"COALESCE(address.street1, '') AS street1, " +
+	"COALESCE(address.street2, '') AS street2, " +
+	"COALESCE(address.zip, '') AS zip"
+
+// details selects the persons with their first phone and address, if any,
+// as personRow in one round-trip.
+func details(db *gorm.DB) *gorm.DB {
+	return db.Table("person").
+		Select(detailColumns).
+		Joins("LEFT JOIN phone ON phone.id = (SELECT MIN(p.id) FROM phone p WHERE p.person_id = person.id)").
+		Joins("LEFT JOIN address_join ON address_join.id = (SELECT MIN(j.id) FROM address_join j WHERE j.person_id = person.id)").
+		Joins("LEFT JOIN address ON address.id = address_join.address_id")
+}
+
+func (p personRow) dto() dto.PersonDTO {
+	return dto.PersonDTO{
+		ID:      p.ID,
+		Name:    p.Name,
+		Age:     p.Age,
+		Number:  p.Number,
+		State:   p.State,
+		City:    p.City,
+		Street1: p.Street1,
+		Street2: p.Street2,
+		Zip:     p.Zip,
+	}
+}
+
+// personMetadataRow is a person along with one of its tags, labels or custom
+// attributes, if any.
+type personMetadataRow struct {
+	Person personRow   `gorm:"embedded"`
+	Meta   metadataRow `gorm:"embedded"`
+}
+
 // GetByID retrieves a person data by its ID.
 func (r *Repo) GetByID(ctx context.Context, id int) (dto.PersonDTO, error) {
-	pr := &entities.Person{}
-	tx := r.db.WithContext(ctx).Table(pr.TableName()).First(&pr, "id= ?", id)
-	if tx != nil && tx.Error != nil {
-		if errors.Is(tx.Error, gorm.ErrRecordNotFound) {
-			return dto.PersonDTO{}, ErrRecordNotFound
-		}
+	ctx, cancel := database.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	db := r.reader(ctx)
+
+	// the first phone and address of the person, if any, and its metadata,
+	// a row each, in one round-trip.
+	rows := []personMetadataRow{}
+	tx := details(db).
+		Select(detailColumns+", meta.kind, meta.meta_name, meta.meta_value").
+		Joins("LEFT JOIN (?) meta ON meta.person_id = person.id", metadata(db, tenant.FromContext(ctx), []int{id})).
+		Where("person.id = ?", id).
+		Order("meta.kind").Order("meta.meta_name").
+		Find(&rows)
+	if tx.Error != nil {
 		return dto.PersonDTO{}, fmt.Errorf("failed to get person data: %v", tx.Error)
 	}
+	if len(rows) == 0 {
+		return dto.PersonDTO{}, ErrRecordNotFound
+	}
 
-	ph := &entities.Phone{}
-	tx = r.db.WithContext(ctx).Table(ph.TableName()).First(&ph, "person_id= ?", id)
-	if tx != nil && tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
-		return dto.PersonDTO{}, fmt.Errorf("failed to get phone data: %v", tx.Error)
+	meta := personMetadata{}
+	for _, row := range rows {
+		if err := meta.add(row.Meta); err != nil {
+			return dto.PersonDTO{}, err
+		}
 	}
 
-	pAddr := &entities.PersonAddress{}
-	tx = r.db.WithContext(ctx).Table(pAddr.TableName()).First(&pAddr, "person_id= ?", id)
-	if tx != nil && tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
-		return dto.PersonDTO{}, fmt.Errorf("failed to get address_join data: %v", tx.Error)
+	p := rows[0].Person.dto()
+	p.Tags, p.Labels, p.Attributes = meta.tags, meta.labels, meta.attributes
+	return p, nil
+}
+
+// GetAll retrieves person rows matching the filter.
+func (r *Repo) GetAll(ctx context.Context, offset int, limit int, filter dto.PersonFilter) ([]dto.PersonDTO, error) {
+	ctx, cancel := database.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	db := r.reader(ctx)
+	query := db.Table((entities.Person{}).TableName())
+
+	if len(filter.Tags) > 0 {
+		sub := db.Model(&entities.PersonTag{}).Select("person_id").Where("tag IN ?", filter.Tags)
+		if filter.TagMode != dto.TagModeOr {
+			sub = sub.Group("person_id").Having("COUNT(DISTINCT tag) = ?", len(filter.Tags))
+		}
+		query = query.Where("person.id IN (?)", sub)
 	}
 
-	addr := &entities.Address{}
-	tx = r.db.WithContext(ctx).Table(addr.TableName()).First(&addr, "id= ?", pAddr.AddressID)
-	if tx != nil && tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
-		return dto.PersonDTO{}, fmt.Errorf("failed to get address: %v", tx.Error)
+	t := tenant.FromContext(ctx)
+	for name, v := range filter.Attributes {
+		value, err := encodeAttribute(v)
+		if err != nil {
+			return nil, err
+		}
+
+		sub := db.Model(&entities.PersonAttribute{}).Select("person_id").
+			Where("tenant = ? AND name = ? AND value = ?", t, name, value)
+		query = query.Where("person.id IN (?)", sub)
 	}
 
-	return dto.PersonDTO{
-		Name:    pr.Name,
-		Age:     pr.Age,
-		Number:  ph.Number,
-		State:   addr.State,
-		City:    addr.City,
-		Street1: addr.Street1,
-		Street2: addr.Street2,
-		Zip:     addr.Zip,
-	}, nil
-}
+	query, err := r.sort(query, t, filter)
+	if err != nil {
+		return nil, err
+	}
 
-// GetAll retrieves person rows.
-func (r *Repo) GetAll(ctx context.Context, offset int, limit int) ([]dto.PersonDTO, error) {
 	persons := []entities.Person{}
-	tx := r.db.WithContext(ctx).Table((entities.Person{}).TableName()).Offset(offset).Limit(limit).Find(&persons)
+	tx := query.Offset(offset).Limit(limit).Find(&persons)
 	if tx != nil && tx.Error != nil {
 		return nil, fmt.Errorf("failed to get person data: %v", tx.Error)
 	}
 
+	ids := make([]int, len(persons))
+	for i, p := range persons {
+		ids[i] = p.ID
+	}
+
+	meta, err := r.metadata(ctx, db, ids)
+	if err != nil {
+		return nil, err
+	}
+
 	dtos := make([]dto.PersonDTO, len(persons))
-	fmt.Println(len(persons))
 	for i, p := range persons {
 		dtos[i] = dto.PersonDTO{
-			ID:   p.ID,
-			Name: p.Name,
-			Age:  p.Age,
+			ID:         p.ID,
+			Name:       p.Name,
+			Age:        p.Age,
+			Tags:       meta[p.ID].tags,
+			Labels:     meta[p.ID].labels,
+			Attributes: meta[p.ID].attributes,
 		}
 	}
 	return dtos, nil
 }
+
+// numeric casts a text column to a number in the SQL dialect of the database.
+func (r *Repo) numeric(column string) string {
+	switch r.db.Dialector.Name() {
+	case "postgres":
+		return "CAST(" + column + " AS NUMERIC)"
+	case "sqlite":
+		return "CAST(" + column + " AS REAL)"
+	default:
+		return "CAST(" + column + " AS DECIMAL(30,10))"
+	}
+}
+
+// sort orders the person query by a person column or a custom attribute.
+func (r *Repo) sort(query *gorm.DB, tenant string, filter dto.PersonFilter) (*gorm.DB, error) {
+	dir := "ASC"
+	if filter.SortDesc {
+		dir = "DESC"
+	}
+
+	switch {
+	case filter.Sort == "" || filter.Sort == "id":
+		return query.Order("person.id " + dir), nil
+	case filter.Sort == "name" || filter.Sort == "age":
+		return query.Order("person." + filter.Sort + " " + dir).Order("person.id"), nil
+	case strings.HasPrefix(filter.Sort, attributePrefix):
+		name := strings.TrimPrefix(filter.Sort, attributePrefix)
+		column := "sort_attr.value"
+		if filter.SortNumeric {
+			column = r.numeric(column)
+		}
+
+		order := column + " " + dir
+		if r.db.Dialector.Name() == "postgres" {
+			// the persons without the attribute come first, as with MySQL
+			// and SQLite.
+			if filter.SortDesc {
+				order += " NULLS LAST"
+			} else {
+				order += " NULLS FIRST"
+			}
+		}
+
+		return query.
+			Select("person.*").
+			Joins("LEFT JOIN person_attribute sort_attr ON sort_attr.person_id = person.id AND sort_attr.tenant = ? AND sort_attr.name = ?", tenant, name).
+			Order(order).
+			Order("person.id"), nil
+	default:
+		return nil, fmt.Errorf("%w: unknown sort %q", ErrInvalidFilter, filter.Sort)
+	}
+}
+
+// GetBlocks retrieves, with their phone and address, the persons sharing a
+// blocking key, by key: the keys shared by several persons are taken in
+// order, limit keys after the offset first ones, each with up to size
+// persons.
+func (r *Repo) GetBlocks(ctx context.Context, offset int, limit int, size int) ([][]dto.PersonDTO, error) {
+	ctx, cancel := database.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	db := r.reader(ctx)
+
+	var keys []string
+	if err := db.Model(&entities.PersonBlockingKey{}).
+		Group("blocking_key").
+		Having("COUNT(*) > 1").
+		Order("blocking_key").
+		Offset(offset).Limit(limit).
+		Pluck("blocking_key", &keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to get blocking key data: %v", err)
+	}
+	if len(keys) == 0 {
+		return [][]dto.PersonDTO{}, nil
+	}
+
+	members := []entities.PersonBlockingKey{}
+	if err := db.Raw("SELECT person_id, blocking_key FROM ("+
+		"SELECT person_id, blocking_key, ROW_NUMBER() OVER (PARTITION BY blocking_key ORDER BY person_id) AS n "+
+		"FROM person_blocking_key WHERE blocking_key IN ?) k "+
+		"WHERE n <= ? ORDER BY blocking_key, person_id", keys, size).
+		Scan(&members).Error; err != nil {
+		return nil, fmt.Errorf("failed to get blocking key data: %v", err)
+	}
+
+	ids := make([]int, 0, len(members))
+	for _, m := range members {
+		ids = append(ids, m.PersonID)
+	}
+
+	rows := []personRow{}
+	if err := details(db).Where("person.id IN ?", ids).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get person data: %v", err)
+	}
+
+	persons := make(map[int]dto.PersonDTO, len(rows))
+	for _, row := range rows {
+		persons[row.ID] = row.dto()
+	}
+
+	blocks := make([][]dto.PersonDTO, 0, len(keys))
+	for i, m := range members {
+		if i == 0 || m.Key != members[i-1].Key {
+			blocks = append(blocks, nil)
+		}
+		if p, ok := persons[m.PersonID]; ok {
+			blocks[len(blocks)-1] = append(blocks[len(blocks)-1], p)
+		}
+	}
+	return blocks, nil
+}
+
+// GetCandidates retrieves, with their phone and address, the persons
+// sharing any of the blocking keys, those sharing the most keys first, up to
+// limit persons.
+func (r *Repo) GetCandidates(ctx context.Context, keys []string, limit int) ([]dto.PersonDTO, error) {
+	if len(keys) == 0 {
+		return []dto.PersonDTO{}, nil
+	}
+
+	ctx, cancel := database.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	db := r.db.WithContext(ctx)
+
+	var ids []int
+	if err := db.Model(&entities.PersonBlockingKey{}).
+		Where("blocking_key IN ?", keys).
+		Group("person_id").
+		Order("COUNT(*) DESC").Order("person_id").
+		Limit(limit).
+		Pluck("person_id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("failed to get blocking key data: %v", err)
+	}
+	if len(ids) == 0 {
+		return []dto.PersonDTO{}, nil
+	}
+
+	rows := []personRow{}
+	if err := details(db).Where("person.id IN ?", ids).Order("person.id").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get person data: %v", err)
+	}
+
+	dtos := make([]dto.PersonDTO, len(rows))
+	for i, row := range rows {
+		dtos[i] = row.dto()
+	}
+	return dtos, nil
+}
+
+// IndexBlockingKeys creates the blocking keys of the persons which have
+// none, e.g. created before the keys were stored, batch persons at a time.
+// It returns the number of persons indexed.
+func (r *Repo) IndexBlockingKeys(ctx context.Context, batch int) (int, error) {
+	indexed, after := 0, 0
+	for {
+		ids, err := r.indexBatch(ctx, after, batch)
+		if err != nil || len(ids) == 0 {
+			return indexed, err
+		}
+
+		indexed += len(ids)
+		after = ids[len(ids)-1]
+	}
+}
+
+// indexBatch indexes the next persons without keys after the given ID.
+func (r *Repo) indexBatch(ctx context.Context, after int, batch int) ([]int, error) {
+	ctx, cancel := database.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var ids []int
+	err := r.db.WithContext(ctx).Model(&entities.Person{}).
+		Where("id > ?", after).
+		Where("NOT EXISTS (SELECT 1 FROM person_blocking_key k WHERE k.person_id = person.id)").
+		Order("id").Limit(batch).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get person data: %v", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	return ids, r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return indexBlockingKeys(tx, ids...)
+	})
+}
+
+// indexBlockingKeys replaces the blocking keys of the persons with the keys
+// of their name and phone numbers.
+func indexBlockingKeys(tx *gorm.DB, ids ...int) error {
+	persons := []entities.Person{}
+	if err := tx.Where("id IN ?", ids).Find(&persons).Error; err != nil {
+		return fmt.Errorf("failed to get person data: %v", err)
+	}
+
+	phones := []entities.Phone{}
+	if err := tx.Where("person_id IN ?", ids).Order("id").Find(&phones).Error; err != nil {
+		return fmt.Errorf("failed to get phone data: %v", err)
+	}
+
+	numbers := map[int][]string{}
+	for _, ph := range phones {
+		numbers[ph.PersonID] = append(numbers[ph.PersonID], ph.Number)
+	}
+
+	if err := tx.Where("person_id IN ?", ids).Delete(&entities.PersonBlockingKey{}).Error; err != nil {
+		return fmt.Errorf("failed to delete blocking key data: %v", err)
+	}
+
+	for _, p := range persons {
+		if err := createBlockingKeys(tx, p.ID, dedup.BlockingKeys(p.Name, numbers[p.ID]...)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func createBlockingKeys(tx *gorm.DB, personID int, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	rows := make([]entities.PersonBlockingKey, len(keys))
+	for i, k := range keys {
+		rows[i] = entities.PersonBlockingKey{PersonID: personID, Key: k}
+	}
+
+	// another instance may index the same person concurrently.
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error; err != nil {
+		return fmt.Errorf("failed to save blocking key data: %v", err)
+	}
+	return nil
+}
+
+// Merge moves the source person phones, addresses, tags, labels, custom
+// attributes and relationships to the target person, updates the target with
+// the survivor data then removes the source person.
+//
+// The merge is recorded so that the source ID keeps resolving to the target
+// and the merge can be reverted.
+func (r *Repo) Merge(ctx context.Context, targetID int, sourceID int, survivor dto.PersonDTO) (dto.PersonDTO, error) {
+	ctx, cancel := database.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var target, source entities.Person
+		if err := tx.First(&target, "id = ?", targetID).Error; err != nil {
+			return notFoundOr(err, "failed to get person data")
+		}
+
+		if err := tx.First(&source, "id = ?", sourceID).Error; err != nil {
+			return notFoundOr(err, "failed to get person data")
+		}
+
+		merge := entities.PersonMerge{
+			SourceID: sourceID,
+			TargetID: targetID,
+			Source:   source,
+			Target:   target,
+			MergedAt: time.Now().UTC(),
+		}
+
+		if err := tx.Model(&entities.Phone{}).Where("person_id = ?", sourceID).
+			Pluck("id", &merge.PhoneIDs).Error; err != nil {
+			return fmt.Errorf("failed to get phone data: %v", err)
+		}
+
+		if err := tx.Model(&entities.PersonAddress{}).Where("person_id = ?", sourceID).
+			Pluck("id", &merge.AddressJoinIDs).Error; err != nil {
+			return fmt.Errorf("failed to get address_join data: %v", err)
+		}
+
+		if err := tx.Model(&entities.Phone{}).Where("person_id = ?", sourceID).
+			Update("person_id", targetID).Error; err != nil {
+			return fmt.Errorf("failed to move phone data: %v", err)
+		}
+
+		if err := tx.Model(&entities.PersonAddress{}).Where("person_id = ?", sourceID).
+			Update("person_id", targetID).Error; err != nil {
+			return fmt.Errorf("failed to move address_join data: %v", err)
+		}
+
+		if err := moveMetadata(tx, &merge); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&target).Select("name", "age").Updates(entities.Person{
+			Name: survivor.Name,
+			Age:  survivor.Age,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to update person data: %v", err)
+		}
+
+		if err := tx.Delete(&source).Error; err != nil {
+			return fmt.Errorf("failed to delete person data: %v", err)
+		}
+
+		if err := indexBlockingKeys(tx, targetID); err != nil {
+			return err
+		}
+
+		if err := tx.Create(&merge).Error; err != nil {
+			return fmt.Errorf("failed to save merge data: %v", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return dto.PersonDTO{}, err
+	}
+
+	// the replicas may not have the merge yet.
+	return r.GetByID(database.NewPrimaryContext(ctx), targetID)
+}
+
+// Unmerge reverts a merge recorded after notBefore: the source person is
+// restored with its phones, addresses, tags, labels, custom attributes and
+// relationships and the target gets its data back.
+func (r *Repo) Unmerge(ctx context.Context, targetID int, sourceID int, notBefore time.Time) (dto.PersonDTO, error) {
+	ctx, cancel := database.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var merge entities.PersonMerge
+		if err := tx.Where("target_id = ? AND source_id = ? AND unmerged_at IS NULL", targetID, sourceID).
+			Order("id DESC").First(&merge).Error; err != nil {
+			return notFoundOr(err, "failed to get merge data")
+		}
+
+		if merge.MergedAt.Before(notBefore) {
+			return ErrMergeExpired
+		}
+
+		if err := tx.First(&entities.Person{}, "id = ?", targetID).Error; err != nil {
+			return notFoundOr(err, "failed to get person data")
+		}
+
+		source := merge.Source
+		if err := tx.Create(&source).Error; err != nil {
+			return fmt.Errorf("failed to restore person data: %v", err)
+		}
+
+		if len(merge.PhoneIDs) > 0 {
+			if err := tx.Model(&entities.Phone{}).Where("id IN ? AND person_id = ?", merge.PhoneIDs, targetID).
+				Update("person_id", sourceID).Error; err != nil {
+				return fmt.Errorf("failed to restore phone data: %v", err)
+			}
+		}
+
+		if len(merge.AddressJoinIDs) > 0 {
+			if err := tx.Model(&entities.PersonAddress{}).Where("id IN ? AND person_id = ?", merge.AddressJoinIDs, targetID).
+				Update("person_id", sourceID).Error; err != nil {
+				return fmt.Errorf("failed to restore address_join data: %v", err)
+			}
+		}
+
+		if err := restoreMetadata(tx, merge); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&entities.Person{ID: targetID}).Select("name", "age").Updates(entities.Person{
+			Name: merge.Target.Name,
+			Age:  merge.Target.Age,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to restore person data: %v", err)
+		}
+
+		if err := indexBlockingKeys(tx, sourceID, targetID); err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		if err := tx.Model(&merge).Update("unmerged_at", &now).Error; err != nil {
+			return fmt.Errorf("failed to save merge data: %v", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return dto.PersonDTO{}, err
+	}
+
+	return r.GetByID(database.NewPrimaryContext(ctx), sourceID)
+}
+
+// moveMetadata moves the tags, labels, custom attributes and relationships
+// of the source person to the target one, recording them in the merge. Those
+// the target already has, and the relationships between both persons, are
+// deleted: the merge records them whole.
+func moveMetadata(tx *gorm.DB, merge *entities.PersonMerge) error {
+	var err error
+
+	merge.TagIDs, merge.Dropped.Tags, err = partition(tx, merge.SourceID, merge.TargetID,
+		func(t entities.PersonTag) (int, string) { return t.ID, t.Tag })
+	if err != nil {
+		return fmt.Errorf("failed to get tag data: %v", err)
+	}
+
+	merge.LabelIDs, merge.Dropped.Labels, err = partition(tx, merge.SourceID, merge.TargetID,
+		func(l entities.PersonLabel) (int, string) { return l.ID, l.Key })
+	if err != nil {
+		return fmt.Errorf("failed to get label data: %v", err)
+	}
+
+	merge.AttributeIDs, merge.Dropped.Attributes, err = partition(tx, merge.SourceID, merge.TargetID,
+		func(a entities.PersonAttribute) (int, string) { return a.ID, a.Tenant + "/" + a.Name })
+	if err != nil {
+		return fmt.Errorf("failed to get attribute data: %v", err)
+	}
+
+	source, target := merge.SourceID, merge.TargetID
+	if err := tx.Where("(person_id = ? AND related_id = ?) OR (person_id = ? AND related_id = ?)", source, target, target, source).
+		Order("id").Find(&merge.Dropped.Relations).Error; err != nil {
+		return fmt.Errorf("failed to get relationship data: %v", err)
+	}
+
+	if err := tx.Model(&entities.Relationship{}).Where("person_id = ? AND related_id <> ?", source, target).
+		Order("id").Pluck("id", &merge.RelationIDs).Error; err != nil {
+		return fmt.Errorf("failed to get relationship data: %v", err)
+	}
+
+	if err := tx.Model(&entities.Relationship{}).Where("related_id = ? AND person_id <> ?", source, target).
+		Order("id").Pluck("id", &merge.RelatedIDs).Error; err != nil {
+		return fmt.Errorf("failed to get relationship data: %v", err)
+	}
+
+	for _, m := range movedRows(*merge) {
+		if err := m.move(tx, source, target); err != nil {
+			return err
+		}
+	}
+
+	if err := deleteRows(tx, merge.Dropped.Tags); err != nil {
+		return err
+	}
+	if err := deleteRows(tx, merge.Dropped.Labels); err != nil {
+		return err
+	}
+	if err := deleteRows(tx, merge.Dropped.Attributes); err != nil {
+		return err
+	}
+	return deleteRows(tx, merge.Dropped.Relations)
+}
+
+// restoreMetadata moves the tags, labels, custom attributes and
+// relationships recorded by the merge back to the source person and
+// restores the deleted ones.
+func restoreMetadata(tx *gorm.DB, merge entities.PersonMerge) error {
+	for _, m := range movedRows(merge) {
+		if err := m.move(tx, merge.TargetID, merge.SourceID); err != nil {
+			return err
+		}
+	}
+
+	if err := createRows(tx, merge.Dropped.Tags); err != nil {
+		return err
+	}
+	if err := createRows(tx, merge.Dropped.Labels); err != nil {
+		return err
+	}
+	if err := createRows(tx, merge.Dropped.Attributes); err != nil {
+		return err
+	}
+	return createRows(tx, merge.Dropped.Relations)
+}
+
+// partition splits the rows of the source person into those to move, by ID,
+// and those whose key the target person already has.
+func partition[T any](tx *gorm.DB, sourceID int, targetID int, key func(T) (int, string)) ([]int, []T, error) {
+	var rows, targets []T
+	if err := tx.Where("person_id = ?", sourceID).Order("id").Find(&rows).Error; err != nil {
+		return nil, nil, err
+	}
+	if err := tx.Where("person_id = ?", targetID).Find(&targets).Error; err != nil {
+		return nil, nil, err
+	}
+
+	has := map[string]bool{}
+	for _, t := range targets {
+		_, k := key(t)
+		has[k] = true
+	}
+
+	var (
+		ids     []int
+		dropped []T
+	)
+	for _, row := range rows {
+		id, k := key(row)
+		if has[k] {
+			dropped = append(dropped, row)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids, dropped, nil
+}
+
+// movedRow are the rows of a table moved by a merge, whose column holds the
+// merged person.
+type movedRow struct {
+	model  any
+	column string
+	ids    []int
+}
+
+func movedRows(merge entities.PersonMerge) []movedRow {
+	return []movedRow{
+		{&entities.PersonTag{}, "person_id", merge.TagIDs},
+		{&entities.PersonLabel{}, "person_id", merge.LabelIDs},
+		{&entities.PersonAttribute{}, "person_id", merge.AttributeIDs},
+		{&entities.Relationship{}, "person_id", merge.RelationIDs},
+		{&entities.Relationship{}, "related_id", merge.RelatedIDs},
+	}
+}
+
+func (m movedRow) move(tx *gorm.DB, from int, to int) error {
+	if len(m.ids) == 0 {
+		return nil
+	}
+
+	res := tx.Model(m.model).Where("id IN ? AND "+m.column+" = ?", m.ids, from).Update(m.column, to)
+	if res.Error != nil {
+		return fmt.Errorf("failed to move %s data: %v", res.Statement.Table, res.Error)
+	}
+	return nil
+}
+
+func deleteRows[T any](tx *gorm.DB, rows []T) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	res := tx.Delete(&rows)
+	if res.Error != nil {
+		return fmt.Errorf("failed to delete %s data: %v", res.Statement.Table, res.Error)
+	}
+	return nil
+}
+
+func createRows[T any](tx *gorm.DB, rows []T) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	res := tx.Create(&rows)
+	if res.Error != nil {
+		return fmt.Errorf("failed to restore %s data: %v", res.Statement.Table, res.Error)
+	}
+	return nil
+}
+
+// ResolveAlias returns the ID of the person the given ID was merged into.
+func (r *Repo) ResolveAlias(ctx context.Context, id int) (int, error) {
+	ctx, cancel := database.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var merge entities.PersonMerge
+	err := r.db.WithContext(ctx).Where("source_id = ? AND unmerged_at IS NULL", id).
+		Order("id DESC").First(&merge).Error
+	if err != nil {
+		return 0, notFoundOr(err, "failed to get merge data")
+	}
+
+	return merge.TargetID, nil
+}
+
+func notFoundOr(err error, msg string) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrRecordNotFound
+	}
+	return fmt.Errorf("%s: %v", msg, err)
+}
+
+// AddTags tags a person, already present tags are ignored. It returns all
+// the tags of the person.
+func (r *Repo) AddTags(ctx context.Context, personID int, tags []string) ([]string, error) {
+	ctx, cancel := database.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&entities.Person{}, "id = ?", personID).Error; err != nil {
+			return notFoundOr(err, "failed to get person data")
+		}
+
+		known := map[string]bool{}
+		rows := []entities.PersonTag{}
+		for _, t := range tags {
+			if known[t] {
+				continue
+			}
+			known[t] = true
+			rows = append(rows, entities.PersonTag{PersonID: personID, Tag: t})
+		}
+
+		if len(rows) == 0 {
+			return nil
+		}
+
+		// the tags the person has, maybe added concurrently, are left as is.
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error; err != nil {
+			return fmt.Errorf("failed to save tag data: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	meta, err := r.metadata(ctx, r.db.WithContext(ctx), []int{personID})
+	if err != nil {
+		return nil, err
+	}
+	return meta[personID].tags, nil
+}
+
+// RemoveTag removes a tag from a person.
+func (r *Repo) RemoveTag(ctx context.Context, personID int, tag string) error {
+	ctx, cancel := database.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx := r.db.WithContext(ctx).Where("person_id = ? AND tag = ?", personID, tag).Delete(&entities.PersonTag{})
+	if tx.Error != nil {
+		return fmt.Errorf("failed to delete tag data: %v", tx.Error)
+	}
+
+	if tx.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetTagCounts retrieves every tag with the number of persons tagged with it.
+func (r *Repo) GetTagCounts(ctx context.Context) ([]dto.TagCountDTO, error) {
+	ctx, cancel := database.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	counts := []dto.TagCountDTO{}
+	tx := r.db.WithContext(ctx).Model(&entities.PersonTag{}).
+		Select("tag, COUNT(DISTINCT person_id) AS count").
+		Group("tag").
+		Order("count DESC, tag").
+		Scan(&counts)
+	if tx.Error != nil {
+		return nil, fmt.Errorf("failed to get tag data: %v", tx.Error)
+	}
+
+	return counts, nil
+}
+
+// SetLabel sets the value of a person label.
+func (r *Repo) SetLabel(ctx context.Context, personID int, key string, value string) error {
+	ctx, cancel := database.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&entities.Person{}, "id = ?", personID).Error; err != nil {
+			return notFoundOr(err, "failed to get person data")
+		}
+
+		label := entities.PersonLabel{PersonID: personID, Key: key, Value: value}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "person_id"}, {Name: "key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"value"}),
+		}).Create(&label).Error; err != nil {
+			return fmt.Errorf("failed to save label data: %v", err)
+		}
+
+		return nil
+	})
+}
+
+// RemoveLabel removes a label from a person.
+func (r *Repo) RemoveLabel(ctx context.Context, personID int, key string) error {
+	ctx, cancel := database.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx := r.db.WithContext(ctx).Where(&entities.PersonLabel{PersonID: personID, Key: key}).Delete(&entities.PersonLabel{})
+	if tx.Error != nil {
+		return fmt.Errorf("failed to delete label data: %v", tx.Error)
+	}
+
+	if tx.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// SetAttributes replaces the custom attributes of a person.
+func (r *Repo) SetAttributes(ctx context.Context, personID int, values map[string]any) error {
+	ctx, cancel := database.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	t := tenant.FromContext(ctx)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&entities.Person{}, "id = ?", personID).Error; err != nil {
+			return notFoundOr(err, "failed to get person data")
+		}
+
+		if err := tx.Where("tenant = ? AND person_id = ?", t, personID).
+			Delete(&entities.PersonAttribute{}).Error; err != nil {
+			return fmt.Errorf("failed to delete attribute data: %v", err)
+		}
+
+		return createAttributes(tx, t, personID, values)
+	})
+}
+
+type personMetadata struct {
+	tags       []string
+	labels     map[string]string
+	attributes map[string]any
+}
+
+// metadataRow is a tag, label or custom attribute of a person, as selected
+// by metadata. Its columns are null for a person without any.
+type metadataRow struct {
+	PersonID  int
+	Kind      *string
+	MetaName  *string
+	MetaValue *string
+}
+
+// Metadata kinds of metadataRow.
+const (
+	metadataTag       = "tag"
+	metadataLabel     = "label"
+	metadataAttribute = "attribute"
+)
+
+func (m *personMetadata) add(row metadataRow) error {
+	if row.Kind == nil {
+		return nil
+	}
+
+	name, value := *row.MetaName, ""
+	if row.MetaValue != nil {
+		value = *row.MetaValue
+	}
+
+	switch *row.Kind {
+	case metadataTag:
+		m.tags = append(m.tags, name)
+	case metadataLabel:
+		if m.labels == nil {
+			m.labels = map[string]string{}
+		}
+		m.labels[name] = value
+	case metadataAttribute:
+		var v any
+		if err := json.Unmarshal([]byte(value), &v); err != nil {
+			return fmt.Errorf("failed to decode attribute %q: %v", name, err)
+		}
+		if m.attributes == nil {
+			m.attributes = map[string]any{}
+		}
+		m.attributes[name] = v
+	}
+	return nil
+}
+
+// metadata selects the tags, labels and custom attributes of the given
+// persons as metadataRow, in one statement. The kinds are literals: the type
+// of a parameter selected by a union cannot be told by PostgreSQL.
+func metadata(db *gorm.DB, tenant string, ids []int) *gorm.DB {
+	tags := db.Model(&entities.PersonTag{}).
+		Select("person_id, '"+metadataTag+"' AS kind, tag AS meta_name, NULL AS meta_value").
+		Where("person_id IN ?", ids)
+	labels := db.Model(&entities.PersonLabel{}).
+		Select("person_id, '"+metadataLabel+"' AS kind, ? AS meta_name, value AS meta_value", clause.Column{Name: "key"}).
+		Where("person_id IN ?", ids)
+	attributes := db.Model(&entities.PersonAttribute{}).
+		Select("person_id, '"+metadataAttribute+"' AS kind, name AS meta_name, value AS meta_value").
+		Where("tenant = ? AND person_id IN ?", tenant, ids)
+
+	return db.Raw("? UNION ALL ? UNION ALL ?", tags, labels, attributes)
+}
+
+// metadata retrieves the tags, labels and custom attributes of the given persons from db.
+func (r *Repo) metadata(ctx context.Context, db *gorm.DB, ids []int) (map[int]personMetadata, error) {
+	meta := map[int]personMetadata{}
+	if len(ids) == 0 {
+		return meta, nil
+	}
+
+	rows := []metadataRow{}
+	if err := db.Table("(?) meta", metadata(db, tenant.FromContext(ctx), ids)).
+		Order("kind").Order("meta_name").
+		Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get metadata: %v", err)
+	}
+
+	for _, row := range rows {
+		m := meta[row.PersonID]
+		if err := m.add(row); err != nil {
+			return nil, err
+		}
+		meta[row.PersonID] = m
+	}
+
+	return meta, nil
+}
+
+func createAttributes(tx *gorm.DB, tenant string, personID int, values map[string]any) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	rows := make([]entities.PersonAttribute, 0, len(values))
+	for name, v := range values {
+		value, err := encodeAttribute(v)
+		if err != nil {
+			return err
+		}
+
+		rows = append(rows, entities.PersonAttribute{
+			PersonID: personID,
+			Tenant:   tenant,
+			Name:     name,
+			Value:    value,
+		})
+	}
+
+	if err := tx.Create(&rows).Error; err != nil {
+		return fmt.Errorf("failed to save attribute data: %v", err)
+	}
+	return nil
+}
+
+// encodeAttribute returns the canonical JSON encoding of an attribute value,
+// the one values are stored and compared with.
+func encodeAttribute(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode attribute: %v", err)
+	}
+	return string(data), nil
+}
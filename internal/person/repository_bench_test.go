@@ -0,0 +1,44 @@
+package person_test
+
+import (
+	"context"
+	"qore-be/internal/database/databasetest"
+	"qore-be/internal/domain/dto"
+	"qore-be/internal/person"
+	"qore-be/internal/person/persontest"
+
+	"testing"
+)
+
+func BenchmarkRepo_GetByID(b *testing.B) {
+	for _, driver := range databasetest.Drivers() {
+		b.Run(driver, func(b *testing.B) {
+			ctx := context.Background()
+			repo := person.NewRepository(databasetest.Open(b, driver))
+
+			p, err := repo.Add(ctx, persontest.NewPerson("nelson", 12))
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			paths := []struct {
+				name    string
+				getByID func(context.Context, int) (dto.PersonDTO, error)
+			}{
+				{name: "sequential", getByID: repo.GetByIDSequential},
+				{name: "joined", getByID: repo.GetByID},
+			}
+
+			for _, path := range paths {
+				b.Run(path.name, func(b *testing.B) {
+					b.ReportAllocs()
+					for i := 0; i < b.N; i++ {
+						if _, err := path.getByID(ctx, p.ID); err != nil {
+							b.Fatal(err)
+						}
+					}
+				})
+			}
+		})
+	}
+}
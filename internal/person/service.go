@@ -2,26 +2,157 @@ package person
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log/slog"
+	"qore-be/internal/attribute"
+	"qore-be/internal/dedup"
 	"qore-be/internal/domain/dto"
+	"qore-be/internal/feature"
+	"qore-be/internal/logging"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
+// DuplicateMode tells what to do when a created person possibly duplicates a stored one.
+type DuplicateMode string
+
+const (
+	// DuplicateModeOff disables the duplicate detection.
+	DuplicateModeOff DuplicateMode = "off"
+	// DuplicateModeWarn creates the person and returns the possible duplicates alongside.
+	DuplicateModeWarn DuplicateMode = "warn"
+	// DuplicateModeBlock refuses to create the person when possible duplicates exist.
+	DuplicateModeBlock DuplicateMode = "block"
+)
+
+var (
+	ErrDuplicatePerson = fmt.Errorf("possible duplicate person")
+	ErrInvalidMerge    = fmt.Errorf("invalid merge")
+	ErrPersonMerged    = fmt.Errorf("person merged")
+	ErrInvalidTag      = fmt.Errorf("invalid tag")
+	ErrInvalidLabel    = fmt.Errorf("invalid label")
+)
+
+// DuplicateError is returned when a person creation is blocked by possible duplicates.
+type DuplicateError struct {
+	Candidates []dto.DuplicateDTO
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("%v: %d candidate(s)", ErrDuplicatePerson, len(e.Candidates))
+}
+
+func (e *DuplicateError) Unwrap() error {
+	return ErrDuplicatePerson
+}
+
+// MergedError is returned when the requested person was merged into another one.
+type MergedError struct {
+	ID       int
+	TargetID int
+}
+
+func (e *MergedError) Error() string {
+	return fmt.Sprintf("%v: %d into %d", ErrPersonMerged, e.ID, e.TargetID)
+}
+
+func (e *MergedError) Unwrap() error {
+	return ErrPersonMerged
+}
+
+// Survivorship rules applied to a person field when merging two persons.
+const (
+	RuleTarget   = "target"
+	RuleSource   = "source"
+	RuleNonEmpty = "non_empty"
+)
+
+// Tags and label keys length limit.
+const (
+	maxTagLength   = 64
+	maxLabelLength = 255
+)
+
+// maxDuplicateCandidates bounds the stored persons a created person is
+// compared to.
+const maxDuplicateCandidates = 200
+
+// maxBlockSize bounds the persons sharing a blocking key compared to each
+// other by the duplicates report.
+const maxBlockSize = 200
+
+// maxAliasHops bounds the resolution of aliases of persons merged several times.
+const maxAliasHops = 16
+
 // Repository the person repository.
 //
 //go:generate mockery --name=Repository --structname=PersonRepository  --case underscore --output=../mocks/ --filename=person_repository.go
 type Repository interface {
 	Add(context.Context, dto.PersonDTO) (dto.PersonDTO, error)
 	GetByID(context.Context, int) (dto.PersonDTO, error)
-	GetAll(context.Context, int, int) ([]dto.PersonDTO, error)
+	GetAll(context.Context, int, int, dto.PersonFilter) ([]dto.PersonDTO, error)
+	GetBlocks(context.Context, int, int, int) ([][]dto.PersonDTO, error)
+	GetCandidates(context.Context, []string, int) ([]dto.PersonDTO, error)
+	Merge(context.Context, int, int, dto.PersonDTO) (dto.PersonDTO, error)
+	Unmerge(context.Context, int, int, time.Time) (dto.PersonDTO, error)
+	ResolveAlias(context.Context, int) (int, error)
+	AddTags(context.Context, int, []string) ([]string, error)
+	RemoveTag(context.Context, int, string) error
+	GetTagCounts(context.Context) ([]dto.TagCountDTO, error)
+	SetLabel(context.Context, int, string, string) error
+	RemoveLabel(context.Context, int, string) error
+	SetAttributes(context.Context, int, map[string]any) error
 }
 
+// AttributeSchema validates the custom attributes of persons against the
+// definitions of the tenant held by the context.
+//
+//go:generate mockery --name=AttributeSchema --structname=PersonAttributeSchema  --case underscore --output=../mocks/ --filename=person_attribute_schema.go
+type AttributeSchema interface {
+	Validate(context.Context, map[string]any) (map[string]any, error)
+	ParseQuery(context.Context, string, string) (any, bool, error)
+}
+
+// Metrics records the outcome of the person operations.
+type Metrics interface {
+	PersonOperation(op string, result string)
+}
+
+// Person operations and results reported to the metrics.
+const (
+	opCreate = "create"
+	opRead   = "read"
+
+	resultSuccess   = "success"
+	resultNotFound  = "not_found"
+	resultDuplicate = "duplicate"
+	resultError     = "error"
+)
+
+type nopMetrics struct{}
+
+func (nopMetrics) PersonOperation(string, string) {}
+
 // ServiceImpl implements the person service.
 type ServiceImpl struct {
-	db  Repository
-	log *slog.Logger
+	db             Repository
+	metrics        Metrics
+	tracer         trace.Tracer
+	dupMode        DuplicateMode
+	matcher        *dedup.Matcher
+	mergeRetention time.Duration
+	attrs          AttributeSchema
+	features       *feature.Flags
 }
 
+const (
+	defaultDuplicateThreshold = 0.85
+	defaultMergeRetention     = 30 * 24 * time.Hour
+)
+
 // ServiceOption ..
 type ServiceOption func(*ServiceImpl) error
 
@@ -40,8 +171,24 @@ func NewService(opts ...ServiceOption) (*ServiceImpl, error) {
 		return nil, fmt.Errorf("missing person DB")
 	}
 
-	if svc.log == nil {
-		svc.log = slog.Default()
+	if svc.dupMode == "" {
+		svc.dupMode = DuplicateModeOff
+	}
+
+	if svc.matcher == nil {
+		svc.matcher = dedup.NewMatcher(defaultDuplicateThreshold)
+	}
+
+	if svc.mergeRetention == 0 {
+		svc.mergeRetention = defaultMergeRetention
+	}
+
+	if svc.metrics == nil {
+		svc.metrics = nopMetrics{}
+	}
+
+	if svc.tracer == nil {
+		svc.tracer = noop.NewTracerProvider().Tracer("")
 	}
 
 	return svc, nil
@@ -55,39 +202,517 @@ func WithRepository(db Repository) ServiceOption {
 	}
 }
 
+// WithDuplicatePolicy returns a closure that initialize the person-service duplicate detection.
+func WithDuplicatePolicy(mode DuplicateMode, threshold float64) ServiceOption {
+	return func(svc *ServiceImpl) error {
+		switch mode {
+		case DuplicateModeOff, DuplicateModeWarn, DuplicateModeBlock:
+		default:
+			return fmt.Errorf("invalid duplicate mode: %q", mode)
+		}
+
+		if threshold <= 0 || threshold > 1 {
+			return fmt.Errorf("invalid duplicate threshold: %v", threshold)
+		}
+
+		svc.dupMode = mode
+		svc.matcher = dedup.NewMatcher(threshold)
+		return nil
+	}
+}
+
+// WithMergeRetention returns a closure that initialize the window during which a merge can be reverted.
+func WithMergeRetention(d time.Duration) ServiceOption {
+	return func(svc *ServiceImpl) error {
+		if d <= 0 {
+			return fmt.Errorf("invalid merge retention: %v", d)
+		}
+		svc.mergeRetention = d
+		return nil
+	}
+}
+
+// WithFeatures returns a closure that initialize the person-service feature
+// flags: the duplicate detection is skipped while the duplicates feature is off.
+func WithFeatures(f *feature.Flags) ServiceOption {
+	return func(svc *ServiceImpl) error {
+		if f == nil {
+			return fmt.Errorf("nil feature flags")
+		}
+		svc.features = f
+		return nil
+	}
+}
+
+// WithAttributeSchema returns a closure that initialize the person-service custom attributes schema.
+func WithAttributeSchema(attrs AttributeSchema) ServiceOption {
+	return func(svc *ServiceImpl) error {
+		if attrs == nil {
+			return fmt.Errorf("nil attribute schema")
+		}
+		svc.attrs = attrs
+		return nil
+	}
+}
+
+// WithMetrics returns a closure that initialize the person-service metrics.
+func WithMetrics(m Metrics) ServiceOption {
+	return func(svc *ServiceImpl) error {
+		if m == nil {
+			return fmt.Errorf("nil metrics")
+		}
+		svc.metrics = m
+		return nil
+	}
+}
+
+// WithTracerProvider returns a closure that initialize the person-service tracer.
+func WithTracerProvider(tp trace.TracerProvider) ServiceOption {
+	return func(svc *ServiceImpl) error {
+		if tp == nil {
+			return fmt.Errorf("nil tracer provider")
+		}
+		svc.tracer = tp.Tracer("qore-be/person")
+		return nil
+	}
+}
+
 // Create saves a new person to database.
-func (s *ServiceImpl) Create(ctx context.Context, d dto.PersonDTO) (dto.PersonDTO, error) {
+//
+// Depending on the duplicate mode, the possible duplicates of the person are
+// either returned alongside the created person or block the creation. They
+// are not looked for while the duplicates feature is off.
+func (s *ServiceImpl) Create(ctx context.Context, d dto.PersonDTO) (res dto.CreatedPersonDTO, err error) {
+	ctx, span := s.tracer.Start(ctx, "person.Create")
+	defer span.End()
+
+	defer func() {
+		switch {
+		case err == nil:
+			s.metrics.PersonOperation(opCreate, resultSuccess)
+		case errors.Is(err, ErrDuplicatePerson):
+			s.metrics.PersonOperation(opCreate, resultDuplicate)
+		default:
+			s.metrics.PersonOperation(opCreate, resultError)
+		}
+	}()
+
+	attrs, err := s.validateAttributes(ctx, d.Attributes)
+	if err != nil {
+		return dto.CreatedPersonDTO{}, err
+	}
+	d.Attributes = attrs
+
+	var candidates []dto.DuplicateDTO
+	if s.dupMode != DuplicateModeOff && s.enabled(feature.Duplicates) {
+		pool, err := s.db.GetCandidates(ctx, dedup.BlockingKeys(d.Name, d.Number), maxDuplicateCandidates)
+		if err != nil {
+			logging.FromContext(ctx).Error("failed to look for duplicates", "error", err.Error())
+			return dto.CreatedPersonDTO{}, err
+		}
+
+		candidates = s.matcher.Match(d, pool)
+		if len(candidates) > 0 && s.dupMode == DuplicateModeBlock {
+			logging.FromContext(ctx).Warn("person creation blocked by possible duplicates", "candidates", len(candidates))
+			return dto.CreatedPersonDTO{}, &DuplicateError{Candidates: candidates}
+		}
+	}
+
 	p, err := s.db.Add(ctx, d)
 	if err != nil {
-		s.log.Error("failed to save the person", "error", err.Error())
-		return dto.PersonDTO{}, err
+		logging.FromContext(ctx).Error("failed to save the person", "error", err.Error())
+		return dto.CreatedPersonDTO{}, err
 	}
 
-	s.log.Info("person created with success", "person", p)
-	return p, nil
+	if len(candidates) > 0 {
+		logging.FromContext(ctx).Warn("person created with possible duplicates", "id", p.ID, "candidates", len(candidates))
+	}
+
+	logging.FromContext(ctx).Info("person created with success", "person", p)
+	return dto.CreatedPersonDTO{PersonDTO: p, Duplicates: candidates}, nil
+}
+
+// enabled reports whether the feature is on, as they all are without flags.
+func (s *ServiceImpl) enabled(name string) bool {
+	return s.features == nil || s.features.Enabled(name)
+}
+
+// Duplicates reports the groups of stored persons that possibly are the same
+// human. Only the persons sharing a blocking key are compared: a page covers
+// limit keys, so a group spanning several keys may be reported on several
+// pages.
+func (s *ServiceImpl) Duplicates(ctx context.Context, page int, limit int) ([]dto.DuplicateGroupDTO, error) {
+	ctx, span := s.tracer.Start(ctx, "person.Duplicates")
+	defer span.End()
+
+	blocks, err := s.db.GetBlocks(ctx, page*limit, limit, maxBlockSize)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to get data", "error", err.Error())
+		return nil, err
+	}
+
+	groups := s.matcher.Groups(blocks)
+	logging.FromContext(ctx).Info("duplicates report computed with success", "groups", len(groups))
+	return groups, nil
 }
 
 // GetByID retrieves a person from the database by its ID.
+//
+// When the person was merged into another one, a MergedError holding the
+// surviving person ID is returned.
 func (s *ServiceImpl) GetByID(ctx context.Context, id int) (*dto.PersonDTO, error) {
+	ctx, span := s.tracer.Start(ctx, "person.GetByID")
+	defer span.End()
+
 	p, err := s.db.GetByID(ctx, id)
+	switch {
+	case err == nil:
+		s.metrics.PersonOperation(opRead, resultSuccess)
+	case errors.Is(err, ErrRecordNotFound):
+		s.metrics.PersonOperation(opRead, resultNotFound)
+	default:
+		s.metrics.PersonOperation(opRead, resultError)
+	}
+
+	if errors.Is(err, ErrRecordNotFound) {
+		if target, aliasErr := s.resolveAlias(ctx, id); aliasErr == nil {
+			logging.FromContext(ctx).Info("person merged into another one", "id", id, "target", target)
+			return nil, &MergedError{ID: id, TargetID: target}
+		}
+	}
+
 	if err != nil {
-		s.log.Error("failed to get the person by id", "id", id, "error", err.Error())
+		logging.FromContext(ctx).Error("failed to get the person by id", "id", id, "error", err.Error())
 		return nil, err
 	}
 
-	s.log.Info("person retrieved with success", "person", p)
+	logging.FromContext(ctx).Info("person retrieved with success", "person", p)
 	return &p, nil
 }
 
 // GetByID retrieves list of person data.
-func (s *ServiceImpl) GetAll(ctx context.Context, page int, limit int) ([]dto.PersonDTO, error) {
+func (s *ServiceImpl) GetAll(ctx context.Context, page int, limit int, filter dto.PersonFilter) ([]dto.PersonDTO, error) {
+	ctx, span := s.tracer.Start(ctx, "person.GetAll")
+	defer span.End()
+
+	tags, err := normalizeTags(filter.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	switch filter.TagMode {
+	case "":
+		filter.TagMode = dto.TagModeAnd
+	case dto.TagModeAnd, dto.TagModeOr:
+	default:
+		return nil, fmt.Errorf("%w: unknown tag mode %q", ErrInvalidTag, filter.TagMode)
+	}
+	filter.Tags = tags
+
+	if err := s.parseAttributeFilter(ctx, &filter); err != nil {
+		return nil, err
+	}
+
 	offset := page * limit
-	persons, err := s.db.GetAll(ctx, offset, limit)
+	persons, err := s.db.GetAll(ctx, offset, limit, filter)
 	if err != nil {
-		s.log.Error("failed to get data", "error", err.Error())
+		logging.FromContext(ctx).Error("failed to get data", "error", err.Error())
 		return nil, err
 	}
 
-	s.log.Info("data retrieved with success")
+	logging.FromContext(ctx).Info("data retrieved with success")
 	return persons, nil
 }
+
+// Merge merges the source person into the target one, applying the
+// survivorship rules to pick the surviving value of every person field.
+func (s *ServiceImpl) Merge(ctx context.Context, targetID int, req dto.MergeDTO) (dto.PersonDTO, error) {
+	ctx, span := s.tracer.Start(ctx, "person.Merge")
+	defer span.End()
+
+	if req.SourceID == targetID {
+		return dto.PersonDTO{}, fmt.Errorf("%w: cannot merge a person into itself", ErrInvalidMerge)
+	}
+
+	target, err := s.db.GetByID(ctx, targetID)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to get the person by id", "id", targetID, "error", err.Error())
+		return dto.PersonDTO{}, err
+	}
+
+	source, err := s.db.GetByID(ctx, req.SourceID)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to get the person by id", "id", req.SourceID, "error", err.Error())
+		return dto.PersonDTO{}, err
+	}
+
+	survivor, err := applySurvivorship(target, source, req.Rules)
+	if err != nil {
+		return dto.PersonDTO{}, err
+	}
+
+	p, err := s.db.Merge(ctx, targetID, req.SourceID, survivor)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to merge persons", "target", targetID, "source", req.SourceID, "error", err.Error())
+		return dto.PersonDTO{}, err
+	}
+
+	logging.FromContext(ctx).Info("persons merged with success", "target", targetID, "source", req.SourceID)
+	return p, nil
+}
+
+// Unmerge reverts the merge of the source person into the target one, as long
+// as the merge happened within the retention window.
+func (s *ServiceImpl) Unmerge(ctx context.Context, targetID int, sourceID int) (dto.PersonDTO, error) {
+	ctx, span := s.tracer.Start(ctx, "person.Unmerge")
+	defer span.End()
+
+	p, err := s.db.Unmerge(ctx, targetID, sourceID, time.Now().UTC().Add(-s.mergeRetention))
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to unmerge persons", "target", targetID, "source", sourceID, "error", err.Error())
+		return dto.PersonDTO{}, err
+	}
+
+	logging.FromContext(ctx).Info("persons unmerged with success", "target", targetID, "source", sourceID)
+	return p, nil
+}
+
+func (s *ServiceImpl) resolveAlias(ctx context.Context, id int) (int, error) {
+	target, err := s.db.ResolveAlias(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+
+	// the target may have been merged as well.
+	for i := 0; i < maxAliasHops; i++ {
+		next, err := s.db.ResolveAlias(ctx, target)
+		if errors.Is(err, ErrRecordNotFound) {
+			return target, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		target = next
+	}
+
+	return 0, fmt.Errorf("too many aliases for person %d", id)
+}
+
+// AddTags tags a person and returns all its tags.
+func (s *ServiceImpl) AddTags(ctx context.Context, id int, tags []string) ([]string, error) {
+	ctx, span := s.tracer.Start(ctx, "person.AddTags")
+	defer span.End()
+
+	tags, err := normalizeTags(tags)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tags) == 0 {
+		return nil, fmt.Errorf("%w: no tag given", ErrInvalidTag)
+	}
+
+	all, err := s.db.AddTags(ctx, id, tags)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to tag the person", "id", id, "error", err.Error())
+		return nil, err
+	}
+
+	logging.FromContext(ctx).Info("person tagged with success", "id", id)
+	return all, nil
+}
+
+// RemoveTag removes a tag from a person.
+func (s *ServiceImpl) RemoveTag(ctx context.Context, id int, tag string) error {
+	ctx, span := s.tracer.Start(ctx, "person.RemoveTag")
+	defer span.End()
+
+	tags, err := normalizeTags([]string{tag})
+	if err != nil {
+		return err
+	}
+
+	if len(tags) == 0 {
+		return fmt.Errorf("%w: no tag given", ErrInvalidTag)
+	}
+
+	if err := s.db.RemoveTag(ctx, id, tags[0]); err != nil {
+		logging.FromContext(ctx).Error("failed to untag the person", "id", id, "error", err.Error())
+		return err
+	}
+
+	logging.FromContext(ctx).Info("person untagged with success", "id", id)
+	return nil
+}
+
+// Tags retrieves every tag with the number of persons tagged with it.
+func (s *ServiceImpl) Tags(ctx context.Context) ([]dto.TagCountDTO, error) {
+	ctx, span := s.tracer.Start(ctx, "person.Tags")
+	defer span.End()
+
+	counts, err := s.db.GetTagCounts(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to get tags", "error", err.Error())
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// SetLabel sets the value of a person label.
+func (s *ServiceImpl) SetLabel(ctx context.Context, id int, key string, value string) error {
+	ctx, span := s.tracer.Start(ctx, "person.SetLabel")
+	defer span.End()
+
+	key = strings.TrimSpace(key)
+	if key == "" || len(key) > maxLabelLength || len(value) > maxLabelLength {
+		return fmt.Errorf("%w: key and value must be at most %d characters long", ErrInvalidLabel, maxLabelLength)
+	}
+
+	if err := s.db.SetLabel(ctx, id, key, value); err != nil {
+		logging.FromContext(ctx).Error("failed to label the person", "id", id, "error", err.Error())
+		return err
+	}
+
+	logging.FromContext(ctx).Info("person labelled with success", "id", id, "key", key)
+	return nil
+}
+
+// RemoveLabel removes a label from a person.
+func (s *ServiceImpl) RemoveLabel(ctx context.Context, id int, key string) error {
+	ctx, span := s.tracer.Start(ctx, "person.RemoveLabel")
+	defer span.End()
+
+	if err := s.db.RemoveLabel(ctx, id, strings.TrimSpace(key)); err != nil {
+		logging.FromContext(ctx).Error("failed to remove the person label", "id", id, "error", err.Error())
+		return err
+	}
+
+	logging.FromContext(ctx).Info("person label removed with success", "id", id, "key", key)
+	return nil
+}
+
+// SetAttributes validates then replaces the custom attributes of a person.
+func (s *ServiceImpl) SetAttributes(ctx context.Context, id int, values map[string]any) (map[string]any, error) {
+	ctx, span := s.tracer.Start(ctx, "person.SetAttributes")
+	defer span.End()
+
+	attrs, err := s.validateAttributes(ctx, values)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.SetAttributes(ctx, id, attrs); err != nil {
+		logging.FromContext(ctx).Error("failed to set the person attributes", "id", id, "error", err.Error())
+		return nil, err
+	}
+
+	logging.FromContext(ctx).Info("person attributes set with success", "id", id)
+	return attrs, nil
+}
+
+func (s *ServiceImpl) validateAttributes(ctx context.Context, values map[string]any) (map[string]any, error) {
+	if s.attrs == nil {
+		if len(values) > 0 {
+			return nil, fmt.Errorf("%w: custom attributes are disabled", attribute.ErrInvalidAttribute)
+		}
+		return nil, nil
+	}
+
+	attrs, err := s.attrs.Validate(ctx, values)
+	if err != nil {
+		logging.FromContext(ctx).Error("invalid person attributes", "error", err.Error())
+		return nil, err
+	}
+
+	return attrs, nil
+}
+
+// parseAttributeFilter converts the raw custom attribute values and sort of
+// the filter to their typed form.
+func (s *ServiceImpl) parseAttributeFilter(ctx context.Context, filter *dto.PersonFilter) error {
+	if strings.HasPrefix(filter.Sort, "-") {
+		filter.Sort = strings.TrimPrefix(filter.Sort, "-")
+		filter.SortDesc = true
+	}
+
+	name, isAttr := strings.CutPrefix(filter.Sort, attributePrefix)
+	if len(filter.Attributes) == 0 && !isAttr {
+		return nil
+	}
+
+	if s.attrs == nil {
+		return fmt.Errorf("%w: custom attributes are disabled", attribute.ErrInvalidAttribute)
+	}
+
+	for k, raw := range filter.Attributes {
+		str, _ := raw.(string)
+		v, _, err := s.attrs.ParseQuery(ctx, k, str)
+		if err != nil {
+			return err
+		}
+		filter.Attributes[k] = v
+	}
+
+	if isAttr {
+		_, numeric, err := s.attrs.ParseQuery(ctx, name, "")
+		if err != nil {
+			return err
+		}
+		filter.SortNumeric = numeric
+	}
+
+	return nil
+}
+
+// normalizeTags trims, lower-cases and deduplicates tags.
+func normalizeTags(tags []string) ([]string, error) {
+	res := []string{}
+	seen := map[string]bool{}
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || seen[t] {
+			continue
+		}
+
+		if len(t) > maxTagLength {
+			return nil, fmt.Errorf("%w: %q is longer than %d characters", ErrInvalidTag, t, maxTagLength)
+		}
+
+		seen[t] = true
+		res = append(res, t)
+	}
+
+	return res, nil
+}
+
+func applySurvivorship(target, source dto.PersonDTO, rules map[string]string) (dto.PersonDTO, error) {
+	survivor := target
+
+	for field, rule := range rules {
+		switch rule {
+		case RuleTarget, RuleSource, RuleNonEmpty:
+		default:
+			return dto.PersonDTO{}, fmt.Errorf("%w: unknown rule %q for field %q", ErrInvalidMerge, rule, field)
+		}
+
+		switch field {
+		case "name":
+			if pickSource(rule, target.Name == "") {
+				survivor.Name = source.Name
+			}
+		case "age":
+			if pickSource(rule, target.Age == 0) {
+				survivor.Age = source.Age
+			}
+		default:
+			return dto.PersonDTO{}, fmt.Errorf("%w: unknown field %q, only name and age take a rule: the phones and addresses of both persons are kept", ErrInvalidMerge, field)
+		}
+	}
+
+	return survivor, nil
+}
+
+func pickSource(rule string, targetEmpty bool) bool {
+	return rule == RuleSource || (rule == RuleNonEmpty && targetEmpty)
+}
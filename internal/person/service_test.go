@@ -3,15 +3,22 @@ package person_test
 import (
 	"context"
 	"fmt"
+	"qore-be/internal/attribute"
 	"qore-be/internal/domain/dto"
+	"qore-be/internal/feature"
 	"qore-be/internal/mocks"
 	"qore-be/internal/person"
 
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestNewPersonService(t *testing.T) {
@@ -40,6 +47,24 @@ func TestNewPersonService(t *testing.T) {
 		assert.Error(t, err)
 		assert.Nil(t, ctrl)
 	})
+
+	t.Run("with invalid duplicate mode", func(t *testing.T) {
+		ctrl, err := person.NewService(
+			person.WithRepository(&person.Repo{}),
+			person.WithDuplicatePolicy("maybe", 0.8),
+		)
+		assert.Error(t, err)
+		assert.Nil(t, ctrl)
+	})
+
+	t.Run("with invalid duplicate threshold", func(t *testing.T) {
+		ctrl, err := person.NewService(
+			person.WithRepository(&person.Repo{}),
+			person.WithDuplicatePolicy(person.DuplicateModeWarn, 1.5),
+		)
+		assert.Error(t, err)
+		assert.Nil(t, ctrl)
+	})
 }
 
 func TestPersonService_Create(t *testing.T) {
@@ -54,11 +79,22 @@ func TestPersonService_Create(t *testing.T) {
 		Zip:     "1234",
 	}
 
+	stored := []dto.PersonDTO{
+		{ID: 1, Name: "Name", Age: 15, Number: "1111111111"},
+		{ID: 2, Name: "Other", Age: 60},
+	}
+
+	// only the persons sharing a blocking key are compared.
+	keys := []string{"name:N500", "phone:111111111"}
+
 	cases := []struct {
-		name   string
-		db     func(*testing.T) person.Repository
-		in     dto.PersonDTO
-		hasErr bool
+		name       string
+		db         func(*testing.T) person.Repository
+		mode       person.DuplicateMode
+		features   map[string]bool
+		in         dto.PersonDTO
+		hasErr     bool
+		duplicates int
 	}{
 		{
 			name: "successfully",
@@ -83,17 +119,98 @@ func TestPersonService_Create(t *testing.T) {
 			in:     validReq,
 			hasErr: true,
 		},
+		{
+			name: "with possible duplicates (warn)",
+			db: func(t *testing.T) person.Repository {
+				d := mocks.NewPersonRepository(t)
+				d.On("GetCandidates", mock.Anything, keys, mock.Anything).Return(stored, nil)
+				d.On("Add", mock.Anything, mock.Anything).
+					Return(dto.PersonDTO{ID: 3, Name: "name"}, nil)
+
+				return d
+			},
+			mode:       person.DuplicateModeWarn,
+			in:         validReq,
+			duplicates: 1,
+		},
+		{
+			name: "with possible duplicates (block)",
+			db: func(t *testing.T) person.Repository {
+				d := mocks.NewPersonRepository(t)
+				d.On("GetCandidates", mock.Anything, keys, mock.Anything).Return(stored, nil)
+
+				return d
+			},
+			mode:   person.DuplicateModeBlock,
+			in:     validReq,
+			hasErr: true,
+		},
+		{
+			name: "without duplicates (block)",
+			db: func(t *testing.T) person.Repository {
+				d := mocks.NewPersonRepository(t)
+				d.On("GetCandidates", mock.Anything, keys, mock.Anything).Return(stored[1:], nil)
+				d.On("Add", mock.Anything, mock.Anything).
+					Return(dto.PersonDTO{ID: 3, Name: "name"}, nil)
+
+				return d
+			},
+			mode: person.DuplicateModeBlock,
+			in:   validReq,
+		},
+		{
+			name: "with duplicates lookup error",
+			db: func(t *testing.T) person.Repository {
+				d := mocks.NewPersonRepository(t)
+				d.On("GetCandidates", mock.Anything, keys, mock.Anything).Return(nil, fmt.Errorf("error"))
+
+				return d
+			},
+			mode:   person.DuplicateModeWarn,
+			in:     validReq,
+			hasErr: true,
+		},
+		{
+			name: "with the duplicates feature off (block)",
+			db: func(t *testing.T) person.Repository {
+				d := mocks.NewPersonRepository(t)
+				d.On("Add", mock.Anything, mock.Anything).
+					Return(dto.PersonDTO{ID: 3, Name: "name"}, nil)
+
+				return d
+			},
+			mode:     person.DuplicateModeBlock,
+			features: map[string]bool{feature.Duplicates: false},
+			in:       validReq,
+		},
 	}
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			svc, err := person.NewService(person.WithRepository(tc.db(t)))
+			mode := tc.mode
+			if mode == "" {
+				mode = person.DuplicateModeOff
+			}
+
+			flags, err := feature.NewFlags(tc.features)
+			require.NoError(t, err)
+
+			svc, err := person.NewService(
+				person.WithRepository(tc.db(t)),
+				person.WithDuplicatePolicy(mode, 0.85),
+				person.WithFeatures(flags),
+			)
 			require.NoError(t, err)
 
 			res, err := svc.Create(context.TODO(), tc.in)
 			assert.Equal(t, !tc.hasErr, err == nil)
 			if !tc.hasErr {
 				assert.NotEmpty(t, res)
+				assert.Len(t, res.Duplicates, tc.duplicates)
+			}
+
+			if tc.mode == person.DuplicateModeBlock && tc.hasErr {
+				assert.ErrorIs(t, err, person.ErrDuplicatePerson)
 			}
 		})
 	}
@@ -149,6 +266,7 @@ func TestPersonService_GetByID(t *testing.T) {
 func TestPersonService_GetAll(t *testing.T) {
 	type Request struct {
 		offset, limit int
+		filter        dto.PersonFilter
 	}
 
 	cases := []struct {
@@ -161,7 +279,7 @@ func TestPersonService_GetAll(t *testing.T) {
 			name: "successfully",
 			db: func(t *testing.T) person.Repository {
 				d := mocks.NewPersonRepository(t)
-				d.On("GetAll", mock.Anything, mock.Anything, mock.Anything).
+				d.On("GetAll", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 					Return([]dto.PersonDTO{
 						{Name: "name"},
 					}, nil)
@@ -174,7 +292,7 @@ func TestPersonService_GetAll(t *testing.T) {
 			name: "with error",
 			db: func(t *testing.T) person.Repository {
 				d := mocks.NewPersonRepository(t)
-				d.On("GetAll", mock.Anything, mock.Anything, mock.Anything).
+				d.On("GetAll", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 					Return(nil, fmt.Errorf("error"))
 
 				return d
@@ -182,6 +300,27 @@ func TestPersonService_GetAll(t *testing.T) {
 			in:     Request{offset: 0, limit: 20},
 			hasErr: true,
 		},
+		{
+			name: "successfully (with tags)",
+			db: func(t *testing.T) person.Repository {
+				d := mocks.NewPersonRepository(t)
+				d.On("GetAll", mock.Anything, 0, 20, dto.PersonFilter{Tags: []string{"vip", "blocked"}, TagMode: dto.TagModeOr}).
+					Return([]dto.PersonDTO{
+						{Name: "name", Tags: []string{"vip"}},
+					}, nil)
+
+				return d
+			},
+			in: Request{offset: 0, limit: 20, filter: dto.PersonFilter{Tags: []string{" VIP", "blocked", "vip"}, TagMode: dto.TagModeOr}},
+		},
+		{
+			name: "with invalid tag mode",
+			db: func(t *testing.T) person.Repository {
+				return mocks.NewPersonRepository(t)
+			},
+			in:     Request{offset: 0, limit: 20, filter: dto.PersonFilter{Tags: []string{"vip"}, TagMode: "xor"}},
+			hasErr: true,
+		},
 	}
 
 	for _, tc := range cases {
@@ -189,7 +328,7 @@ func TestPersonService_GetAll(t *testing.T) {
 			svc, err := person.NewService(person.WithRepository(tc.db(t)))
 			require.NoError(t, err)
 
-			res, err := svc.GetAll(context.TODO(), tc.in.offset, tc.in.limit)
+			res, err := svc.GetAll(context.TODO(), tc.in.offset, tc.in.limit, tc.in.filter)
 			assert.Equal(t, !tc.hasErr, err == nil)
 			if !tc.hasErr {
 				assert.NotEmpty(t, res)
@@ -198,3 +337,487 @@ func TestPersonService_GetAll(t *testing.T) {
 	}
 
 }
+
+func TestPersonService_Duplicates(t *testing.T) {
+	cases := []struct {
+		name   string
+		db     func(*testing.T) person.Repository
+		groups int
+		hasErr bool
+	}{
+		{
+			name: "successfully",
+			db: func(t *testing.T) person.Repository {
+				d := mocks.NewPersonRepository(t)
+				d.On("GetBlocks", mock.Anything, 20, 10, mock.Anything).
+					Return([][]dto.PersonDTO{
+						{{ID: 1, Name: "John Doe", Age: 30}, {ID: 2, Name: "Jon Doe", Age: 30}},
+						{{ID: 2, Name: "Jon Doe", Age: 30}, {ID: 3, Name: "Alice Martin", Age: 52}},
+					}, nil)
+
+				return d
+			},
+			groups: 1,
+		},
+		{
+			name: "with error",
+			db: func(t *testing.T) person.Repository {
+				d := mocks.NewPersonRepository(t)
+				d.On("GetBlocks", mock.Anything, 20, 10, mock.Anything).
+					Return(nil, fmt.Errorf("error"))
+
+				return d
+			},
+			hasErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc, err := person.NewService(person.WithRepository(tc.db(t)))
+			require.NoError(t, err)
+
+			res, err := svc.Duplicates(context.TODO(), 2, 10)
+			assert.Equal(t, !tc.hasErr, err == nil)
+			assert.Len(t, res, tc.groups)
+		})
+	}
+}
+
+func TestPersonService_GetByID_Merged(t *testing.T) {
+	d := mocks.NewPersonRepository(t)
+	d.On("GetByID", mock.Anything, 1).Return(dto.PersonDTO{}, person.ErrRecordNotFound)
+	d.On("ResolveAlias", mock.Anything, 1).Return(2, nil)
+	d.On("ResolveAlias", mock.Anything, 2).Return(3, nil)
+	d.On("ResolveAlias", mock.Anything, 3).Return(0, person.ErrRecordNotFound)
+
+	svc, err := person.NewService(person.WithRepository(d))
+	require.NoError(t, err)
+
+	res, err := svc.GetByID(context.TODO(), 1)
+	assert.Nil(t, res)
+
+	var mergedErr *person.MergedError
+	require.ErrorAs(t, err, &mergedErr)
+	assert.Equal(t, 3, mergedErr.TargetID)
+
+	t.Run("without alias", func(t *testing.T) {
+		d := mocks.NewPersonRepository(t)
+		d.On("GetByID", mock.Anything, 1).Return(dto.PersonDTO{}, person.ErrRecordNotFound)
+		d.On("ResolveAlias", mock.Anything, 1).Return(0, person.ErrRecordNotFound)
+
+		svc, err := person.NewService(person.WithRepository(d))
+		require.NoError(t, err)
+
+		_, err = svc.GetByID(context.TODO(), 1)
+		assert.ErrorIs(t, err, person.ErrRecordNotFound)
+	})
+}
+
+func TestPersonService_Merge(t *testing.T) {
+	target := dto.PersonDTO{ID: 1, Name: "", Age: 30}
+	source := dto.PersonDTO{ID: 2, Name: "John Doe", Age: 31}
+
+	cases := []struct {
+		name     string
+		db       func(*testing.T) person.Repository
+		in       dto.MergeDTO
+		survivor dto.PersonDTO
+		hasErr   bool
+	}{
+		{
+			name: "successfully (default rules)",
+			db: func(t *testing.T) person.Repository {
+				d := mocks.NewPersonRepository(t)
+				d.On("GetByID", mock.Anything, 1).Return(target, nil)
+				d.On("GetByID", mock.Anything, 2).Return(source, nil)
+				d.On("Merge", mock.Anything, 1, 2, target).Return(target, nil)
+
+				return d
+			},
+			in: dto.MergeDTO{SourceID: 2},
+		},
+		{
+			name: "successfully (with rules)",
+			db: func(t *testing.T) person.Repository {
+				survivor := dto.PersonDTO{ID: 1, Name: "John Doe", Age: 31}
+
+				d := mocks.NewPersonRepository(t)
+				d.On("GetByID", mock.Anything, 1).Return(target, nil)
+				d.On("GetByID", mock.Anything, 2).Return(source, nil)
+				d.On("Merge", mock.Anything, 1, 2, survivor).Return(survivor, nil)
+
+				return d
+			},
+			in: dto.MergeDTO{SourceID: 2, Rules: map[string]string{
+				"name": person.RuleNonEmpty,
+				"age":  person.RuleSource,
+			}},
+		},
+		{
+			name: "with the same person",
+			db: func(t *testing.T) person.Repository {
+				return mocks.NewPersonRepository(t)
+			},
+			in:     dto.MergeDTO{SourceID: 1},
+			hasErr: true,
+		},
+		{
+			name: "with unknown rule",
+			db: func(t *testing.T) person.Repository {
+				d := mocks.NewPersonRepository(t)
+				d.On("GetByID", mock.Anything, 1).Return(target, nil)
+				d.On("GetByID", mock.Anything, 2).Return(source, nil)
+
+				return d
+			},
+			in:     dto.MergeDTO{SourceID: 2, Rules: map[string]string{"name": "longest"}},
+			hasErr: true,
+		},
+		{
+			name: "with unknown field",
+			db: func(t *testing.T) person.Repository {
+				d := mocks.NewPersonRepository(t)
+				d.On("GetByID", mock.Anything, 1).Return(target, nil)
+				d.On("GetByID", mock.Anything, 2).Return(source, nil)
+
+				return d
+			},
+			in:     dto.MergeDTO{SourceID: 2, Rules: map[string]string{"email": person.RuleSource}},
+			hasErr: true,
+		},
+		{
+			name: "with missing source",
+			db: func(t *testing.T) person.Repository {
+				d := mocks.NewPersonRepository(t)
+				d.On("GetByID", mock.Anything, 1).Return(target, nil)
+				d.On("GetByID", mock.Anything, 2).Return(dto.PersonDTO{}, person.ErrRecordNotFound)
+
+				return d
+			},
+			in:     dto.MergeDTO{SourceID: 2},
+			hasErr: true,
+		},
+		{
+			name: "with merge error",
+			db: func(t *testing.T) person.Repository {
+				d := mocks.NewPersonRepository(t)
+				d.On("GetByID", mock.Anything, 1).Return(target, nil)
+				d.On("GetByID", mock.Anything, 2).Return(source, nil)
+				d.On("Merge", mock.Anything, 1, 2, mock.Anything).Return(dto.PersonDTO{}, fmt.Errorf("error"))
+
+				return d
+			},
+			in:     dto.MergeDTO{SourceID: 2},
+			hasErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc, err := person.NewService(person.WithRepository(tc.db(t)))
+			require.NoError(t, err)
+
+			res, err := svc.Merge(context.TODO(), 1, tc.in)
+			assert.Equal(t, !tc.hasErr, err == nil)
+			if !tc.hasErr {
+				assert.NotEmpty(t, res)
+			}
+		})
+	}
+}
+
+func TestPersonService_Unmerge(t *testing.T) {
+	cases := []struct {
+		name   string
+		db     func(*testing.T) person.Repository
+		hasErr bool
+	}{
+		{
+			name: "successfully",
+			db: func(t *testing.T) person.Repository {
+				d := mocks.NewPersonRepository(t)
+				d.On("Unmerge", mock.Anything, 1, 2, mock.MatchedBy(func(notBefore time.Time) bool {
+					return time.Since(notBefore) > time.Hour
+				})).Return(dto.PersonDTO{ID: 2, Name: "name"}, nil)
+
+				return d
+			},
+		},
+		{
+			name: "with expired merge",
+			db: func(t *testing.T) person.Repository {
+				d := mocks.NewPersonRepository(t)
+				d.On("Unmerge", mock.Anything, 1, 2, mock.Anything).
+					Return(dto.PersonDTO{}, person.ErrMergeExpired)
+
+				return d
+			},
+			hasErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc, err := person.NewService(
+				person.WithRepository(tc.db(t)),
+				person.WithMergeRetention(2*time.Hour),
+			)
+			require.NoError(t, err)
+
+			res, err := svc.Unmerge(context.TODO(), 1, 2)
+			assert.Equal(t, !tc.hasErr, err == nil)
+			if !tc.hasErr {
+				assert.NotEmpty(t, res)
+			}
+		})
+	}
+}
+
+func TestPersonService_AddTags(t *testing.T) {
+	cases := []struct {
+		name   string
+		db     func(*testing.T) person.Repository
+		in     []string
+		hasErr error
+	}{
+		{
+			name: "successfully",
+			db: func(t *testing.T) person.Repository {
+				d := mocks.NewPersonRepository(t)
+				d.On("AddTags", mock.Anything, 1, []string{"vip", "newsletter"}).
+					Return([]string{"newsletter", "old", "vip"}, nil)
+
+				return d
+			},
+			in: []string{"VIP ", "newsletter", "vip"},
+		},
+		{
+			name: "without tags",
+			db: func(t *testing.T) person.Repository {
+				return mocks.NewPersonRepository(t)
+			},
+			in:     []string{" "},
+			hasErr: person.ErrInvalidTag,
+		},
+		{
+			name: "with too long tag",
+			db: func(t *testing.T) person.Repository {
+				return mocks.NewPersonRepository(t)
+			},
+			in:     []string{strings.Repeat("a", 65)},
+			hasErr: person.ErrInvalidTag,
+		},
+		{
+			name: "with unknown person",
+			db: func(t *testing.T) person.Repository {
+				d := mocks.NewPersonRepository(t)
+				d.On("AddTags", mock.Anything, 1, []string{"vip"}).
+					Return(nil, person.ErrRecordNotFound)
+
+				return d
+			},
+			in:     []string{"vip"},
+			hasErr: person.ErrRecordNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc, err := person.NewService(person.WithRepository(tc.db(t)))
+			require.NoError(t, err)
+
+			res, err := svc.AddTags(context.TODO(), 1, tc.in)
+			if tc.hasErr != nil {
+				assert.ErrorIs(t, err, tc.hasErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.NotEmpty(t, res)
+		})
+	}
+}
+
+func TestPersonService_SetLabel(t *testing.T) {
+	cases := []struct {
+		name   string
+		db     func(*testing.T) person.Repository
+		key    string
+		hasErr error
+	}{
+		{
+			name: "successfully",
+			db: func(t *testing.T) person.Repository {
+				d := mocks.NewPersonRepository(t)
+				d.On("SetLabel", mock.Anything, 1, "team", "blue").Return(nil)
+
+				return d
+			},
+			key: " team",
+		},
+		{
+			name: "with empty key",
+			db: func(t *testing.T) person.Repository {
+				return mocks.NewPersonRepository(t)
+			},
+			key:    "",
+			hasErr: person.ErrInvalidLabel,
+		},
+		{
+			name: "with unknown person",
+			db: func(t *testing.T) person.Repository {
+				d := mocks.NewPersonRepository(t)
+				d.On("SetLabel", mock.Anything, 1, "team", "blue").Return(person.ErrRecordNotFound)
+
+				return d
+			},
+			key:    "team",
+			hasErr: person.ErrRecordNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc, err := person.NewService(person.WithRepository(tc.db(t)))
+			require.NoError(t, err)
+
+			err = svc.SetLabel(context.TODO(), 1, tc.key, "blue")
+			if tc.hasErr != nil {
+				assert.ErrorIs(t, err, tc.hasErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestPersonService_Attributes(t *testing.T) {
+	t.Run("create with valid attributes", func(t *testing.T) {
+		schema := mocks.NewPersonAttributeSchema(t)
+		schema.On("Validate", mock.Anything, map[string]any{"lang": "fr"}).
+			Return(map[string]any{"lang": "fr"}, nil)
+
+		d := mocks.NewPersonRepository(t)
+		d.On("Add", mock.Anything, dto.PersonDTO{Name: "name", Attributes: map[string]any{"lang": "fr"}}).
+			Return(dto.PersonDTO{ID: 1, Name: "name", Attributes: map[string]any{"lang": "fr"}}, nil)
+
+		svc, err := person.NewService(person.WithRepository(d), person.WithAttributeSchema(schema))
+		require.NoError(t, err)
+
+		res, err := svc.Create(context.TODO(), dto.PersonDTO{Name: "name", Attributes: map[string]any{"lang": "fr"}})
+		assert.NoError(t, err)
+		assert.Equal(t, "fr", res.Attributes["lang"])
+	})
+
+	t.Run("create with invalid attributes", func(t *testing.T) {
+		schema := mocks.NewPersonAttributeSchema(t)
+		schema.On("Validate", mock.Anything, mock.Anything).
+			Return(nil, fmt.Errorf("%w: error", attribute.ErrInvalidAttribute))
+
+		svc, err := person.NewService(person.WithRepository(mocks.NewPersonRepository(t)), person.WithAttributeSchema(schema))
+		require.NoError(t, err)
+
+		_, err = svc.Create(context.TODO(), dto.PersonDTO{Name: "name"})
+		assert.ErrorIs(t, err, attribute.ErrInvalidAttribute)
+	})
+
+	t.Run("create with attributes disabled", func(t *testing.T) {
+		svc, err := person.NewService(person.WithRepository(mocks.NewPersonRepository(t)))
+		require.NoError(t, err)
+
+		_, err = svc.Create(context.TODO(), dto.PersonDTO{Name: "name", Attributes: map[string]any{"lang": "fr"}})
+		assert.ErrorIs(t, err, attribute.ErrInvalidAttribute)
+	})
+
+	t.Run("list filtered and sorted by attributes", func(t *testing.T) {
+		schema := mocks.NewPersonAttributeSchema(t)
+		schema.On("ParseQuery", mock.Anything, "lang", "fr").Return("fr", false, nil)
+		schema.On("ParseQuery", mock.Anything, "seniority", "").Return(nil, true, nil)
+
+		d := mocks.NewPersonRepository(t)
+		d.On("GetAll", mock.Anything, 0, 10, dto.PersonFilter{
+			TagMode:     dto.TagModeAnd,
+			Tags:        []string{},
+			Attributes:  map[string]any{"lang": "fr"},
+			Sort:        "attr.seniority",
+			SortDesc:    true,
+			SortNumeric: true,
+		}).Return([]dto.PersonDTO{{ID: 1}}, nil)
+
+		svc, err := person.NewService(person.WithRepository(d), person.WithAttributeSchema(schema))
+		require.NoError(t, err)
+
+		res, err := svc.GetAll(context.TODO(), 0, 10, dto.PersonFilter{
+			Attributes: map[string]any{"lang": "fr"},
+			Sort:       "-attr.seniority",
+		})
+		assert.NoError(t, err)
+		assert.Len(t, res, 1)
+	})
+
+	t.Run("set attributes", func(t *testing.T) {
+		schema := mocks.NewPersonAttributeSchema(t)
+		schema.On("Validate", mock.Anything, map[string]any{"seniority": 3.0}).
+			Return(map[string]any{"seniority": 3.0}, nil)
+
+		d := mocks.NewPersonRepository(t)
+		d.On("SetAttributes", mock.Anything, 1, map[string]any{"seniority": 3.0}).Return(nil)
+
+		svc, err := person.NewService(person.WithRepository(d), person.WithAttributeSchema(schema))
+		require.NoError(t, err)
+
+		res, err := svc.SetAttributes(context.TODO(), 1, map[string]any{"seniority": 3.0})
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{"seniority": 3.0}, res)
+	})
+}
+
+type recordedMetrics map[string]int
+
+func (m recordedMetrics) PersonOperation(op string, result string) {
+	m[op+"/"+result]++
+}
+
+func TestPersonService_Metrics(t *testing.T) {
+	m := recordedMetrics{}
+	svc, err := person.NewService(person.WithRepository(person.NewMemoryRepository()), person.WithMetrics(m))
+	require.NoError(t, err)
+
+	created, err := svc.Create(context.TODO(), dto.PersonDTO{Name: "name"})
+	assert.NoError(t, err)
+	_, err = svc.GetByID(context.TODO(), created.ID)
+	assert.NoError(t, err)
+	_, err = svc.GetByID(context.TODO(), created.ID+1)
+	assert.ErrorIs(t, err, person.ErrRecordNotFound)
+
+	assert.Equal(t, recordedMetrics{
+		"read/success":   1,
+		"read/not_found": 1,
+		"create/success": 1,
+	}, m)
+}
+
+func TestPersonService_Tracing(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+
+	d := mocks.NewPersonRepository(t)
+	d.On("GetByID", mock.Anything, 1).Return(dto.PersonDTO{ID: 1}, nil).Run(func(args mock.Arguments) {
+		// the repository runs within the service span.
+		assert.True(t, trace.SpanContextFromContext(args.Get(0).(context.Context)).IsValid())
+	})
+
+	svc, err := person.NewService(person.WithRepository(d), person.WithTracerProvider(tp))
+	require.NoError(t, err)
+
+	ctx, parent := tp.Tracer("test").Start(context.TODO(), "GET /person/:id/info")
+	_, err = svc.GetByID(ctx, 1)
+	parent.End()
+	assert.NoError(t, err)
+
+	spans := exp.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "person.GetByID", spans[0].Name)
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent.SpanID())
+}
@@ -0,0 +1,144 @@
+package redact
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// keys maps the log attribute keys known to hold PII to their field.
+var keys = map[string]string{
+	"full_name":    FieldName,
+	"phone":        FieldPhone,
+	"phone_number": FieldPhone,
+	"address":      FieldAddress,
+	"street":       FieldAddress,
+	"street1":      FieldAddress,
+	"street2":      FieldAddress,
+	"email":        FieldEmail,
+}
+
+// personKeys maps the person fields too common to be PII elsewhere, e.g. the
+// name of an attribute, to their field. They are only matched within a
+// "person" group or with a "person." or "person_" prefix.
+var personKeys = map[string]string{
+	"name":   FieldName,
+	"number": FieldPhone,
+}
+
+// personGroup is the key of the groups holding the fields of a person.
+const personGroup = "person"
+
+// sqlLiterals matches the single-quoted strings of a SQL statement, which
+// may hold the values of the person fields.
+var sqlLiterals = regexp.MustCompile(`'(?:[^']|'')*'`)
+
+// Handler wraps a slog handler and scrubs the attributes known to hold PII,
+// whatever the logger they are given to.
+type Handler struct {
+	next slog.Handler
+}
+
+// NewHandler wraps the handler.
+func NewHandler(next slog.Handler) *Handler {
+	return &Handler{next: next}
+}
+
+// Enabled implements slog.Handler.
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler.
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	scrubbed := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		if a, ok := scrub(a, false); ok {
+			scrubbed.AddAttrs(a)
+		}
+		return true
+	})
+
+	return h.next.Handle(ctx, scrubbed)
+}
+
+// WithAttrs implements slog.Handler.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	scrubbed := make([]slog.Attr, 0, len(attrs))
+	for _, a := range attrs {
+		if a, ok := scrub(a, false); ok {
+			scrubbed = append(scrubbed, a)
+		}
+	}
+
+	return &Handler{next: h.next.WithAttrs(scrubbed)}
+}
+
+// WithGroup implements slog.Handler.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{next: h.next.WithGroup(name)}
+}
+
+// scrub redacts the attribute, of a person group when person is true.
+func scrub(a slog.Attr, person bool) (slog.Attr, bool) {
+	// values logging themselves are trusted to redact their own fields.
+	if a.Value.Kind() == slog.KindLogValuer {
+		a.Value = a.Value.Resolve()
+		return a, true
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		group := a.Value.Group()
+		attrs := make([]slog.Attr, 0, len(group))
+		inPerson := strings.EqualFold(a.Key, personGroup)
+		for _, ga := range group {
+			if ga, ok := scrub(ga, inPerson); ok {
+				attrs = append(attrs, ga)
+			}
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(attrs...)}, true
+	}
+
+	key := strings.ToLower(a.Key)
+	if key == "sql" {
+		return slog.String(a.Key, sqlLiterals.ReplaceAllString(a.Value.String(), "'?'")), true
+	}
+
+	field, ok := fieldOf(key, person)
+	if !ok {
+		return a, true
+	}
+
+	v, keep := String(field, a.Value.String())
+	if !keep {
+		return slog.Attr{}, false
+	}
+
+	return slog.String(a.Key, v), true
+}
+
+// fieldOf returns the PII field held by the lowercase key.
+func fieldOf(key string, person bool) (string, bool) {
+	if field, ok := keys[key]; ok {
+		return field, true
+	}
+
+	if !person {
+		for _, prefix := range []string{personGroup + ".", personGroup + "_"} {
+			if k, ok := strings.CutPrefix(key, prefix); ok {
+				key, person = k, true
+				break
+			}
+		}
+	}
+	if !person {
+		return "", false
+	}
+
+	if field, ok := personKeys[key]; ok {
+		return field, true
+	}
+	field, ok := keys[key]
+	return field, ok
+}
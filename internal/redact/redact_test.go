@@ -0,0 +1,122 @@
+package redact_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"qore-be/internal/domain/dto"
+	"qore-be/internal/redact"
+	"strings"
+
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePolicy(t *testing.T) {
+	p, err := redact.ParsePolicy(map[string]string{"name": "Hash", " phone ": "drop"}, "key")
+	require.NoError(t, err)
+	assert.Equal(t, redact.ModeHash, p.Modes[redact.FieldName])
+	assert.Equal(t, redact.ModeDrop, p.Modes[redact.FieldPhone])
+	assert.Equal(t, redact.ModeMask, p.Modes[redact.FieldAddress])
+
+	_, err = redact.ParsePolicy(map[string]string{"ssn": "drop"}, "")
+	assert.Error(t, err)
+
+	_, err = redact.ParsePolicy(map[string]string{"name": "encrypt"}, "")
+	assert.Error(t, err)
+
+	_, err = redact.ParsePolicy(map[string]string{"address": "hash"}, "")
+	assert.Error(t, err)
+}
+
+func TestString(t *testing.T) {
+	defer redact.SetPolicy(redact.DefaultPolicy())
+
+	redact.SetPolicy(redact.Policy{
+		Modes: map[string]redact.Mode{
+			redact.FieldName:    redact.ModeMask,
+			redact.FieldPhone:   redact.ModeDrop,
+			redact.FieldAddress: redact.ModeHash,
+			redact.FieldEmail:   redact.ModeClear,
+		},
+		HashKey: []byte("key"),
+	})
+
+	v, ok := redact.String(redact.FieldName, "Jane Doe")
+	assert.True(t, ok)
+	assert.Equal(t, "J******e", v)
+
+	v, ok = redact.String(redact.FieldName, "Zoé")
+	assert.True(t, ok)
+	assert.Equal(t, "Z*é", v)
+
+	_, ok = redact.String(redact.FieldPhone, "0612345678")
+	assert.False(t, ok)
+
+	h1, ok := redact.String(redact.FieldAddress, "1 Main Street")
+	assert.True(t, ok)
+	h2, _ := redact.String(redact.FieldAddress, "1 Main Street")
+	assert.True(t, strings.HasPrefix(h1, "sha256:"))
+	assert.Equal(t, h1, h2)
+	assert.NotContains(t, h1, "Main")
+
+	v, _ = redact.String(redact.FieldEmail, "jane@example.com")
+	assert.Equal(t, "jane@example.com", v)
+}
+
+func TestHandler(t *testing.T) {
+	defer redact.SetPolicy(redact.DefaultPolicy())
+	redact.SetPolicy(redact.Policy{
+		Modes:   map[string]redact.Mode{redact.FieldAddress: redact.ModeHash},
+		HashKey: []byte("key"),
+	})
+
+	var buf bytes.Buffer
+	log := slog.New(redact.NewHandler(slog.NewJSONHandler(&buf, nil)))
+
+	log.With("phone", "0612345678").Info("msg",
+		"person_name", "Jane Doe",
+		"name", "employee_number",
+		"number", "E0042",
+		"person.number", "0612345678",
+		"id", 1,
+		slog.Group("req", "street1", "1 Main Street", "city", "Paris"),
+		"sql", "SELECT * FROM `person` WHERE name = 'Jane' AND id = 1",
+		"person", dto.PersonDTO{
+			ID:      1,
+			Name:    "Jane Doe",
+			Number:  "0612345678",
+			Street1: "1 Main Street",
+			City:    "Paris",
+			Labels:  map[string]string{"nickname": "JD"},
+		},
+	)
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+
+	assert.Equal(t, "0********8", entry["phone"])
+	assert.Equal(t, "J******e", entry["person_name"])
+	assert.Equal(t, "employee_number", entry["name"])
+	assert.Equal(t, "E0042", entry["number"])
+	assert.Equal(t, "0********8", entry["person.number"])
+	assert.Equal(t, float64(1), entry["id"])
+	assert.Equal(t, "Paris", entry["req"].(map[string]any)["city"])
+	assert.True(t, strings.HasPrefix(entry["req"].(map[string]any)["street1"].(string), "sha256:"))
+	assert.Equal(t, "SELECT * FROM `person` WHERE name = '?' AND id = 1", entry["sql"])
+
+	person := entry["person"].(map[string]any)
+	assert.Equal(t, "J******e", person["name"])
+	assert.Equal(t, "0********8", person["phone_number"])
+	assert.Equal(t, []any{"nickname"}, person["labels"])
+
+	// the person redacts itself and is not redacted a second time.
+	expected, _ := redact.String(redact.FieldAddress, "1 Main Street")
+	assert.Equal(t, expected, person["street1"])
+
+	assert.NotContains(t, buf.String(), "Jane")
+	assert.NotContains(t, buf.String(), "Main Street")
+	assert.NotContains(t, buf.String(), "JD")
+}
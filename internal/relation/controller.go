@@ -0,0 +1,217 @@
+package relation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"qore-be/internal/breaker"
+	"qore-be/internal/domain/dto"
+	"qore-be/internal/logging"
+	"qore-be/internal/utils"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Service the relationship services.
+//
+//go:generate mockery --name=Service --structname=RelationService  --case underscore --output=../mocks/ --filename=relation_service.go
+type Service interface {
+	Create(context.Context, int, dto.RelationDTO) (dto.RelationDTO, error)
+	Get(context.Context, int, int) (dto.RelationDTO, error)
+	Update(context.Context, int, int, dto.RelationDTO) (dto.RelationDTO, error)
+	Delete(context.Context, int, int) error
+	Graph(context.Context, int, int, *time.Time) (dto.RelationGraphDTO, error)
+}
+
+// Controller represents the relationship controller.
+type Controller struct {
+	svc Service
+}
+
+// ControllerOption ..
+type ControllerOption = func(*Controller) error
+
+var (
+	errMissingRelationService = fmt.Errorf("nil relationship service")
+)
+
+// NewController creates a new relationship controller.
+func NewController(opts ...ControllerOption) (*Controller, error) {
+	ctrl := &Controller{}
+
+	for _, opt := range opts {
+		if err := opt(ctrl); err != nil {
+			return nil, err
+		}
+	}
+
+	if ctrl.svc == nil {
+		return nil, errMissingRelationService
+	}
+
+	return ctrl, nil
+}
+
+// WithService initialize the relationship-controller with a relationship-service.
+func WithService(svc Service) ControllerOption {
+	return func(ctrl *Controller) error {
+		if svc == nil {
+			return errMissingRelationService
+		}
+		ctrl.svc = svc
+		return nil
+	}
+}
+
+// Create adds a relationship to a person.
+func (c *Controller) Create(ctx *gin.Context) {
+	personID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		logging.FromContext(ctx).Error("invalid request", "error", err.Error())
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req dto.RelationDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rel, err := c.svc.Create(ctx, personID, req)
+	if err != nil {
+		c.handleError(ctx, "failed to create relationship", err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, rel)
+}
+
+// GetAll retrieves the relationship graph around a person.
+//
+// The depth query parameter (default 1) tells how many hops are followed and
+// the optional at query parameter (RFC 3339 or YYYY-MM-DD) filters the
+// relationships valid at that time.
+func (c *Controller) GetAll(ctx *gin.Context) {
+	personID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		logging.FromContext(ctx).Error("invalid request", "error", err.Error())
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	depth := utils.StringToInt(ctx.Query("depth"), 1)
+
+	var at *time.Time
+	if v := ctx.Query("at"); v != "" {
+		t, err := parseTime(v)
+		if err != nil {
+			logging.FromContext(ctx).Error("invalid request", "error", err.Error())
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		at = &t
+	}
+
+	graph, err := c.svc.Graph(ctx, personID, depth, at)
+	if err != nil {
+		c.handleError(ctx, "failed to get relationships", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, graph)
+}
+
+// Get retrieves a relationship of a person.
+func (c *Controller) Get(ctx *gin.Context) {
+	personID, id, ok := c.ids(ctx)
+	if !ok {
+		return
+	}
+
+	rel, err := c.svc.Get(ctx, personID, id)
+	if err != nil {
+		c.handleError(ctx, "failed to get relationship", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rel)
+}
+
+// Update replaces a relationship of a person.
+func (c *Controller) Update(ctx *gin.Context) {
+	personID, id, ok := c.ids(ctx)
+	if !ok {
+		return
+	}
+
+	var req dto.RelationDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rel, err := c.svc.Update(ctx, personID, id, req)
+	if err != nil {
+		c.handleError(ctx, "failed to update relationship", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rel)
+}
+
+// Delete removes a relationship of a person.
+func (c *Controller) Delete(ctx *gin.Context) {
+	personID, id, ok := c.ids(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.svc.Delete(ctx, personID, id); err != nil {
+		c.handleError(ctx, "failed to delete relationship", err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (c *Controller) ids(ctx *gin.Context) (int, int, bool) {
+	personID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		logging.FromContext(ctx).Error("invalid request", "error", err.Error())
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return 0, 0, false
+	}
+
+	id, err := strconv.Atoi(ctx.Param("rid"))
+	if err != nil {
+		logging.FromContext(ctx).Error("invalid request", "error", err.Error())
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return 0, 0, false
+	}
+
+	return personID, id, true
+}
+
+func (c *Controller) handleError(ctx *gin.Context, msg string, err error) {
+	logging.FromContext(ctx).Error(msg, "error", err.Error())
+
+	switch {
+	case errors.Is(err, ErrInvalidRelation):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		breaker.WriteError(ctx, err)
+	}
+}
+
+func parseTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, v)
+}
@@ -0,0 +1,259 @@
+package relation_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"qore-be/internal/domain/dto"
+	"qore-be/internal/mocks"
+	"qore-be/internal/relation"
+
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRelationController(t *testing.T) {
+	t.Run("successfully", func(t *testing.T) {
+		ctrl, err := relation.NewController(relation.WithService(mocks.NewRelationService(t)))
+		assert.NoError(t, err)
+		assert.NotNil(t, ctrl)
+	})
+
+	t.Run("with missing relationship service", func(t *testing.T) {
+		ctrl, err := relation.NewController()
+		assert.Error(t, err)
+		assert.Nil(t, ctrl)
+	})
+}
+
+func TestRelationController_Create(t *testing.T) {
+	validReq := dto.RelationDTO{RelatedID: 2, Type: relation.TypeParent}
+
+	cases := []struct {
+		name           string
+		svc            func(*testing.T) relation.Service
+		in             interface{}
+		expectedStatus int
+	}{
+		{
+			name: "successfully",
+			svc: func(t *testing.T) relation.Service {
+				s := mocks.NewRelationService(t)
+				s.On("Create", mock.Anything, 1, mock.Anything).
+					Return(dto.RelationDTO{ID: 1, PersonID: 1, RelatedID: 2, Type: relation.TypeParent}, nil)
+
+				return s
+			},
+			in:             validReq,
+			expectedStatus: 201,
+		},
+		{
+			name: "with invalid request",
+			svc: func(t *testing.T) relation.Service {
+				return mocks.NewRelationService(t)
+			},
+			in:             map[string]string{"type": "parent"},
+			expectedStatus: 400,
+		},
+		{
+			name: "with invalid relationship",
+			svc: func(t *testing.T) relation.Service {
+				s := mocks.NewRelationService(t)
+				s.On("Create", mock.Anything, 1, mock.Anything).
+					Return(dto.RelationDTO{}, fmt.Errorf("%w: error", relation.ErrInvalidRelation))
+
+				return s
+			},
+			in:             validReq,
+			expectedStatus: 400,
+		},
+		{
+			name: "with unknown person",
+			svc: func(t *testing.T) relation.Service {
+				s := mocks.NewRelationService(t)
+				s.On("Create", mock.Anything, 1, mock.Anything).
+					Return(dto.RelationDTO{}, relation.ErrRecordNotFound)
+
+				return s
+			},
+			in:             validReq,
+			expectedStatus: 404,
+		},
+		{
+			name: "with internal error",
+			svc: func(t *testing.T) relation.Service {
+				s := mocks.NewRelationService(t)
+				s.On("Create", mock.Anything, 1, mock.Anything).
+					Return(dto.RelationDTO{}, fmt.Errorf("error"))
+
+				return s
+			},
+			in:             validReq,
+			expectedStatus: 500,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl, err := relation.NewController(relation.WithService(tc.svc(t)))
+			require.NoError(t, err)
+
+			srv := gin.Default()
+			gin.SetMode(gin.TestMode)
+
+			srv.POST("/person/:id/relations", ctrl.Create)
+
+			rec := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.in)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, "/person/1/relations", bytes.NewBuffer(data))
+			require.NoError(t, err)
+
+			srv.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestRelationController_GetAll(t *testing.T) {
+	cases := []struct {
+		name           string
+		svc            func(*testing.T) relation.Service
+		req            string
+		expectedStatus int
+	}{
+		{
+			name: "successfully",
+			svc: func(t *testing.T) relation.Service {
+				s := mocks.NewRelationService(t)
+				s.On("Graph", mock.Anything, 1, 1, (*time.Time)(nil)).
+					Return(dto.RelationGraphDTO{Root: 1, Depth: 1}, nil)
+
+				return s
+			},
+			req:            "",
+			expectedStatus: 200,
+		},
+		{
+			name: "successfully (depth 2, valid at a given date)",
+			svc: func(t *testing.T) relation.Service {
+				s := mocks.NewRelationService(t)
+				s.On("Graph", mock.Anything, 1, 2, mock.MatchedBy(func(at *time.Time) bool {
+					return at != nil && at.Year() == 2024
+				})).Return(dto.RelationGraphDTO{Root: 1, Depth: 2}, nil)
+
+				return s
+			},
+			req:            "?depth=2&at=2024-05-01",
+			expectedStatus: 200,
+		},
+		{
+			name: "with invalid date",
+			svc: func(t *testing.T) relation.Service {
+				return mocks.NewRelationService(t)
+			},
+			req:            "?at=yesterday",
+			expectedStatus: 400,
+		},
+		{
+			name: "with invalid depth",
+			svc: func(t *testing.T) relation.Service {
+				s := mocks.NewRelationService(t)
+				s.On("Graph", mock.Anything, 1, 9, (*time.Time)(nil)).
+					Return(dto.RelationGraphDTO{}, relation.ErrInvalidRelation)
+
+				return s
+			},
+			req:            "?depth=9",
+			expectedStatus: 400,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl, err := relation.NewController(relation.WithService(tc.svc(t)))
+			require.NoError(t, err)
+
+			srv := gin.Default()
+			gin.SetMode(gin.TestMode)
+
+			srv.GET("/person/:id/relations", ctrl.GetAll)
+
+			rec := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodGet, "/person/1/relations"+tc.req, nil)
+			require.NoError(t, err)
+
+			srv.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestRelationController_Delete(t *testing.T) {
+	cases := []struct {
+		name           string
+		svc            func(*testing.T) relation.Service
+		req            string
+		expectedStatus int
+	}{
+		{
+			name: "successfully",
+			svc: func(t *testing.T) relation.Service {
+				s := mocks.NewRelationService(t)
+				s.On("Delete", mock.Anything, 1, 3).Return(nil)
+
+				return s
+			},
+			req:            "3",
+			expectedStatus: 204,
+		},
+		{
+			name: "with invalid id",
+			svc: func(t *testing.T) relation.Service {
+				return mocks.NewRelationService(t)
+			},
+			req:            "$$",
+			expectedStatus: 400,
+		},
+		{
+			name: "with not-found error",
+			svc: func(t *testing.T) relation.Service {
+				s := mocks.NewRelationService(t)
+				s.On("Delete", mock.Anything, 1, 3).Return(relation.ErrRecordNotFound)
+
+				return s
+			},
+			req:            "3",
+			expectedStatus: 404,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl, err := relation.NewController(relation.WithService(tc.svc(t)))
+			require.NoError(t, err)
+
+			srv := gin.Default()
+			gin.SetMode(gin.TestMode)
+
+			srv.DELETE("/person/:id/relations/:rid", ctrl.Delete)
+
+			rec := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodDelete, "/person/1/relations/"+tc.req, nil)
+			require.NoError(t, err)
+
+			srv.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+		})
+	}
+}
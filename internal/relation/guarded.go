@@ -0,0 +1,68 @@
+package relation
+
+import (
+	"context"
+	"qore-be/internal/breaker"
+	"qore-be/internal/domain/dto"
+)
+
+// expected are the relation errors the breaker does not count as failures.
+var expected = []error{ErrRecordNotFound, ErrInvalidRelation}
+
+// GuardedRepo guards a repository with a circuit breaker, which also bounds
+// the concurrent calls of each operation.
+type GuardedRepo struct {
+	repo    Repository
+	breaker *breaker.Breaker
+}
+
+// NewGuardedRepository wraps the repository with the breaker. The operations
+// are named "relation.<method>", e.g. "relation.GetByPersons".
+func NewGuardedRepository(repo Repository, b *breaker.Breaker) *GuardedRepo {
+	if repo == nil || b == nil {
+		panic("nil repository or breaker")
+	}
+	return &GuardedRepo{repo: repo, breaker: b}
+}
+
+// Add implements Repository.
+func (r *GuardedRepo) Add(ctx context.Context, d dto.RelationDTO) (dto.RelationDTO, error) {
+	return breaker.Call(ctx, r.breaker, "relation.Add", func(ctx context.Context) (dto.RelationDTO, error) {
+		return r.repo.Add(ctx, d)
+	}, expected...)
+}
+
+// GetByID implements Repository.
+func (r *GuardedRepo) GetByID(ctx context.Context, id int) (dto.RelationDTO, error) {
+	return breaker.Call(ctx, r.breaker, "relation.GetByID", func(ctx context.Context) (dto.RelationDTO, error) {
+		return r.repo.GetByID(ctx, id)
+	}, expected...)
+}
+
+// Update implements Repository.
+func (r *GuardedRepo) Update(ctx context.Context, d dto.RelationDTO) (dto.RelationDTO, error) {
+	return breaker.Call(ctx, r.breaker, "relation.Update", func(ctx context.Context) (dto.RelationDTO, error) {
+		return r.repo.Update(ctx, d)
+	}, expected...)
+}
+
+// Delete implements Repository.
+func (r *GuardedRepo) Delete(ctx context.Context, id int) error {
+	return r.breaker.Do(ctx, "relation.Delete", func(ctx context.Context) error {
+		return r.repo.Delete(ctx, id)
+	}, expected...)
+}
+
+// GetByPersons implements Repository.
+func (r *GuardedRepo) GetByPersons(ctx context.Context, ids []int) ([]dto.RelationDTO, error) {
+	return breaker.Call(ctx, r.breaker, "relation.GetByPersons", func(ctx context.Context) ([]dto.RelationDTO, error) {
+		return r.repo.GetByPersons(ctx, ids)
+	}, expected...)
+}
+
+// GetPersons implements Repository.
+func (r *GuardedRepo) GetPersons(ctx context.Context, ids []int) ([]dto.PersonDTO, error) {
+	return breaker.Call(ctx, r.breaker, "relation.GetPersons", func(ctx context.Context) ([]dto.PersonDTO, error) {
+		return r.repo.GetPersons(ctx, ids)
+	}, expected...)
+}
@@ -0,0 +1,177 @@
+package relation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"qore-be/internal/database"
+	"qore-be/internal/domain/dto"
+	"qore-be/internal/domain/entities"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRecordNotFound = fmt.Errorf("not found")
+)
+
+// Repo represents the relationship repository.
+type Repo struct {
+	db      *gorm.DB
+	timeout time.Duration
+}
+
+// NewRepository create a new instance of the relationship repository.
+func NewRepository(db *gorm.DB, opts ...RepoOption) *Repo {
+	if db == nil {
+		panic("nil db")
+	}
+
+	r := &Repo{
+		db: db,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// RepoOption ..
+type RepoOption func(*Repo)
+
+// WithQueryTimeout bounds the queries of every repository call.
+func WithQueryTimeout(d time.Duration) RepoOption {
+	return func(r *Repo) {
+		r.timeout = d
+	}
+}
+
+// Add saves a new relationship to the database.
+func (r *Repo) Add(ctx context.Context, d dto.RelationDTO) (dto.RelationDTO, error) {
+	ctx, cancel := database.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rel := toEntity(d)
+	if err := r.db.WithContext(ctx).Create(&rel).Error; err != nil {
+		return dto.RelationDTO{}, fmt.Errorf("failed to save relationship: %v", err)
+	}
+
+	return toDTO(rel), nil
+}
+
+// GetByID retrieves a relationship by its ID.
+func (r *Repo) GetByID(ctx context.Context, id int) (dto.RelationDTO, error) {
+	ctx, cancel := database.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rel := entities.Relationship{}
+	if err := r.db.WithContext(ctx).First(&rel, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.RelationDTO{}, ErrRecordNotFound
+		}
+		return dto.RelationDTO{}, fmt.Errorf("failed to get relationship: %v", err)
+	}
+
+	return toDTO(rel), nil
+}
+
+// Update saves the changes of an existing relationship.
+func (r *Repo) Update(ctx context.Context, d dto.RelationDTO) (dto.RelationDTO, error) {
+	ctx, cancel := database.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rel := toEntity(d)
+	err := r.db.WithContext(ctx).Model(&rel).
+		Select("person_id", "related_id", "type", "valid_from", "valid_to").
+		Updates(&rel).Error
+	if err != nil {
+		return dto.RelationDTO{}, fmt.Errorf("failed to update relationship: %v", err)
+	}
+
+	return toDTO(rel), nil
+}
+
+// Delete removes a relationship.
+func (r *Repo) Delete(ctx context.Context, id int) error {
+	ctx, cancel := database.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx := r.db.WithContext(ctx).Delete(&entities.Relationship{}, "id = ?", id)
+	if tx.Error != nil {
+		return fmt.Errorf("failed to delete relationship: %v", tx.Error)
+	}
+
+	if tx.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetByPersons retrieves the relationships from or to any of the given persons.
+func (r *Repo) GetByPersons(ctx context.Context, ids []int) ([]dto.RelationDTO, error) {
+	ctx, cancel := database.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rels := []entities.Relationship{}
+	tx := r.db.WithContext(ctx).
+		Where("person_id IN ? OR related_id IN ?", ids, ids).
+		Order("id").
+		Find(&rels)
+	if tx.Error != nil {
+		return nil, fmt.Errorf("failed to get relationships: %v", tx.Error)
+	}
+
+	dtos := make([]dto.RelationDTO, len(rels))
+	for i, rel := range rels {
+		dtos[i] = toDTO(rel)
+	}
+	return dtos, nil
+}
+
+// GetPersons retrieves the given persons, missing ones are ignored.
+func (r *Repo) GetPersons(ctx context.Context, ids []int) ([]dto.PersonDTO, error) {
+	ctx, cancel := database.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	persons := []entities.Person{}
+	tx := r.db.WithContext(ctx).Where("id IN ?", ids).Order("id").Find(&persons)
+	if tx.Error != nil {
+		return nil, fmt.Errorf("failed to get person data: %v", tx.Error)
+	}
+
+	dtos := make([]dto.PersonDTO, len(persons))
+	for i, p := range persons {
+		dtos[i] = dto.PersonDTO{
+			ID:   p.ID,
+			Name: p.Name,
+			Age:  p.Age,
+		}
+	}
+	return dtos, nil
+}
+
+func toEntity(d dto.RelationDTO) entities.Relationship {
+	return entities.Relationship{
+		ID:        d.ID,
+		PersonID:  d.PersonID,
+		RelatedID: d.RelatedID,
+		Type:      d.Type,
+		ValidFrom: d.ValidFrom,
+		ValidTo:   d.ValidTo,
+	}
+}
+
+func toDTO(rel entities.Relationship) dto.RelationDTO {
+	return dto.RelationDTO{
+		ID:        rel.ID,
+		PersonID:  rel.PersonID,
+		RelatedID: rel.RelatedID,
+		Type:      rel.Type,
+		ValidFrom: rel.ValidFrom,
+		ValidTo:   rel.ValidTo,
+	}
+}
@@ -0,0 +1,268 @@
+package relation
+
+import (
+	"context"
+	"fmt"
+	"qore-be/internal/domain/dto"
+	"qore-be/internal/logging"
+	"time"
+)
+
+// Relationship types.
+const (
+	TypeParent           = "parent"
+	TypeChild            = "child"
+	TypeSpouse           = "spouse"
+	TypeEmergencyContact = "emergency_contact"
+	TypeGuardian         = "guardian"
+)
+
+// MaxDepth is the deepest relationship graph traversal allowed.
+const MaxDepth = 5
+
+var (
+	ErrInvalidRelation = fmt.Errorf("invalid relationship")
+
+	types = map[string]bool{
+		TypeParent:           true,
+		TypeChild:            true,
+		TypeSpouse:           true,
+		TypeEmergencyContact: true,
+		TypeGuardian:         true,
+	}
+)
+
+// Repository the relationship repository.
+//
+//go:generate mockery --name=Repository --structname=RelationRepository  --case underscore --output=../mocks/ --filename=relation_repository.go
+type Repository interface {
+	Add(context.Context, dto.RelationDTO) (dto.RelationDTO, error)
+	GetByID(context.Context, int) (dto.RelationDTO, error)
+	Update(context.Context, dto.RelationDTO) (dto.RelationDTO, error)
+	Delete(context.Context, int) error
+	GetByPersons(context.Context, []int) ([]dto.RelationDTO, error)
+	GetPersons(context.Context, []int) ([]dto.PersonDTO, error)
+}
+
+// ServiceImpl implements the relationship service.
+type ServiceImpl struct {
+	db Repository
+}
+
+// ServiceOption ..
+type ServiceOption func(*ServiceImpl) error
+
+// NewService creates a new relationship service.
+func NewService(opts ...ServiceOption) (*ServiceImpl, error) {
+	svc := &ServiceImpl{}
+
+	for _, opt := range opts {
+		err := opt(svc)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if svc.db == nil {
+		return nil, fmt.Errorf("missing relationship DB")
+	}
+
+	return svc, nil
+}
+
+// WithRepository returns a closure that initialize the relationship-service with the relationship-repository.
+func WithRepository(db Repository) ServiceOption {
+	return func(svc *ServiceImpl) error {
+		svc.db = db
+		return nil
+	}
+}
+
+// Create saves a new relationship of the given person.
+func (s *ServiceImpl) Create(ctx context.Context, personID int, d dto.RelationDTO) (dto.RelationDTO, error) {
+	d.ID = 0
+	d.PersonID = personID
+	if err := s.validate(ctx, d); err != nil {
+		return dto.RelationDTO{}, err
+	}
+
+	rel, err := s.db.Add(ctx, d)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to save the relationship", "error", err.Error())
+		return dto.RelationDTO{}, err
+	}
+
+	logging.FromContext(ctx).Info("relationship created with success", "relation", rel.ID)
+	return rel, nil
+}
+
+// Get retrieves a relationship of the given person.
+func (s *ServiceImpl) Get(ctx context.Context, personID int, id int) (dto.RelationDTO, error) {
+	rel, err := s.db.GetByID(ctx, id)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to get the relationship by id", "id", id, "error", err.Error())
+		return dto.RelationDTO{}, err
+	}
+
+	if rel.PersonID != personID {
+		return dto.RelationDTO{}, ErrRecordNotFound
+	}
+
+	return rel, nil
+}
+
+// Update replaces a relationship of the given person.
+func (s *ServiceImpl) Update(ctx context.Context, personID int, id int, d dto.RelationDTO) (dto.RelationDTO, error) {
+	if _, err := s.Get(ctx, personID, id); err != nil {
+		return dto.RelationDTO{}, err
+	}
+
+	d.ID = id
+	d.PersonID = personID
+	if err := s.validate(ctx, d); err != nil {
+		return dto.RelationDTO{}, err
+	}
+
+	rel, err := s.db.Update(ctx, d)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to update the relationship", "id", id, "error", err.Error())
+		return dto.RelationDTO{}, err
+	}
+
+	logging.FromContext(ctx).Info("relationship updated with success", "relation", id)
+	return rel, nil
+}
+
+// Delete removes a relationship of the given person.
+func (s *ServiceImpl) Delete(ctx context.Context, personID int, id int) error {
+	if _, err := s.Get(ctx, personID, id); err != nil {
+		return err
+	}
+
+	if err := s.db.Delete(ctx, id); err != nil {
+		logging.FromContext(ctx).Error("failed to delete the relationship", "id", id, "error", err.Error())
+		return err
+	}
+
+	logging.FromContext(ctx).Info("relationship deleted with success", "relation", id)
+	return nil
+}
+
+// Graph retrieves the relationship graph around a person, up to the given
+// depth. Relationships are followed in both directions and every person is
+// visited once, so cycles do not loop. When at is set, only the relationships
+// valid at that time are followed.
+func (s *ServiceImpl) Graph(ctx context.Context, personID int, depth int, at *time.Time) (dto.RelationGraphDTO, error) {
+	if depth < 1 || depth > MaxDepth {
+		return dto.RelationGraphDTO{}, fmt.Errorf("%w: depth must be between 1 and %d", ErrInvalidRelation, MaxDepth)
+	}
+
+	root, err := s.db.GetPersons(ctx, []int{personID})
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to get the person", "id", personID, "error", err.Error())
+		return dto.RelationGraphDTO{}, err
+	}
+
+	if len(root) == 0 {
+		return dto.RelationGraphDTO{}, ErrRecordNotFound
+	}
+
+	depths := map[int]int{personID: 0}
+	seen := map[int]bool{}
+	relations := []dto.RelationDTO{}
+
+	frontier := []int{personID}
+	for level := 1; level <= depth && len(frontier) > 0; level++ {
+		rels, err := s.db.GetByPersons(ctx, frontier)
+		if err != nil {
+			logging.FromContext(ctx).Error("failed to get the relationships", "error", err.Error())
+			return dto.RelationGraphDTO{}, err
+		}
+
+		next := []int{}
+		for _, rel := range rels {
+			if seen[rel.ID] || !validAt(rel, at) {
+				continue
+			}
+			seen[rel.ID] = true
+			relations = append(relations, rel)
+
+			for _, id := range []int{rel.PersonID, rel.RelatedID} {
+				if _, ok := depths[id]; !ok {
+					depths[id] = level
+					next = append(next, id)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	ids := make([]int, 0, len(depths))
+	for id := range depths {
+		ids = append(ids, id)
+	}
+
+	persons, err := s.db.GetPersons(ctx, ids)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to get the persons", "error", err.Error())
+		return dto.RelationGraphDTO{}, err
+	}
+
+	nodes := make([]dto.RelationNodeDTO, 0, len(persons))
+	for _, p := range persons {
+		nodes = append(nodes, dto.RelationNodeDTO{
+			ID:    p.ID,
+			Name:  p.Name,
+			Depth: depths[p.ID],
+		})
+	}
+
+	return dto.RelationGraphDTO{
+		Root:      personID,
+		Depth:     depth,
+		Nodes:     nodes,
+		Relations: relations,
+	}, nil
+}
+
+func (s *ServiceImpl) validate(ctx context.Context, d dto.RelationDTO) error {
+	if !types[d.Type] {
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidRelation, d.Type)
+	}
+
+	if d.PersonID == d.RelatedID {
+		return fmt.Errorf("%w: a person cannot be related to itself", ErrInvalidRelation)
+	}
+
+	if d.ValidFrom != nil && d.ValidTo != nil && d.ValidTo.Before(*d.ValidFrom) {
+		return fmt.Errorf("%w: valid_to is before valid_from", ErrInvalidRelation)
+	}
+
+	persons, err := s.db.GetPersons(ctx, []int{d.PersonID, d.RelatedID})
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to get the persons", "error", err.Error())
+		return err
+	}
+
+	if len(persons) != 2 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func validAt(rel dto.RelationDTO, at *time.Time) bool {
+	if at == nil {
+		return true
+	}
+
+	if rel.ValidFrom != nil && at.Before(*rel.ValidFrom) {
+		return false
+	}
+
+	if rel.ValidTo != nil && at.After(*rel.ValidTo) {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,257 @@
+package relation_test
+
+import (
+	"context"
+	"fmt"
+	"qore-be/internal/domain/dto"
+	"qore-be/internal/mocks"
+	"qore-be/internal/relation"
+
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRelationService(t *testing.T) {
+	t.Run("successfully", func(t *testing.T) {
+		svc, err := relation.NewService(relation.WithRepository(&relation.Repo{}))
+		assert.NoError(t, err)
+		assert.NotNil(t, svc)
+	})
+
+	t.Run("with missing relationship repo", func(t *testing.T) {
+		svc, err := relation.NewService()
+		assert.Error(t, err)
+		assert.Nil(t, svc)
+	})
+}
+
+func TestRelationService_Create(t *testing.T) {
+	from := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	before := from.Add(-time.Hour)
+
+	cases := []struct {
+		name   string
+		db     func(*testing.T) relation.Repository
+		in     dto.RelationDTO
+		hasErr error
+	}{
+		{
+			name: "successfully",
+			db: func(t *testing.T) relation.Repository {
+				d := mocks.NewRelationRepository(t)
+				d.On("GetPersons", mock.Anything, []int{1, 2}).
+					Return([]dto.PersonDTO{{ID: 1}, {ID: 2}}, nil)
+				d.On("Add", mock.Anything, dto.RelationDTO{PersonID: 1, RelatedID: 2, Type: relation.TypeParent}).
+					Return(dto.RelationDTO{ID: 1, PersonID: 1, RelatedID: 2, Type: relation.TypeParent}, nil)
+
+				return d
+			},
+			in: dto.RelationDTO{RelatedID: 2, Type: relation.TypeParent},
+		},
+		{
+			name: "with unknown type",
+			db: func(t *testing.T) relation.Repository {
+				return mocks.NewRelationRepository(t)
+			},
+			in:     dto.RelationDTO{RelatedID: 2, Type: "friend"},
+			hasErr: relation.ErrInvalidRelation,
+		},
+		{
+			name: "with the person itself",
+			db: func(t *testing.T) relation.Repository {
+				return mocks.NewRelationRepository(t)
+			},
+			in:     dto.RelationDTO{RelatedID: 1, Type: relation.TypeSpouse},
+			hasErr: relation.ErrInvalidRelation,
+		},
+		{
+			name: "with invalid validity dates",
+			db: func(t *testing.T) relation.Repository {
+				return mocks.NewRelationRepository(t)
+			},
+			in:     dto.RelationDTO{RelatedID: 2, Type: relation.TypeSpouse, ValidFrom: &from, ValidTo: &before},
+			hasErr: relation.ErrInvalidRelation,
+		},
+		{
+			name: "with unknown related person",
+			db: func(t *testing.T) relation.Repository {
+				d := mocks.NewRelationRepository(t)
+				d.On("GetPersons", mock.Anything, []int{1, 2}).
+					Return([]dto.PersonDTO{{ID: 1}}, nil)
+
+				return d
+			},
+			in:     dto.RelationDTO{RelatedID: 2, Type: relation.TypeGuardian},
+			hasErr: relation.ErrRecordNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc, err := relation.NewService(relation.WithRepository(tc.db(t)))
+			require.NoError(t, err)
+
+			res, err := svc.Create(context.TODO(), 1, tc.in)
+			if tc.hasErr != nil {
+				assert.ErrorIs(t, err, tc.hasErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, 1, res.PersonID)
+		})
+	}
+}
+
+func TestRelationService_Delete(t *testing.T) {
+	cases := []struct {
+		name   string
+		db     func(*testing.T) relation.Repository
+		hasErr bool
+	}{
+		{
+			name: "successfully",
+			db: func(t *testing.T) relation.Repository {
+				d := mocks.NewRelationRepository(t)
+				d.On("GetByID", mock.Anything, 3).Return(dto.RelationDTO{ID: 3, PersonID: 1}, nil)
+				d.On("Delete", mock.Anything, 3).Return(nil)
+
+				return d
+			},
+		},
+		{
+			name: "with relationship of another person",
+			db: func(t *testing.T) relation.Repository {
+				d := mocks.NewRelationRepository(t)
+				d.On("GetByID", mock.Anything, 3).Return(dto.RelationDTO{ID: 3, PersonID: 9}, nil)
+
+				return d
+			},
+			hasErr: true,
+		},
+		{
+			name: "with error",
+			db: func(t *testing.T) relation.Repository {
+				d := mocks.NewRelationRepository(t)
+				d.On("GetByID", mock.Anything, 3).Return(dto.RelationDTO{ID: 3, PersonID: 1}, nil)
+				d.On("Delete", mock.Anything, 3).Return(fmt.Errorf("error"))
+
+				return d
+			},
+			hasErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc, err := relation.NewService(relation.WithRepository(tc.db(t)))
+			require.NoError(t, err)
+
+			err = svc.Delete(context.TODO(), 1, 3)
+			assert.Equal(t, !tc.hasErr, err == nil)
+		})
+	}
+}
+
+func TestRelationService_Graph(t *testing.T) {
+	ended := time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// 1 -> 2 -> 3 -> 1 is a cycle, 3 -> 4 ended in 2010.
+	rels := []dto.RelationDTO{
+		{ID: 1, PersonID: 1, RelatedID: 2, Type: relation.TypeParent},
+		{ID: 2, PersonID: 2, RelatedID: 3, Type: relation.TypeSpouse},
+		{ID: 3, PersonID: 3, RelatedID: 1, Type: relation.TypeGuardian},
+		{ID: 4, PersonID: 3, RelatedID: 4, Type: relation.TypeEmergencyContact, ValidTo: &ended},
+	}
+
+	byPersons := func(ids []int) []dto.RelationDTO {
+		res := []dto.RelationDTO{}
+		for _, r := range rels {
+			for _, id := range ids {
+				if r.PersonID == id || r.RelatedID == id {
+					res = append(res, r)
+					break
+				}
+			}
+		}
+		return res
+	}
+
+	persons := func(ids []int) []dto.PersonDTO {
+		res := []dto.PersonDTO{}
+		for _, id := range ids {
+			res = append(res, dto.PersonDTO{ID: id, Name: fmt.Sprintf("p%d", id)})
+		}
+		return res
+	}
+
+	newRepo := func(t *testing.T) relation.Repository {
+		d := mocks.NewRelationRepository(t)
+		d.On("GetPersons", mock.Anything, mock.Anything).
+			Return(func(_ context.Context, ids []int) ([]dto.PersonDTO, error) { return persons(ids), nil })
+		d.On("GetByPersons", mock.Anything, mock.Anything).
+			Return(func(_ context.Context, ids []int) ([]dto.RelationDTO, error) { return byPersons(ids), nil })
+
+		return d
+	}
+
+	t.Run("depth 1", func(t *testing.T) {
+		svc, err := relation.NewService(relation.WithRepository(newRepo(t)))
+		require.NoError(t, err)
+
+		g, err := svc.Graph(context.TODO(), 1, 1, nil)
+		require.NoError(t, err)
+		assert.Len(t, g.Relations, 2)
+		assert.Len(t, g.Nodes, 3)
+	})
+
+	t.Run("depth 2 with a cycle", func(t *testing.T) {
+		svc, err := relation.NewService(relation.WithRepository(newRepo(t)))
+		require.NoError(t, err)
+
+		g, err := svc.Graph(context.TODO(), 1, 2, nil)
+		require.NoError(t, err)
+		assert.Len(t, g.Relations, 4)
+		assert.Len(t, g.Nodes, 4)
+
+		for _, n := range g.Nodes {
+			if n.ID == 4 {
+				assert.Equal(t, 2, n.Depth)
+			}
+		}
+	})
+
+	t.Run("valid at a given time", func(t *testing.T) {
+		svc, err := relation.NewService(relation.WithRepository(newRepo(t)))
+		require.NoError(t, err)
+
+		now := time.Now()
+		g, err := svc.Graph(context.TODO(), 1, 3, &now)
+		require.NoError(t, err)
+		assert.Len(t, g.Relations, 3)
+		assert.Len(t, g.Nodes, 3)
+	})
+
+	t.Run("with invalid depth", func(t *testing.T) {
+		svc, err := relation.NewService(relation.WithRepository(mocks.NewRelationRepository(t)))
+		require.NoError(t, err)
+
+		_, err = svc.Graph(context.TODO(), 1, relation.MaxDepth+1, nil)
+		assert.ErrorIs(t, err, relation.ErrInvalidRelation)
+	})
+
+	t.Run("with unknown person", func(t *testing.T) {
+		d := mocks.NewRelationRepository(t)
+		d.On("GetPersons", mock.Anything, []int{1}).Return([]dto.PersonDTO{}, nil)
+
+		svc, err := relation.NewService(relation.WithRepository(d))
+		require.NoError(t, err)
+
+		_, err = svc.Graph(context.TODO(), 1, 1, nil)
+		assert.ErrorIs(t, err, relation.ErrRecordNotFound)
+	})
+}
@@ -0,0 +1,49 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var ErrNotFound = fmt.Errorf("secret not found")
+
+// Provider reads the secrets by name.
+type Provider interface {
+	Get(ctx context.Context, name string) (string, error)
+}
+
+// Dir reads the secrets from the files of a directory, e.g. the Docker or
+// Kubernetes secrets mounted in /run/secrets. The secrets are named by file.
+type Dir struct {
+	path string
+}
+
+// NewDir creates the provider of the secrets of the directory.
+func NewDir(path string) *Dir {
+	return &Dir{path: path}
+}
+
+// Get implements Provider.
+func (d *Dir) Get(_ context.Context, name string) (string, error) {
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("invalid secret name: %q", name)
+	}
+
+	return ReadFile(filepath.Join(d.path, name))
+}
+
+// ReadFile reads a secret from a file, without its trailing newline.
+func ReadFile(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read the secret file: %v", err)
+	}
+
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
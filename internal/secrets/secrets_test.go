@@ -0,0 +1,106 @@
+package secrets_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"qore-be/internal/secrets"
+	"strings"
+
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDir(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "db_url"), []byte("qore:s3cr3t@tcp(mysql:3306)/qore\n"), 0o600))
+
+	p := secrets.NewDir(dir)
+
+	v, err := p.Get(ctx, "db_url")
+	require.NoError(t, err)
+	assert.Equal(t, "qore:s3cr3t@tcp(mysql:3306)/qore", v)
+
+	_, err = p.Get(ctx, "admin_token")
+	assert.ErrorIs(t, err, secrets.ErrNotFound)
+
+	_, err = p.Get(ctx, "../etc/passwd")
+	assert.ErrorContains(t, err, "invalid secret name")
+}
+
+// vaultStub serves the KV version 2 secrets, mounted at "secret".
+func vaultStub(t *testing.T, token string, data map[string]map[string]any) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != token {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+
+		path, ok := strings.CutPrefix(r.URL.Path, "/v1/secret/data/")
+		secret, found := data[path]
+		if !ok || !found {
+			http.Error(w, `{"errors":[]}`, http.StatusNotFound)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"data": secret}})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestVault(t *testing.T) {
+	ctx := context.Background()
+	srv := vaultStub(t, "t0k3n", map[string]map[string]any{
+		"qore/db": {"url": "qore:s3cr3t@tcp(mysql:3306)/qore", "port": 3306},
+	})
+
+	p, err := secrets.NewVault(srv.URL, "t0k3n", "secret")
+	require.NoError(t, err)
+
+	v, err := p.Get(ctx, "qore/db#url")
+	require.NoError(t, err)
+	assert.Equal(t, "qore:s3cr3t@tcp(mysql:3306)/qore", v)
+
+	tests := []struct {
+		name   string
+		secret string
+		err    string
+	}{
+		{name: "without key", secret: "qore/db", err: "invalid secret name"},
+		{name: "unknown secret", secret: "qore/cache#url", err: secrets.ErrNotFound.Error()},
+		{name: "unknown key", secret: "qore/db#password", err: secrets.ErrNotFound.Error()},
+		{name: "not a string", secret: "qore/db#port", err: "is not a string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := p.Get(ctx, tt.secret)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+
+	denied, err := secrets.NewVault(srv.URL, "wrong", "secret")
+	require.NoError(t, err)
+	_, err = denied.Get(ctx, "qore/db#url")
+	assert.ErrorContains(t, err, "403 Forbidden: {\"errors\":[\"permission denied\"]}")
+}
+
+func TestNewVault(t *testing.T) {
+	_, err := secrets.NewVault("not a url", "t0k3n", "secret")
+	assert.Error(t, err)
+
+	_, err = secrets.NewVault("http://127.0.0.1:8200", "", "secret")
+	assert.Error(t, err)
+
+	_, err = secrets.NewVault("http://127.0.0.1:8200", "t0k3n", "")
+	assert.Error(t, err)
+
+	_, err = secrets.NewVault("http://127.0.0.1:8200", "t0k3n", "secret", secrets.WithHTTPClient(nil))
+	assert.Error(t, err)
+}
@@ -0,0 +1,112 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Vault reads the secrets from the KV version 2 secrets engine of a
+// HashiCorp Vault compatible server. The secrets are named "<path>#<key>",
+// e.g. "qore/db#url" for the url key of the qore/db secret.
+type Vault struct {
+	addr   string
+	token  string
+	mount  string
+	client *http.Client
+}
+
+// VaultOption ..
+type VaultOption func(*Vault) error
+
+// NewVault creates the provider of the secrets of the engine mounted at
+// mount, e.g. "secret", of the server at addr, authenticated by token.
+func NewVault(addr string, token string, mount string, opts ...VaultOption) (*Vault, error) {
+	if _, err := url.ParseRequestURI(addr); err != nil {
+		return nil, fmt.Errorf("invalid vault address: %v", err)
+	}
+	if token == "" {
+		return nil, fmt.Errorf("missing vault token")
+	}
+	if mount == "" {
+		return nil, fmt.Errorf("missing vault mount")
+	}
+
+	v := &Vault{
+		addr:   strings.TrimSuffix(addr, "/"),
+		token:  token,
+		mount:  strings.Trim(mount, "/"),
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+
+	for _, opt := range opts {
+		if err := opt(v); err != nil {
+			return nil, err
+		}
+	}
+
+	return v, nil
+}
+
+// WithHTTPClient sets the client of the Vault API.
+func WithHTTPClient(c *http.Client) VaultOption {
+	return func(v *Vault) error {
+		if c == nil {
+			return fmt.Errorf("nil http client")
+		}
+		v.client = c
+		return nil
+	}
+}
+
+// Get implements Provider.
+func (v *Vault) Get(ctx context.Context, name string) (string, error) {
+	path, key, ok := strings.Cut(name, "#")
+	if !ok || path == "" || key == "" {
+		return "", fmt.Errorf("invalid secret name: %q, expected <path>#<key>", name)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.addr+"/v1/"+v.mount+"/data/"+strings.Trim(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the vault secret %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", fmt.Errorf("%w: %s", ErrNotFound, path)
+	case resp.StatusCode != http.StatusOK:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("failed to read the vault secret %s: %s: %s", path, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var secret struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return "", fmt.Errorf("failed to decode the vault secret %s: %v", path, err)
+	}
+
+	value, ok := secret.Data.Data[key]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("vault secret %s is not a string", name)
+	}
+	return s, nil
+}
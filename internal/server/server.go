@@ -2,23 +2,50 @@ package server
 
 import (
 	"context"
+	"qore-be/internal/attribute"
+	"qore-be/internal/certs"
 	"qore-be/internal/config"
+	"qore-be/internal/database"
+	"qore-be/internal/debug"
+	"qore-be/internal/feature"
+	"qore-be/internal/health"
+	"qore-be/internal/logging"
+	"qore-be/internal/metrics"
+	"qore-be/internal/middleware"
 	"qore-be/internal/person"
+	"qore-be/internal/relation"
+	"qore-be/internal/tenant"
+	"qore-be/internal/tracing"
 
+	"crypto/tls"
 	"fmt"
-	"log/slog"
+	"net"
 	"net/http"
-	"os"
+	"net/netip"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 // Server the http server.
 type Server struct {
-	cfg    *config.Config
-	person *person.Controller
+	cfg       *config.Config
+	person    *person.Controller
+	relation  *relation.Controller
+	attribute *attribute.Controller
+	health    *health.Controller
+	debug     *debug.Controller
+	metrics   *metrics.Metrics
+	tracer    trace.TracerProvider
+	replicas  *database.Replicas
+	origins   *middleware.Origins
+	limiter   *middleware.RateLimiter
+	features  *feature.Flags
+	tls       *certs.Loader
 
 	router *gin.Engine
 }
@@ -60,49 +87,321 @@ func WithPersonController(c *person.Controller) Option {
 	}
 }
 
-// Start starts the http server.
+// WithRelationController initialize the server with a relationship controller.
+func WithRelationController(c *relation.Controller) Option {
+	return func(svc *Server) error {
+		if c == nil {
+			return fmt.Errorf("nil relationship controller")
+		}
+		svc.relation = c
+		return nil
+	}
+}
+
+// WithAttributeController initialize the server with a custom attribute controller.
+func WithAttributeController(c *attribute.Controller) Option {
+	return func(svc *Server) error {
+		if c == nil {
+			return fmt.Errorf("nil attribute controller")
+		}
+		svc.attribute = c
+		return nil
+	}
+}
+
+// WithHealthController initialize the server with the health controller.
+func WithHealthController(c *health.Controller) Option {
+	return func(svc *Server) error {
+		if c == nil {
+			return fmt.Errorf("nil health controller")
+		}
+		svc.health = c
+		return nil
+	}
+}
+
+// WithDebugController initialize the server with the debug controller.
+func WithDebugController(c *debug.Controller) Option {
+	return func(svc *Server) error {
+		if c == nil {
+			return fmt.Errorf("nil debug controller")
+		}
+		svc.debug = c
+		return nil
+	}
+}
+
+// WithMetrics initialize the server with the prometheus metrics.
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(svc *Server) error {
+		if m == nil {
+			return fmt.Errorf("nil metrics")
+		}
+		svc.metrics = m
+		return nil
+	}
+}
+
+// WithTracerProvider initialize the server with the tracer provider creating the requests spans.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(svc *Server) error {
+		if tp == nil {
+			return fmt.Errorf("nil tracer provider")
+		}
+		svc.tracer = tp
+		return nil
+	}
+}
+
+// WithReplicas initialize the server with the read replicas, whose clients
+// read their writes back.
+func WithReplicas(r *database.Replicas) Option {
+	return func(svc *Server) error {
+		if r == nil {
+			return fmt.Errorf("nil replicas")
+		}
+		svc.replicas = r
+		return nil
+	}
+}
+
+// WithOrigins initialize the server with the origins allowed to call the
+// API, instead of any.
+func WithOrigins(o *middleware.Origins) Option {
+	return func(svc *Server) error {
+		if o == nil {
+			return fmt.Errorf("nil origins")
+		}
+		svc.origins = o
+		return nil
+	}
+}
+
+// WithRateLimiter initialize the server with the rate limiter of the API
+// clients.
+func WithRateLimiter(l *middleware.RateLimiter) Option {
+	return func(svc *Server) error {
+		if l == nil {
+			return fmt.Errorf("nil rate limiter")
+		}
+		svc.limiter = l
+		return nil
+	}
+}
+
+// WithFeatures initialize the server with the flags switching the optional
+// features off.
+func WithFeatures(f *feature.Flags) Option {
+	return func(svc *Server) error {
+		if f == nil {
+			return fmt.Errorf("nil feature flags")
+		}
+		svc.features = f
+		return nil
+	}
+}
+
+// WithTLS initialize the server with the certificates serving the API over
+// TLS.
+func WithTLS(l *certs.Loader) Option {
+	return func(svc *Server) error {
+		if l == nil {
+			return fmt.Errorf("nil certificate loader")
+		}
+		svc.tls = l
+		return nil
+	}
+}
+
+// Start listens on the configured host and serves the API until ctx is done,
+// see Serve.
 func (s *Server) Start(ctx context.Context) error {
-	server := &http.Server{
-		Addr:    s.cfg.Host,
-		Handler: s.router,
+	ln, err := net.Listen("tcp", s.cfg.Host)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.cfg.Host, err)
 	}
 
-	ch := make(chan error)
+	return s.Serve(ctx, ln)
+}
+
+// Serve serves the API on the listener until ctx is done, then shuts down
+// gracefully: the readiness probe fails for ShutdownDelay, then the listener
+// is closed and the requests in flight are given ShutdownTimeout to end. It
+// returns nil once they all ended, or the error that stopped the server.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	server := s.newHTTPServer()
+
+	errc := make(chan error, 1)
 	go func() {
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			ch <- err
+		if server.TLSConfig != nil {
+			errc <- server.ServeTLS(ln, "", "")
+			return
 		}
+		errc <- server.Serve(ln)
 	}()
 
-	for {
-		select {
-		case <-ctx.Done():
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			err := server.Shutdown(ctx)
-			cancel()
-			if err != nil {
-				slog.Error("failed to shutdown server", "error", err.Error())
-				os.Exit(-1)
-			}
-		case err := <-ch:
-			if err != http.ErrServerClosed {
-				slog.Error("failed to start the server", "error", err.Error())
-				os.Exit(-1)
-			}
+	select {
+	case err := <-errc:
+		return fmt.Errorf("failed to serve: %w", err)
+	case <-ctx.Done():
+	}
+
+	log := logging.FromContext(ctx)
+	log.Info("shutting down the server", "delay", s.cfg.ShutdownDelay.String(), "timeout", s.cfg.ShutdownTimeout.String())
+	if s.health != nil {
+		s.health.Drain()
+	}
+
+	select {
+	case err := <-errc:
+		return fmt.Errorf("failed to serve: %w", err)
+	case <-time.After(s.cfg.ShutdownDelay):
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		// the requests still in flight are cut off.
+		server.Close()
+		return fmt.Errorf("failed to drain the requests: %w", err)
+	}
+	<-errc
+
+	log.Info("server shut down")
+	return nil
+}
+
+func (s *Server) newHTTPServer() *http.Server {
+	var handler http.Handler = s.router
+	if s.cfg.ServerH2C {
+		handler = h2c.NewHandler(handler, &http2.Server{IdleTimeout: s.cfg.ServerIdleTimeout})
+	}
+
+	server := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: s.cfg.ServerReadHeaderTimeout,
+		ReadTimeout:       s.cfg.ServerReadTimeout,
+		WriteTimeout:      s.cfg.ServerWriteTimeout,
+		IdleTimeout:       s.cfg.ServerIdleTimeout,
+		MaxHeaderBytes:    s.cfg.ServerMaxHeaderBytes,
+	}
+
+	if s.tls != nil {
+		server.TLSConfig = s.tls.Config()
+		server.TLSConfig.NextProtos = []string{"http/1.1"}
+		if s.cfg.ServerHTTP2 {
+			server.TLSConfig.NextProtos = []string{"h2", "http/1.1"}
+		} else {
+			// a non-nil map disables HTTP/2.
+			server.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
 		}
 	}
+
+	return server
+}
+
+func (s *Server) tenantOptions() []tenant.Option {
+	var opts []tenant.Option
+	for _, network := range s.cfg.TenantTrustedProxies {
+		// the networks are validated by the configuration.
+		if p, err := netip.ParsePrefix(network); err == nil {
+			opts = append(opts, tenant.WithTrustedProxies(p))
+		}
+	}
+
+	if s.cfg.TenantFromCertificate {
+		opts = append(opts, tenant.WithClientCertificate())
+	}
+	return opts
 }
 
 func (s *Server) setupRouter() {
 	router := gin.Default()
+	// lets the handlers context expose the request context values.
+	router.ContextWithFallback = true
+
+	if s.metrics != nil {
+		router.Use(s.metrics.Middleware())
+		router.GET("/metrics", s.metrics.Handler())
+	}
+
+	if s.tracer != nil {
+		router.Use(tracing.Middleware(s.tracer))
+	}
+	router.Use(middleware.RequestID())
+	if s.cfg.ServerMaxBodyBytes > 0 {
+		router.Use(middleware.BodyLimit(s.cfg.ServerMaxBodyBytes))
+	}
 
 	// CORS middleware
-	router.Use(cors.Default())
+	if s.origins != nil {
+		router.Use(middleware.CORS(s.origins))
+	} else {
+		router.Use(cors.Default())
+	}
+	router.Use(tenant.Middleware(s.tenantOptions()...))
+	if s.replicas != nil {
+		router.Use(middleware.ReadYourWrites(s.replicas))
+	}
+
+	if s.health != nil {
+		router.GET("/healthz", s.health.Liveness)
+		router.GET("/readyz", s.health.Readiness)
+	}
+
+	// the health routes above are not limited.
+	if s.limiter != nil {
+		router.Use(middleware.RateLimit(s.limiter))
+	}
 
 	personCtrl := router.Group("/person")
 	personCtrl.GET("", s.person.GetAll)
 	personCtrl.POST("/create", s.person.Create)
+	personCtrl.GET("/duplicates", s.require(feature.Duplicates), s.person.Duplicates)
+	personCtrl.GET("/tags", s.person.Tags)
 	personCtrl.GET("/:id/info", s.person.GetByID)
+	personCtrl.POST("/:id/merge", s.require(feature.Merge), s.person.Merge)
+	personCtrl.POST("/:id/unmerge", s.require(feature.Merge), s.person.Unmerge)
+	personCtrl.POST("/:id/tags", s.person.AddTags)
+	personCtrl.DELETE("/:id/tags/:tag", s.person.RemoveTag)
+	personCtrl.PUT("/:id/labels/:key", s.person.SetLabel)
+	personCtrl.DELETE("/:id/labels/:key", s.person.RemoveLabel)
+	personCtrl.PUT("/:id/attributes", s.person.SetAttributes)
+
+	if s.relation != nil {
+		relationCtrl := personCtrl.Group("/:id/relations", s.require(feature.Relations))
+		relationCtrl.GET("", s.relation.GetAll)
+		relationCtrl.POST("", s.relation.Create)
+		relationCtrl.GET("/:rid", s.relation.Get)
+		relationCtrl.PUT("/:rid", s.relation.Update)
+		relationCtrl.DELETE("/:rid", s.relation.Delete)
+	}
+
+	adminCtrl := router.Group("/admin", middleware.AdminGuard(s.cfg.AdminToken))
+	if s.health != nil {
+		adminCtrl.GET("/health", s.health.Health)
+	}
+
+	if s.debug != nil {
+		adminCtrl.GET("/log-level", s.debug.GetLogLevel)
+		adminCtrl.PUT("/log-level", s.debug.SetLogLevel)
+	}
+
+	if s.attribute != nil {
+		adminCtrl.GET("/attributes", s.attribute.GetAll)
+		adminCtrl.POST("/attributes", s.attribute.Create)
+		adminCtrl.GET("/attributes/:name", s.attribute.Get)
+		adminCtrl.PUT("/attributes/:name", s.attribute.Update)
+		adminCtrl.DELETE("/attributes/:name", s.attribute.Delete)
+	}
 
 	s.router = router
 }
+
+// require answers 404 while the feature is switched off.
+func (s *Server) require(name string) gin.HandlerFunc {
+	if s.features == nil {
+		return func(ctx *gin.Context) { ctx.Next() }
+	}
+	return feature.Require(s.features, name)
+}
@@ -0,0 +1,248 @@
+package server_test
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"qore-be/internal/certs"
+	"qore-be/internal/certs/certstest"
+	"qore-be/internal/config"
+	"qore-be/internal/domain/dto"
+	"qore-be/internal/health"
+	"qore-be/internal/mocks"
+	"qore-be/internal/person"
+	"qore-be/internal/server"
+	"strings"
+	"time"
+
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+)
+
+// newServer serves the persons of the repository, whose reads of the person
+// 1 block until released.
+func newServer(t *testing.T, cfg *config.Config, opts ...server.Option) (srv *server.Server, started chan struct{}, release chan struct{}) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	started, release = make(chan struct{}), make(chan struct{})
+	repo := mocks.NewPersonRepository(t)
+	repo.On("GetByID", mock.Anything, 1).
+		Run(func(mock.Arguments) {
+			close(started)
+			<-release
+		}).
+		Return(dto.PersonDTO{ID: 1, Name: "Ada"}, nil).Maybe()
+
+	svc, err := person.NewService(person.WithRepository(repo))
+	require.NoError(t, err)
+	personCtrl, err := person.NewController(person.WithService(svc))
+	require.NoError(t, err)
+	healthCtrl, err := health.NewController()
+	require.NoError(t, err)
+
+	srv = server.New(append([]server.Option{
+		server.WithConfig(cfg),
+		server.WithPersonController(personCtrl),
+		server.WithHealthController(healthCtrl),
+	}, opts...)...)
+	return srv, started, release
+}
+
+func serve(t *testing.T, ctx context.Context, srv *server.Server) (url string, done chan error) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	done = make(chan error, 1)
+	go func() { done <- srv.Serve(ctx, ln) }()
+	return "http://" + ln.Addr().String(), done
+}
+
+func get(url string) (int, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+func TestServer_Serve(t *testing.T) {
+	cfg := &config.Config{ShutdownDelay: 200 * time.Millisecond, ShutdownTimeout: 5 * time.Second}
+	srv, started, release := newServer(t, cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	url, done := serve(t, ctx, srv)
+
+	code, err := get(url + "/readyz")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	inFlight := make(chan int, 1)
+	go func() {
+		code, err := get(url + "/person/1/info")
+		assert.NoError(t, err)
+		inFlight <- code
+	}()
+	<-started
+	cancel()
+
+	// the readiness fails first, while the server still accepts requests.
+	assert.Eventually(t, func() bool {
+		code, err := get(url + "/readyz")
+		return err == nil && code == http.StatusServiceUnavailable
+	}, time.Second, 10*time.Millisecond)
+
+	// then the server waits for the requests in flight.
+	select {
+	case err := <-done:
+		t.Fatalf("the server stopped before the request in flight ended: %v", err)
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	close(release)
+	assert.Equal(t, http.StatusOK, <-inFlight)
+	require.NoError(t, <-done)
+
+	_, err = get(url + "/readyz")
+	assert.Error(t, err, "the server no longer accepts connections")
+}
+
+func TestServer_ServeTimeout(t *testing.T) {
+	cfg := &config.Config{ShutdownTimeout: 100 * time.Millisecond}
+	srv, started, release := newServer(t, cfg)
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	url, done := serve(t, ctx, srv)
+
+	go func() {
+		_, _ = get(url + "/person/1/info")
+	}()
+	<-started
+	cancel()
+
+	assert.ErrorContains(t, <-done, "failed to drain the requests")
+}
+
+func TestServer_Start(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	srv, _, _ := newServer(t, &config.Config{Host: ln.Addr().String(), ShutdownTimeout: time.Second})
+	assert.ErrorContains(t, srv.Start(context.Background()), "failed to listen on "+ln.Addr().String())
+}
+
+func TestServer_Limits(t *testing.T) {
+	cfg := &config.Config{
+		ShutdownTimeout:         time.Second,
+		ServerReadHeaderTimeout: 100 * time.Millisecond,
+		ServerMaxHeaderBytes:    1024,
+		ServerMaxBodyBytes:      64,
+	}
+	srv, _, _ := newServer(t, cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	url, _ := serve(t, ctx, srv)
+
+	resp, err := http.Post(url+"/person/create", "application/json", strings.NewReader(`{"name":"`+strings.Repeat("a", 64)+`"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+
+	req, err := http.NewRequest(http.MethodGet, url+"/healthz", nil)
+	require.NoError(t, err)
+	// the limit is enforced with some slack by net/http.
+	req.Header.Set("X-Large", strings.Repeat("a", 8192))
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusRequestHeaderFieldsTooLarge, resp.StatusCode)
+
+	// a client too slow to send its headers is disconnected.
+	conn, err := net.Dial("tcp", strings.TrimPrefix(url, "http://"))
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("GET /healthz HTTP/1.1\r\n"))
+	require.NoError(t, err)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	_, err = io.ReadAll(conn)
+	assert.NoError(t, err, "the server closed the connection")
+}
+
+func TestServer_TLS(t *testing.T) {
+	ca := certstest.NewCA(t, "ca")
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	ca.Issue(t, "server", certFile, keyFile)
+	clientCert := ca.Issue(t, "client", filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key"))
+
+	loader, err := certs.NewLoader(certFile, keyFile, certs.WithClientCA(ca.File, tls.RequireAndVerifyClientCert))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		http2 bool
+		proto string
+	}{
+		{name: "HTTP/2", http2: true, proto: "HTTP/2.0"},
+		{name: "HTTP/1.1", proto: "HTTP/1.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _, _ := newServer(t, &config.Config{ShutdownTimeout: time.Second, ServerHTTP2: tt.http2}, server.WithTLS(loader))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			url, _ := serve(t, ctx, srv)
+			url = strings.Replace(url, "http://", "https://", 1)
+
+			tlsCfg := &tls.Config{RootCAs: ca.Pool}
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsCfg, ForceAttemptHTTP2: true}}
+			_, err := client.Get(url + "/healthz")
+			assert.Error(t, err, "a client certificate is required")
+
+			tlsCfg.Certificates = []tls.Certificate{clientCert}
+			client = &http.Client{Transport: &http.Transport{TLSClientConfig: tlsCfg, ForceAttemptHTTP2: true}}
+			resp, err := client.Get(url + "/healthz")
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, tt.proto, resp.Proto)
+		})
+	}
+}
+
+func TestServer_H2C(t *testing.T) {
+	srv, _, _ := newServer(t, &config.Config{ShutdownTimeout: time.Second, ServerH2C: true})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	url, _ := serve(t, ctx, srv)
+
+	// HTTP/2 with prior knowledge over cleartext.
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network string, addr string, _ *tls.Config) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, addr)
+		},
+	}}
+	resp, err := client.Get(url + "/healthz")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "HTTP/2.0", resp.Proto)
+}
@@ -0,0 +1,131 @@
+package tenant
+
+import (
+	"context"
+	"net/http"
+	"net/netip"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Header is the request header holding the tenant ID.
+const Header = "X-Tenant-ID"
+
+// Default is the tenant of the requests that do not name one.
+const Default = "default"
+
+type ctxKey struct{}
+
+var validID = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// NewContext returns a copy of ctx holding the tenant ID.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the tenant ID held by ctx, or the default tenant.
+func FromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(ctxKey{}).(string); ok && id != "" {
+		return id
+	}
+	return Default
+}
+
+type resolver struct {
+	proxies     []netip.Prefix
+	certificate bool
+}
+
+// Option ..
+type Option func(*resolver)
+
+// WithTrustedProxies accepts the tenant header from the clients of the
+// networks, e.g. the proxies authenticating the requests.
+func WithTrustedProxies(networks ...netip.Prefix) Option {
+	return func(r *resolver) {
+		r.proxies = append(r.proxies, networks...)
+	}
+}
+
+// WithClientCertificate names the tenant of the clients verified by their
+// TLS certificate after the common name of the certificate.
+func WithClientCertificate() Option {
+	return func(r *resolver) {
+		r.certificate = true
+	}
+}
+
+// Middleware stores the tenant of the request in the request context: the
+// tenant of the client certificate, else the one named by the header, else
+// the default tenant.
+//
+// The header is only accepted from the trusted proxies: the other clients
+// naming a tenant are refused, as they could act on behalf of any tenant.
+func Middleware(opts ...Option) gin.HandlerFunc {
+	r := &resolver{}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return func(ctx *gin.Context) {
+		id, err := r.resolve(ctx.Request)
+		if err != nil {
+			ctx.AbortWithStatusJSON(err.status, gin.H{"error": err.msg})
+			return
+		}
+
+		ctx.Request = ctx.Request.WithContext(NewContext(ctx.Request.Context(), id))
+		ctx.Next()
+	}
+}
+
+type resolveError struct {
+	status int
+	msg    string
+}
+
+func (r *resolver) resolve(req *http.Request) (string, *resolveError) {
+	header := req.Header.Get(Header)
+
+	if r.certificate && req.TLS != nil && len(req.TLS.VerifiedChains) > 0 {
+		id := req.TLS.VerifiedChains[0][0].Subject.CommonName
+		if !validID.MatchString(id) {
+			return "", &resolveError{http.StatusForbidden, "invalid tenant in the client certificate"}
+		}
+		if header != "" && header != id {
+			return "", &resolveError{http.StatusForbidden, "tenant not allowed"}
+		}
+		return id, nil
+	}
+
+	if header == "" {
+		return Default, nil
+	}
+
+	if !validID.MatchString(header) {
+		return "", &resolveError{http.StatusBadRequest, "invalid tenant"}
+	}
+
+	if !r.trusted(req.RemoteAddr) {
+		return "", &resolveError{http.StatusForbidden, "tenant not allowed"}
+	}
+	return header, nil
+}
+
+// trusted tells whether the client, not the one named by any forwarding
+// header, is a trusted proxy.
+func (r *resolver) trusted(remoteAddr string) bool {
+	addr, err := netip.ParseAddrPort(remoteAddr)
+	if err != nil {
+		return false
+	}
+
+	ip := addr.Addr().Unmap()
+	for _, p := range r.proxies {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
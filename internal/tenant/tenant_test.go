@@ -0,0 +1,126 @@
+package tenant_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"qore-be/internal/tenant"
+
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	verified := func(name string) *tls.ConnectionState {
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: name}}
+		return &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	}
+
+	cases := []struct {
+		name     string
+		opts     []tenant.Option
+		header   string
+		ip       string
+		tls      *tls.ConnectionState
+		status   int
+		expected string
+	}{
+		{
+			name:     "no tenant",
+			ip:       "192.0.2.1",
+			status:   http.StatusOK,
+			expected: tenant.Default,
+		},
+		{
+			name:   "header from an untrusted client",
+			header: "acme",
+			ip:     "192.0.2.1",
+			status: http.StatusForbidden,
+		},
+		{
+			name:     "header from a trusted proxy",
+			opts:     []tenant.Option{tenant.WithTrustedProxies(netip.MustParsePrefix("10.0.0.0/8"))},
+			header:   "acme",
+			ip:       "10.1.2.3",
+			status:   http.StatusOK,
+			expected: "acme",
+		},
+		{
+			name:   "invalid header",
+			opts:   []tenant.Option{tenant.WithTrustedProxies(netip.MustParsePrefix("10.0.0.0/8"))},
+			header: "acme corp",
+			ip:     "10.1.2.3",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:     "client certificate",
+			opts:     []tenant.Option{tenant.WithClientCertificate()},
+			ip:       "192.0.2.1",
+			tls:      verified("acme"),
+			status:   http.StatusOK,
+			expected: "acme",
+		},
+		{
+			name:     "client certificate and the same header",
+			opts:     []tenant.Option{tenant.WithClientCertificate()},
+			header:   "acme",
+			ip:       "192.0.2.1",
+			tls:      verified("acme"),
+			status:   http.StatusOK,
+			expected: "acme",
+		},
+		{
+			name:   "client certificate and another header",
+			opts:   []tenant.Option{tenant.WithClientCertificate(), tenant.WithTrustedProxies(netip.MustParsePrefix("0.0.0.0/0"))},
+			header: "globex",
+			ip:     "192.0.2.1",
+			tls:    verified("acme"),
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "invalid client certificate",
+			opts:   []tenant.Option{tenant.WithClientCertificate()},
+			ip:     "192.0.2.1",
+			tls:    verified("Acme Corp"),
+			status: http.StatusForbidden,
+		},
+		{
+			name:     "client certificate ignored",
+			ip:       "192.0.2.1",
+			tls:      verified("acme"),
+			status:   http.StatusOK,
+			expected: tenant.Default,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(tenant.Middleware(tc.opts...))
+			router.GET("/", func(ctx *gin.Context) {
+				ctx.String(http.StatusOK, tenant.FromContext(ctx.Request.Context()))
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.header != "" {
+				req.Header.Set(tenant.Header, tc.header)
+			}
+			req.RemoteAddr = tc.ip + ":1234"
+			req.TLS = tc.tls
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.status, w.Code)
+			if tc.status == http.StatusOK {
+				assert.Equal(t, tc.expected, w.Body.String())
+			}
+		})
+	}
+}
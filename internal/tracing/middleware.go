@@ -0,0 +1,57 @@
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Middleware creates a server span for every request. The trace context of
+// the caller is extracted from the traceparent header, and the one of the
+// request is written back in the response headers.
+//
+// The span is stored in the request context, so the handlers must pass it
+// down (gin.Context does when ContextWithFallback is set).
+func Middleware(tp trace.TracerProvider) gin.HandlerFunc {
+	tracer := tp.Tracer(instrumentation)
+	propagator := propagation.TraceContext{}
+
+	return func(ctx *gin.Context) {
+		parent := propagator.Extract(ctx.Request.Context(), propagation.HeaderCarrier(ctx.Request.Header))
+
+		route := ctx.FullPath()
+		name := ctx.Request.Method
+		if route != "" {
+			name = fmt.Sprintf("%s %s", ctx.Request.Method, route)
+		}
+
+		spanCtx, span := tracer.Start(parent, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(ctx.Request.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(ctx.Request.URL.Path),
+			),
+		)
+		defer span.End()
+
+		propagator.Inject(spanCtx, propagation.HeaderCarrier(ctx.Writer.Header()))
+		ctx.Request = ctx.Request.WithContext(spanCtx)
+
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		if len(ctx.Errors) > 0 {
+			span.RecordError(ctx.Errors.Last())
+		}
+	}
+}
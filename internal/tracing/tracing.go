@@ -0,0 +1,70 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Span exporters.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// ServiceName is the name the spans are reported under.
+const ServiceName = "qore-be"
+
+// instrumentation is the name of the tracers created by this project.
+const instrumentation = "qore-be"
+
+// NewProvider creates the tracer provider exporting the spans with the given
+// exporter, and installs it along with the W3C trace context propagator as
+// the global ones. With the "none" exporter, spans are still created so that
+// the trace context is propagated, but they are not exported.
+//
+// The OTLP exporter sends the spans over HTTP to the endpoint, or to the one
+// set by the standard OTEL_EXPORTER_OTLP_* variables when it is empty.
+func NewProvider(ctx context.Context, exporter string, endpoint string) (*sdktrace.TracerProvider, error) {
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(ServiceName))),
+	}
+
+	switch exporter {
+	case ExporterNone, "":
+	case ExporterStdout:
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create stdout exporter: %v", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exp))
+	case ExporterOTLP:
+		var expOpts []otlptracehttp.Option
+		if endpoint != "" {
+			expOpts = append(expOpts, otlptracehttp.WithEndpointURL(endpoint))
+		}
+
+		exp, err := otlptracehttp.New(ctx, expOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP exporter: %v", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exp))
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter: %q", exporter)
+	}
+
+	tp := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return tp, nil
+}
@@ -0,0 +1,123 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"qore-be/internal/domain/entities"
+	"qore-be/internal/tracing"
+
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func newProvider() (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	exp := tracetest.NewInMemoryExporter()
+	return sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp)), exp
+}
+
+func attr(span tracetest.SpanStub, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestNewProvider(t *testing.T) {
+	for _, exporter := range []string{tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP} {
+		tp, err := tracing.NewProvider(context.TODO(), exporter, "http://localhost:4318")
+		assert.NoError(t, err, exporter)
+		assert.NoError(t, tp.Shutdown(context.TODO()))
+	}
+
+	_, err := tracing.NewProvider(context.TODO(), "jaeger", "")
+	assert.Error(t, err)
+}
+
+func TestMiddleware(t *testing.T) {
+	tp, exp := newProvider()
+
+	srv := gin.Default()
+	gin.SetMode(gin.TestMode)
+	srv.ContextWithFallback = true
+
+	var handlerSpan trace.SpanContext
+	srv.Use(tracing.Middleware(tp))
+	srv.GET("/person/:id/info", func(ctx *gin.Context) {
+		handlerSpan = trace.SpanContextFromContext(ctx)
+		ctx.Status(http.StatusInternalServerError)
+	})
+
+	rec := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/person/1/info", nil)
+	require.NoError(t, err)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	srv.ServeHTTP(rec, req)
+
+	spans := exp.GetSpans()
+	require.Len(t, spans, 1)
+
+	span := spans[0]
+	assert.Equal(t, "GET /person/:id/info", span.Name)
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent.SpanID().String())
+	assert.Equal(t, int64(500), attr(span, "http.response.status_code").AsInt64())
+	assert.Equal(t, codes.Error, span.Status.Code)
+
+	// the span is handed to the handlers and propagated back to the caller.
+	assert.Equal(t, span.SpanContext.SpanID(), handlerSpan.SpanID())
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-"+span.SpanContext.SpanID().String()+"-01",
+		rec.Header().Get("traceparent"))
+}
+
+func TestGormPlugin(t *testing.T) {
+	tp, exp := newProvider()
+
+	db, err := gorm.Open(mysql.New(mysql.Config{SkipInitializeWithVersion: true}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	require.NoError(t, err)
+	require.NoError(t, db.Use(tracing.GormPlugin(tp, "mysql")))
+
+	ctx, parent := tp.Tracer("test").Start(context.TODO(), "parent")
+	p := entities.Person{}
+	db.WithContext(ctx).Where("name = ? AND age > 18", "Jane").First(&p)
+	parent.End()
+
+	spans := exp.GetSpans()
+	require.Len(t, spans, 2)
+
+	span := spans[0]
+	assert.Equal(t, "gorm.select", span.Name)
+	assert.Equal(t, parent.SpanContext().SpanID(), span.Parent.SpanID())
+	assert.Equal(t, "person", attr(span, "db.collection.name").AsString())
+
+	query := attr(span, "db.query.text").AsString()
+	assert.Contains(t, query, "age > ?")
+	assert.NotContains(t, query, "Jane")
+	assert.NotContains(t, query, "18")
+}
+
+func TestSanitize(t *testing.T) {
+	cases := map[string]string{
+		"SELECT * FROM `person` WHERE name = 'O''Brien' LIMIT 10": "SELECT * FROM `person` WHERE name = ? LIMIT ?",
+		`SELECT * FROM "person" WHERE age >= 18.5`:                `SELECT * FROM "person" WHERE age >= ?`,
+		"SELECT t1.id FROM person t1 WHERE t1.id IN (?,?)":        "SELECT t1.id FROM person t1 WHERE t1.id IN (?,?)",
+	}
+
+	for in, expected := range cases {
+		assert.Equal(t, expected, tracing.Sanitize(in))
+	}
+}